@@ -0,0 +1,318 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/retr0h/gilt/v2/pkg/config"
+	"github.com/retr0h/gilt/v2/pkg/report"
+	"github.com/retr0h/gilt/v2/pkg/repositories"
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and maintain the clone cache",
+	Long: `Inspect and maintain the bare clones gilt keeps under <giltDir>/cache, and
+the archives it unpacks under <giltDir>/archives.  Every subcommand holds
+gilt's lock, so it never races an overlay.`,
+}
+
+// cacheListCmd represents the cache list command
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the clones and archives in the cache",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		initCacheConfig()
+		initLogger()
+
+		repos := repositories.New(
+			appConfig,
+			logger,
+		)
+		entries, err := repos.CacheListContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+		if jsonOutput() {
+			return printJSON(entries)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "SOURCE\tSIZE\tLAST FETCH\tLAST USED")
+		for _, e := range entries {
+			_, _ = fmt.Fprintf(
+				w,
+				"%s\t%s\t%s\t%s\n",
+				cacheSource(e),
+				formatSize(e.Size),
+				formatTime(e.LastFetch),
+				formatTime(e.LastUsed),
+			)
+		}
+		return w.Flush()
+	},
+}
+
+// cacheInspectCmd represents the cache inspect command
+var cacheInspectCmd = &cobra.Command{
+	Use:   "inspect <repository>",
+	Short: "Describe the clone of a repository, and list its tags",
+	Long: `Describe the clone of a repository in the cache, and list its tags.  The
+repository is named by its URL, by the base name of that URL without the .git
+suffix, or by the name of its clone.  An unpacked archive is named by its
+SHA-256 checksum.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		initCacheConfig()
+		initLogger()
+
+		repos := repositories.New(
+			appConfig,
+			logger,
+		)
+		entry, err := repos.CacheInspectContext(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		if jsonOutput() {
+			return printJSON([]report.CacheEntry{entry})
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		if entry.SHA256 != "" {
+			_, _ = fmt.Fprintf(w, "SHA256:\t%s\n", entry.SHA256)
+		} else {
+			_, _ = fmt.Fprintf(w, "Git:\t%s\n", orDash(entry.Git))
+		}
+		_, _ = fmt.Fprintf(w, "Dir:\t%s\n", entry.Dir)
+		_, _ = fmt.Fprintf(w, "Size:\t%s\n", formatSize(entry.Size))
+		_, _ = fmt.Fprintf(w, "Last fetch:\t%s\n", formatTime(entry.LastFetch))
+		_, _ = fmt.Fprintf(w, "Last used:\t%s\n", formatTime(entry.LastUsed))
+		_, _ = fmt.Fprintf(w, "Tags:\t%s\n", orDash(strings.Join(entry.Tags, ", ")))
+		return w.Flush()
+	},
+}
+
+// cacheVerifyCmd represents the cache verify command
+var cacheVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Run git fsck on each clone, and re-clone corrupt ones",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		initCacheConfig()
+		initLogger()
+
+		repos := repositories.New(
+			appConfig,
+			logger,
+		)
+		checks, err := repos.CacheVerifyContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput() {
+			if err := printJSON(checks); err != nil {
+				return err
+			}
+		} else {
+			printChecks(checks)
+		}
+
+		failed := false
+		for _, c := range checks {
+			if !c.OK && !c.Repaired {
+				failed = true
+			}
+		}
+		if failed {
+			return errors.New("cache has corrupt clones")
+		}
+		return nil
+	},
+}
+
+// printChecks prints the outcome of verifying each clone.
+func printChecks(checks []report.CacheCheck) {
+	for _, c := range checks {
+		switch {
+		case c.OK:
+			fmt.Printf("ok        %s\n", orDash(c.Git))
+		case c.Repaired:
+			fmt.Printf("repaired  %s\n", c.Git)
+		default:
+			fmt.Printf("corrupt   %s: %s\n", c.Dir, c.Error)
+		}
+	}
+}
+
+// cachePruneCmd represents the cache prune command
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove clones and archives which have not been used recently",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cmd.SilenceUsage = true
+
+		value, _ := cmd.Flags().GetString("unused-for")
+		unusedFor, err := parseAge(value)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceErrors = true
+
+		initCacheConfig()
+		initLogger()
+
+		repos := repositories.New(
+			appConfig,
+			logger,
+		)
+		pruned, err := repos.CachePruneContext(cmd.Context(), unusedFor)
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput() {
+			return printJSON(pruned)
+		}
+		for _, e := range pruned {
+			fmt.Printf("removed %s (%s)\n", cacheSource(e), formatSize(e.Size))
+		}
+		return nil
+	},
+}
+
+// initCacheConfig load only what the cache subcommands need, the gilt dir and
+// how Git operations are performed, so they work without a Giltfile.  One
+// which exists is still read, as it may set them.
+func initCacheConfig() {
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+
+	viper.SetConfigType("yaml")
+	viper.AutomaticEnv()
+	viper.SetEnvPrefix("gilt")
+	viper.SetConfigFile(viper.GetString("giltFile"))
+
+	if _, err := os.Stat(viper.ConfigFileUsed()); err == nil {
+		if err := viper.ReadInConfig(); err != nil {
+			logFatal(
+				"failed to read config",
+				slog.Group(
+					"",
+					slog.String("Giltfile", viper.ConfigFileUsed()),
+					slog.String("err", err.Error()),
+				),
+			)
+		}
+	}
+
+	appConfig = config.Repositories{
+		Debug:      viper.GetBool("debug"),
+		GiltDir:    viper.GetString("giltDir"),
+		GitBackend: viper.GetString("gitBackend"),
+	}
+	switch appConfig.GitBackend {
+	case "", config.GitBackendExec, config.GitBackendNative:
+	default:
+		logFatal(
+			"validation failed",
+			slog.String("err", fmt.Sprintf("invalid git backend %q", appConfig.GitBackend)),
+		)
+	}
+}
+
+// cacheSource names what `e` was cloned or unpacked from.
+func cacheSource(e report.CacheEntry) string {
+	if e.SHA256 != "" {
+		return "archive " + e.SHA256
+	}
+	return orDash(e.Git)
+}
+
+// parseAge parses a duration, which may also be given in days (e.g. "30d").
+func parseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return d, nil
+}
+
+// formatSize formats `size` bytes for humans.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// formatTime formats `t` for humans, or "-" when it is unknown.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+func init() {
+	cachePruneCmd.Flags().
+		String("unused-for", "30d", "Remove clones and archives unused for this long (e.g. 30d, 12h)")
+
+	cacheCmd.AddCommand(cacheListCmd)
+	cacheCmd.AddCommand(cacheInspectCmd)
+	cacheCmd.AddCommand(cacheVerifyCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
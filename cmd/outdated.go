@@ -0,0 +1,90 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/retr0h/gilt/v2/pkg/report"
+	"github.com/retr0h/gilt/v2/pkg/repositories"
+)
+
+// outdatedCmd represents the outdated command
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "List newer upstream tags for each repository",
+	Long: `Fetch each repository in the Giltfile into the clone cache, and compare
+its configured version with the newest tag, and the newest tag sharing the same
+major version.`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		initConfig()
+		initLogger()
+
+		repos := repositories.New(
+			appConfig,
+			logger,
+		)
+		results, err := repos.OutdatedContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput() {
+			return printJSON(results)
+		}
+		return printOutdatedTable(results)
+	},
+}
+
+func printOutdatedTable(results []report.Outdated) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "GIT\tVERSION\tLATEST\tLATEST IN MAJOR")
+	for _, o := range results {
+		_, _ = fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\n",
+			o.Git,
+			o.Version,
+			orDash(o.Latest),
+			orDash(o.LatestInMajor),
+		)
+	}
+	return w.Flush()
+}
+
+// orDash returns `s`, or "-" when it is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func init() {
+	rootCmd.AddCommand(outdatedCmd)
+}
@@ -21,8 +21,13 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
+	"github.com/retr0h/gilt/v2/pkg/report"
 	"github.com/retr0h/gilt/v2/pkg/repositories"
 )
 
@@ -31,7 +36,10 @@ var overlayCmd = &cobra.Command{
 	Use:   "overlay",
 	Short: "Install Gilt dependencies",
 	Long: `Overlay the repositories from the Giltfile into their respective
-destinations.`,
+destinations.  With --only, or --skip, just some of them are fetched and
+overlaid; they are named as gilt update names them.  With --label, just
+those whose labels match the expression, such as 'helm && !experimental',
+are.`,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		// By the time we reach this point, we know that the arguments were
 		// properly parsed, and we don't want to show the usage if an error
@@ -47,10 +55,89 @@ destinations.`,
 			appConfig,
 			logger,
 		)
-		return repos.Overlay()
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			plans, err := repos.PlanContext(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if jsonOutput() {
+				return printJSON(plans)
+			}
+			printPlans(plans)
+			return nil
+		}
+
+		results, err := repos.OverlayReportContext(cmd.Context())
+		if jsonOutput() {
+			// The report covers a failed overlay too
+			return errors.Join(err, printJSON(results))
+		}
+		return err
 	},
 }
 
+func printPlans(plans []report.Plan) {
+	for _, p := range plans {
+		fmt.Println(heading(p.Git, p.Archive, p.Path, p.Version, p.Tag, p.Commit))
+		for _, command := range p.PreCommands {
+			fmt.Printf("  pre        %s\n", command)
+		}
+		for _, command := range p.BuildCommands {
+			fmt.Printf("  build      %s\n", command)
+		}
+		for _, path := range p.Delete {
+			fmt.Printf("  delete     %s\n", path)
+		}
+		for _, path := range p.Create {
+			fmt.Printf("  create     %s\n", path)
+		}
+		for _, path := range p.Overwrite {
+			fmt.Printf("  overwrite  %s\n", path)
+		}
+		for _, command := range p.Commands {
+			fmt.Printf("  run        %s\n", command)
+		}
+	}
+}
+
+// heading describes a repository by where it is vendored from, and what that
+// resolved to.  A local path, and a repository no longer in the Giltfile whose
+// files are pruned, resolve to nothing.
+func heading(git, archive, path, version, tag, commit string) string {
+	switch {
+	case path != "":
+		return path
+	case commit == "" && archive != "":
+		return archive
+	case commit == "":
+		return git
+	case archive != "":
+		return fmt.Sprintf("%s (%s)", archive, commit)
+	case tag != "":
+		return fmt.Sprintf("%s@%s -> %s (%s)", git, version, tag, commit)
+	}
+
+	return fmt.Sprintf("%s@%s (%s)", git, version, commit)
+}
+
 func init() {
+	overlayCmd.Flags().
+		Bool("locked", false, "Fail if any version resolves differently than in Giltfile.lock")
+	_ = viper.BindPFlag("locked", overlayCmd.Flags().Lookup("locked"))
+	overlayCmd.Flags().
+		Bool("prune", false, "Delete files earlier overlays installed which are no longer produced")
+	_ = viper.BindPFlag("prune", overlayCmd.Flags().Lookup("prune"))
+	overlayCmd.Flags().
+		StringSlice("only", nil, "Overlay only the named repositories (e.g. etcd,consul)")
+	_ = viper.BindPFlag("only", overlayCmd.Flags().Lookup("only"))
+	overlayCmd.Flags().
+		StringSlice("skip", nil, "Do not overlay the named repositories")
+	_ = viper.BindPFlag("skip", overlayCmd.Flags().Lookup("skip"))
+	overlayCmd.Flags().
+		String("label", "", "Overlay only the repositories whose labels match the expression (e.g. 'helm && !experimental')")
+	_ = viper.BindPFlag("label", overlayCmd.Flags().Lookup("label"))
+	overlayCmd.Flags().
+		Bool("dry-run", false, "Print what would be deleted, written, and run, without changing anything")
+
 	rootCmd.AddCommand(overlayCmd)
 }
@@ -21,11 +21,15 @@
 package cmd
 
 import (
+	"context"
 	_ "embed"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/lmittmann/tint"
@@ -48,17 +52,35 @@ const (
 	website = "https://github.com/retr0h/gilt"
 )
 
+// Formats commands print their results in, selected with --output.
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "gilt",
 	Short: "A GIT layering command line tool",
 	Long:  fmt.Sprintf("%sgilt: %s\n%s", asciiArt, desc, website),
+	PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+		switch output := viper.GetString("output"); output {
+		case outputText, outputJSON:
+			return nil
+		default:
+			return fmt.Errorf("invalid output %q (text|json)", output)
+		}
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// An interrupt cancels the command's context, which kills any process it is
+// still running.
 func Execute() {
-	err := rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
 	if err != nil {
 		os.Exit(1)
 	}
@@ -67,17 +89,27 @@ func Execute() {
 func init() {
 	rootCmd.PersistentFlags().BoolP("debug", "d", false, "Enable or disable debug mode")
 	rootCmd.PersistentFlags().BoolP("parallel", "p", true, "Fetch clones in parallel")
-	rootCmd.PersistentFlags().Bool("no-commands", false, "Skip post-commands when overlaying")
+	rootCmd.PersistentFlags().
+		Bool("no-commands", false, "Skip pre- and post-commands when overlaying")
 	rootCmd.PersistentFlags().
 		StringP("gilt-dir", "c", "~/.gilt/clone", "Path to Gilt's clone dir")
 	rootCmd.PersistentFlags().
 		StringP("gilt-file", "f", "Giltfile.yaml", "Path to config file")
+	rootCmd.PersistentFlags().
+		String("git-backend", config.GitBackendExec, "Git backend to use (exec|native)")
+	rootCmd.PersistentFlags().
+		String("replace", "", "Path to a file replacing repositories with local paths")
+	rootCmd.PersistentFlags().
+		StringP("output", "o", outputText, "Output format of results and logs (text|json)")
 
 	_ = viper.BindPFlag("debug", rootCmd.PersistentFlags().Lookup("debug"))
 	_ = viper.BindPFlag("parallel", rootCmd.PersistentFlags().Lookup("parallel"))
 	_ = viper.BindPFlag("skipCommands", rootCmd.PersistentFlags().Lookup("no-commands"))
 	_ = viper.BindPFlag("giltFile", rootCmd.PersistentFlags().Lookup("gilt-file"))
 	_ = viper.BindPFlag("giltDir", rootCmd.PersistentFlags().Lookup("gilt-dir"))
+	_ = viper.BindPFlag("gitBackend", rootCmd.PersistentFlags().Lookup("git-backend"))
+	_ = viper.BindPFlag("replace", rootCmd.PersistentFlags().Lookup("replace"))
+	_ = viper.BindPFlag("output", rootCmd.PersistentFlags().Lookup("output"))
 	_ = viper.BindPFlag("repositories", rootCmd.PersistentFlags().Lookup("repositories"))
 
 	cobra.OnInitialize(initLogger)
@@ -98,6 +130,17 @@ func initLogger() {
 		logLevel = slog.LevelDebug
 	}
 
+	// Machine-readable output leaves stdout to the results, and logs in a
+	// form which can be parsed too
+	if jsonOutput() {
+		logger = slog.New(
+			slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
+				Level: logLevel,
+			}),
+		)
+		return
+	}
+
 	logger = slog.New(
 		tint.NewHandler(os.Stderr, &tint.Options{
 			Level:      logLevel,
@@ -107,6 +150,23 @@ func initLogger() {
 	)
 }
 
+// jsonOutput reports whether results are printed as JSON.
+func jsonOutput() bool {
+	return viper.GetString("output") == outputJSON
+}
+
+// printJSON prints `results` to stdout as a single JSON document; an empty
+// list, rather than null, when there are none.
+func printJSON[T any](results []T) error {
+	if results == nil {
+		results = []T{}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
 func initConfig() {
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
@@ -0,0 +1,107 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/retr0h/gilt/v2/pkg/report"
+	"github.com/retr0h/gilt/v2/pkg/repositories"
+)
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Detect drift between destinations and their pinned versions",
+	Long: `Extract each repository's pinned version into a temporary worktree, and
+compare it with what was overlaid into its destinations.  Files which were
+modified, deleted, or added locally are reported, and the command exits
+non-zero when anything drifted.`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		initConfig()
+		initLogger()
+
+		repos := repositories.New(
+			appConfig,
+			logger,
+		)
+		results, err := repos.StatusContext(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		drifted := anyDrifted(results)
+		if jsonOutput() {
+			if err := printJSON(results); err != nil {
+				return err
+			}
+		} else {
+			printStatus(results)
+		}
+
+		if drifted {
+			return errors.New("destinations have drifted")
+		}
+		return nil
+	},
+}
+
+// anyDrifted reports whether any repository drifted.
+func anyDrifted(results []report.Status) bool {
+	for _, s := range results {
+		if s.Drifted() {
+			return true
+		}
+	}
+	return false
+}
+
+// printStatus prints the drifted files of each repository.
+func printStatus(results []report.Status) {
+	for _, s := range results {
+		if !s.Drifted() {
+			continue
+		}
+		fmt.Println(heading(s.Git, s.Archive, s.Path, s.Version, s.Tag, s.Commit))
+		for _, path := range s.Modified {
+			fmt.Printf("  modified  %s\n", path)
+		}
+		for _, path := range s.Deleted {
+			fmt.Printf("  deleted   %s\n", path)
+		}
+		for _, path := range s.Added {
+			fmt.Printf("  added     %s\n", path)
+		}
+	}
+	if !anyDrifted(results) {
+		fmt.Println("no drift detected")
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/retr0h/gilt/v2/pkg/repositories"
+)
+
+// updateCmd represents the update command
+var updateCmd = &cobra.Command{
+	Use:   "update [repo...]",
+	Short: "Bump repository versions in the Giltfile to the newest tag",
+	Long: `Fetch each repository into the clone cache, and rewrite its version in the
+Giltfile to the newest tag, or to the newest tag satisfying --constraint.
+Comments, key order, and anchors in the Giltfile are kept.
+
+Repositories may be named by their name, their git url, or the base name of
+that url without the ".git" suffix.  Every repository is updated when none are
+named.
+Versions which are constraints, or not semantic versions, are left alone.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		initConfig()
+		initLogger()
+
+		constraint, _ := cmd.Flags().GetString("constraint")
+		repos := repositories.New(
+			appConfig,
+			logger,
+		)
+		results, err := repos.UpdateContext(cmd.Context(), constraint, args)
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput() {
+			return printJSON(results)
+		}
+		if len(results) == 0 {
+			fmt.Printf("%s is up to date\n", appConfig.GiltFile)
+			return nil
+		}
+		for _, u := range results {
+			fmt.Printf("updated %s from %s to %s\n", u.Git, u.From, u.To)
+		}
+		return nil
+	},
+}
+
+func init() {
+	updateCmd.Flags().
+		String("constraint", "", "Only update to tags satisfying this semantic version constraint")
+
+	rootCmd.AddCommand(updateCmd)
+}
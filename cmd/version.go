@@ -61,9 +61,14 @@ var (
 	versionCmd = &cobra.Command{
 		Use:   "version",
 		Short: "Display the version of tool",
-		Run: func(_ *cobra.Command, _ []string) {
+		Run: func(cmd *cobra.Command, _ []string) {
 			version := buildVersion(version, commit, date, builtBy, treeState)
 
+			// JSON unless text is asked for, as scripts parse it
+			if cmd.Flags().Changed("output") && !jsonOutput() {
+				fmt.Println(version.String())
+				return
+			}
 			jsonOut, _ := version.JSONString()
 			fmt.Println(jsonOut)
 		},
@@ -0,0 +1,319 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+// Package archive downloads release archives, verifies their checksum, and
+// unpacks them.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/avfs/avfs"
+)
+
+// New factory to create a new Archive instance.
+func New(
+	appFs avfs.VFS,
+	logger *slog.Logger,
+) *Archive {
+	return &Archive{
+		appFs:  appFs,
+		client: http.DefaultClient,
+		logger: logger,
+	}
+}
+
+// Fetch download the archive at `url`, verify its SHA-256 digest is
+// `checksum`, and unpack it into `dstDir`.  A gzip or bzip2 compressed
+// tarball, a plain tarball, and a zip file are recognized by their content.
+// When everything in the archive is below a single top-level directory, as in
+// most release tarballs, that directory becomes `dstDir`.  Nothing is written
+// to `dstDir` unless the whole archive was verified and unpacked.
+func (a *Archive) Fetch(ctx context.Context, url, checksum, dstDir string) error {
+	parent := a.appFs.Dir(dstDir)
+	if err := a.appFs.MkdirAll(parent, 0o700); err != nil {
+		return err
+	}
+
+	file, err := a.appFs.CreateTemp(parent, "download")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+		_ = a.appFs.Remove(file.Name())
+	}()
+
+	size, err := a.download(ctx, url, checksum, file)
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := a.appFs.MkdirTemp(parent, "unpack")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = a.appFs.RemoveAll(tmpDir) }()
+
+	a.logger.Debug("unpacking", slog.String("archive", url), slog.String("dstDir", tmpDir))
+	if err := a.unpack(file, size, tmpDir); err != nil {
+		return fmt.Errorf("unable to unpack %s: %s", url, err)
+	}
+
+	root, err := a.root(tmpDir)
+	if err != nil {
+		return err
+	}
+
+	return a.appFs.Rename(root, dstDir)
+}
+
+// download the archive at `url` into `file`, and return its size.
+func (a *Archive) download(
+	ctx context.Context,
+	url string,
+	checksum string,
+	file avfs.File,
+) (int64, error) {
+	a.logger.Info("downloading", slog.String("archive", url))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unable to download %s: %s", url, resp.Status)
+	}
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, h), resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("unable to download %s: %s", url, err)
+	}
+	if sum := fmt.Sprintf("%x", h.Sum(nil)); sum != checksum {
+		return 0, fmt.Errorf("%s has sha256 %s, expected %s", url, sum, checksum)
+	}
+
+	return size, nil
+}
+
+// unpack the archive in `file`, of `size` bytes, into `dstDir`.
+func (a *Archive) unpack(file avfs.File, size int64, dstDir string) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	br := bufio.NewReader(file)
+	magic, err := br.Peek(4)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")):
+		return a.unzip(file, size, dstDir)
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = gz.Close() }()
+		return a.untar(gz, dstDir)
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		return a.untar(bzip2.NewReader(br), dstDir)
+	default:
+		return a.untar(br, dstDir)
+	}
+}
+
+// untar unpack the tarball read from `r` into `dstDir`.
+func (a *Archive) untar(r io.Reader, dstDir string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		mode := hdr.FileInfo().Mode()
+		switch hdr.Typeflag {
+		case tar.TypeDir, tar.TypeReg, tar.TypeSymlink:
+		default:
+			a.logger.Debug("skipping entry", slog.String("name", hdr.Name))
+			continue
+		}
+		if err := a.writeEntry(dstDir, hdr.Name, mode, hdr.Linkname, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// unzip unpack the zip file in `file`, of `size` bytes, into `dstDir`.
+func (a *Archive) unzip(file avfs.File, size int64, dstDir string) error {
+	zr, err := zip.NewReader(file, size)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range zr.File {
+		if err := a.unzipEntry(f, dstDir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unzipEntry unpack the zip entry `f` into `dstDir`.
+func (a *Archive) unzipEntry(f *zip.File, dstDir string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rc.Close() }()
+
+	mode := f.Mode()
+	var linkname string
+	if mode&fs.ModeSymlink != 0 {
+		target, err := io.ReadAll(rc)
+		if err != nil {
+			return err
+		}
+		linkname = string(target)
+	}
+
+	return a.writeEntry(dstDir, f.Name, mode, linkname, rc)
+}
+
+// writeEntry create the archive entry `name` below `dstDir`; a directory, a
+// symlink to `linkname`, or a file with the contents of `r`.  Entries which
+// would be written, or link, outside of `dstDir` are refused, as are entries
+// below a symlink written earlier, which could lead anywhere once several
+// symlinks are chained.
+func (a *Archive) writeEntry(
+	dstDir string,
+	name string,
+	mode fs.FileMode,
+	linkname string,
+	r io.Reader,
+) error {
+	name = path.Clean(strings.TrimPrefix(name, "./"))
+	if name == "." {
+		return nil
+	}
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return fmt.Errorf("illegal path %s", name)
+	}
+	dst := a.appFs.Join(dstDir, a.appFs.FromSlash(name))
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		info, err := a.appFs.Lstat(a.appFs.Join(dstDir, a.appFs.FromSlash(dir)))
+		if err == nil && info.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("illegal path %s, %s is a link", name, dir)
+		}
+	}
+
+	if mode.IsDir() {
+		return a.appFs.MkdirAll(dst, 0o755)
+	}
+	if err := a.appFs.MkdirAll(a.appFs.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	if mode&fs.ModeSymlink != 0 {
+		target := path.Join(path.Dir(name), linkname)
+		if path.IsAbs(linkname) || climbs(linkname) ||
+			!filepath.IsLocal(filepath.FromSlash(target)) {
+			return fmt.Errorf("illegal link %s -> %s", name, linkname)
+		}
+		return a.appFs.Symlink(linkname, dst)
+	}
+
+	var perm fs.FileMode = 0o644
+	if mode&0o111 != 0 {
+		perm = 0o755
+	}
+	w, err := a.appFs.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		_ = w.Close()
+		return err
+	}
+
+	return w.Close()
+}
+
+// climbs reports whether the link target `linkname` goes up a directory after
+// going down one, e.g. `sub/link/..`.  Through a symlink, this does not lead
+// back where it started, so the target cannot be checked by its name alone.
+func climbs(linkname string) bool {
+	down := false
+	for _, elem := range strings.Split(linkname, "/") {
+		switch elem {
+		case "..":
+			if down {
+				return true
+			}
+		case ".", "":
+		default:
+			down = true
+		}
+	}
+
+	return false
+}
+
+// root returns the directory in `dir` holding everything unpacked; the single
+// top-level directory of the archive when there is one, otherwise `dir`.
+func (a *Archive) root(dir string) (string, error) {
+	entries, err := a.appFs.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		return a.appFs.Join(dir, entries[0].Name()), nil
+	}
+
+	return dir, nil
+}
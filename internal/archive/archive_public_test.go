@@ -0,0 +1,299 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package archive_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/vfs/osfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/retr0h/gilt/v2/internal"
+	"github.com/retr0h/gilt/v2/internal/archive"
+)
+
+// entry a file, directory, or symlink in a test archive.
+type entry struct {
+	name     string
+	body     string
+	mode     int64
+	linkname string
+}
+
+type ArchivePublicTestSuite struct {
+	suite.Suite
+
+	appFs  avfs.VFS
+	server *httptest.Server
+	files  map[string][]byte
+
+	dstDir string
+
+	am internal.ArchiveManager
+}
+
+func (suite *ArchivePublicTestSuite) NewTestArchiveManager() internal.ArchiveManager {
+	return archive.New(
+		suite.appFs,
+		slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	)
+}
+
+func (suite *ArchivePublicTestSuite) SetupTest() {
+	suite.appFs = osfs.NewWithNoIdm()
+	suite.files = make(map[string][]byte)
+	suite.server = httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, ok := suite.files[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(data)
+		}),
+	)
+	suite.dstDir = filepath.Join(suite.T().TempDir(), "archives", "dst")
+
+	suite.am = suite.NewTestArchiveManager()
+}
+
+func (suite *ArchivePublicTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+// serve publishes `data` at `name`, and returns its url and checksum.
+func (suite *ArchivePublicTestSuite) serve(name string, data []byte) (string, string) {
+	suite.files["/"+name] = data
+	return suite.server.URL + "/" + name, fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+func (suite *ArchivePublicTestSuite) tarball(entries []entry) []byte {
+	var b bytes.Buffer
+	tw := tar.NewWriter(&b)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: e.mode, Size: int64(len(e.body))}
+		switch {
+		case e.linkname != "":
+			hdr.Typeflag = tar.TypeSymlink
+			hdr.Linkname = e.linkname
+			hdr.Size = 0
+		case e.body == "" && e.name[len(e.name)-1] == '/':
+			hdr.Typeflag = tar.TypeDir
+		default:
+			hdr.Typeflag = tar.TypeReg
+		}
+		assert.NoError(suite.T(), tw.WriteHeader(hdr))
+		if hdr.Typeflag == tar.TypeReg {
+			_, err := tw.Write([]byte(e.body))
+			assert.NoError(suite.T(), err)
+		}
+	}
+	assert.NoError(suite.T(), tw.Close())
+
+	return b.Bytes()
+}
+
+func (suite *ArchivePublicTestSuite) gzipped(data []byte) []byte {
+	var b bytes.Buffer
+	gw := gzip.NewWriter(&b)
+	_, err := gw.Write(data)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), gw.Close())
+
+	return b.Bytes()
+}
+
+func (suite *ArchivePublicTestSuite) zipped(entries []entry) []byte {
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{Name: e.name}
+		hdr.SetMode(os.FileMode(e.mode))
+		w, err := zw.CreateHeader(hdr)
+		assert.NoError(suite.T(), err)
+		_, err = w.Write([]byte(e.body))
+		assert.NoError(suite.T(), err)
+	}
+	assert.NoError(suite.T(), zw.Close())
+
+	return b.Bytes()
+}
+
+func (suite *ArchivePublicTestSuite) assertFile(name, expected string) {
+	got, err := os.ReadFile(filepath.Join(suite.dstDir, name))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), expected, string(got))
+}
+
+func (suite *ArchivePublicTestSuite) TestFetchTarballStripsTopLevelDir() {
+	url, checksum := suite.serve("release.tar.gz", suite.gzipped(suite.tarball([]entry{
+		{name: "release-1.0/", mode: 0o755},
+		{name: "release-1.0/README.md", body: "readme", mode: 0o644},
+		{name: "release-1.0/bin/run", body: "#!/bin/sh", mode: 0o755},
+		{name: "release-1.0/docs", linkname: "README.md"},
+	})))
+
+	err := suite.am.Fetch(context.Background(), url, checksum, suite.dstDir)
+	assert.NoError(suite.T(), err)
+
+	suite.assertFile("README.md", "readme")
+	suite.assertFile("bin/run", "#!/bin/sh")
+	info, err := os.Stat(filepath.Join(suite.dstDir, "bin", "run"))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), os.FileMode(0o755), info.Mode().Perm())
+	link, err := os.Readlink(filepath.Join(suite.dstDir, "docs"))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "README.md", link)
+}
+
+func (suite *ArchivePublicTestSuite) TestFetchTarballKeepsSeveralTopLevelEntries() {
+	url, checksum := suite.serve("release.tar", suite.tarball([]entry{
+		{name: "./a.yml", body: "a", mode: 0o644},
+		{name: "./roles/x/b.yml", body: "b", mode: 0o644},
+	}))
+
+	err := suite.am.Fetch(context.Background(), url, checksum, suite.dstDir)
+	assert.NoError(suite.T(), err)
+
+	suite.assertFile("a.yml", "a")
+	suite.assertFile("roles/x/b.yml", "b")
+}
+
+func (suite *ArchivePublicTestSuite) TestFetchZipOk() {
+	url, checksum := suite.serve("release.zip", suite.zipped([]entry{
+		{name: "release/a.yml", body: "a", mode: 0o644},
+		{name: "release/b.yml", body: "b", mode: 0o644},
+	}))
+
+	err := suite.am.Fetch(context.Background(), url, checksum, suite.dstDir)
+	assert.NoError(suite.T(), err)
+
+	suite.assertFile("a.yml", "a")
+	suite.assertFile("b.yml", "b")
+}
+
+func (suite *ArchivePublicTestSuite) TestFetchReturnsErrorWhenChecksumMismatch() {
+	url, _ := suite.serve("release.tar", suite.tarball([]entry{
+		{name: "a.yml", body: "a", mode: 0o644},
+	}))
+	checksum := fmt.Sprintf("%x", sha256.Sum256([]byte("other")))
+
+	err := suite.am.Fetch(context.Background(), url, checksum, suite.dstDir)
+	assert.ErrorContains(suite.T(), err, "expected "+checksum)
+	assert.NoDirExists(suite.T(), suite.dstDir)
+}
+
+func (suite *ArchivePublicTestSuite) TestFetchReturnsErrorWhenNotFound() {
+	err := suite.am.Fetch(context.Background(), suite.server.URL+"/missing.tar", "", suite.dstDir)
+	assert.ErrorContains(suite.T(), err, "404 Not Found")
+	assert.NoDirExists(suite.T(), suite.dstDir)
+}
+
+func (suite *ArchivePublicTestSuite) TestFetchReturnsErrorWhenPathEscapes() {
+	url, checksum := suite.serve("evil.tar", suite.tarball([]entry{
+		{name: "../evil.yml", body: "evil", mode: 0o644},
+	}))
+
+	err := suite.am.Fetch(context.Background(), url, checksum, suite.dstDir)
+	assert.ErrorContains(suite.T(), err, "illegal path ../evil.yml")
+	assert.NoDirExists(suite.T(), suite.dstDir)
+	assert.NoFileExists(suite.T(), filepath.Join(filepath.Dir(suite.dstDir), "evil.yml"))
+}
+
+func (suite *ArchivePublicTestSuite) TestFetchReturnsErrorWhenLinkEscapes() {
+	url, checksum := suite.serve("evil.tar", suite.tarball([]entry{
+		{name: "a.yml", body: "a", mode: 0o644},
+		{name: "passwd", linkname: "/etc/passwd"},
+	}))
+
+	err := suite.am.Fetch(context.Background(), url, checksum, suite.dstDir)
+	assert.ErrorContains(suite.T(), err, "illegal link passwd -> /etc/passwd")
+	assert.NoDirExists(suite.T(), suite.dstDir)
+}
+
+func (suite *ArchivePublicTestSuite) TestFetchReturnsErrorWhenChainedLinksEscape() {
+	url, checksum := suite.serve("evil.tar", suite.tarball([]entry{
+		{name: "sub/", mode: 0o755},
+		{name: "sub/up", linkname: ".."},
+		{name: "out", linkname: "sub/up/.."},
+	}))
+
+	err := suite.am.Fetch(context.Background(), url, checksum, suite.dstDir)
+	assert.ErrorContains(suite.T(), err, "illegal link out -> sub/up/..")
+	assert.NoDirExists(suite.T(), suite.dstDir)
+}
+
+func (suite *ArchivePublicTestSuite) TestFetchReturnsErrorWhenWritingThroughLink() {
+	url, checksum := suite.serve("evil.tar", suite.tarball([]entry{
+		{name: "here", linkname: "."},
+		{name: "here/up", linkname: ".."},
+		{name: "here/up/evil.yml", body: "evil", mode: 0o644},
+	}))
+
+	err := suite.am.Fetch(context.Background(), url, checksum, suite.dstDir)
+	assert.ErrorContains(suite.T(), err, "illegal path here/up, here is a link")
+	assert.NoDirExists(suite.T(), suite.dstDir)
+	assert.NoFileExists(suite.T(), filepath.Join(filepath.Dir(suite.dstDir), "evil.yml"))
+}
+
+func (suite *ArchivePublicTestSuite) TestFetchKeepsLinksWithinArchive() {
+	url, checksum := suite.serve("release.tar", suite.tarball([]entry{
+		{name: "a/", mode: 0o755},
+		{name: "a/b.yml", body: "b", mode: 0o644},
+		{name: "c/", mode: 0o755},
+		{name: "c/b.yml", linkname: "../a/./b.yml"},
+	}))
+
+	err := suite.am.Fetch(context.Background(), url, checksum, suite.dstDir)
+	assert.NoError(suite.T(), err)
+	suite.assertFile("c/b.yml", "b")
+}
+
+func (suite *ArchivePublicTestSuite) TestFetchReturnsErrorWhenNotAnArchive() {
+	url, checksum := suite.serve(
+		"release.tar",
+		[]byte("not an archive, but long enough to be read"),
+	)
+
+	err := suite.am.Fetch(context.Background(), url, checksum, suite.dstDir)
+	assert.Error(suite.T(), err)
+	assert.NoDirExists(suite.T(), suite.dstDir)
+}
+
+// In order for `go test` to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run.
+func TestArchivePublicTestSuite(t *testing.T) {
+	suite.Run(t, new(ArchivePublicTestSuite))
+}
@@ -0,0 +1,328 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+// Package cache inspects and maintains gilt's cache of bare clones and
+// unpacked archives.
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/avfs/avfs"
+
+	"github.com/retr0h/gilt/v2/internal"
+	"github.com/retr0h/gilt/v2/internal/repository"
+	"github.com/retr0h/gilt/v2/pkg/config"
+	"github.com/retr0h/gilt/v2/pkg/report"
+)
+
+// checksum matches the name of the directory an archive is unpacked in.
+var checksum = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// New factory to create a new Cache instance.
+func New(
+	appFs avfs.VFS,
+	repoManager internal.RepositoryManager,
+	gitManager internal.GitManager,
+	logger *slog.Logger,
+) *Cache {
+	return &Cache{
+		appFs:       appFs,
+		repoManager: repoManager,
+		gitManager:  gitManager,
+		logger:      logger,
+	}
+}
+
+// List describes each clone in `cacheDir`, and each archive unpacked in
+// `archiveDir`.
+func (c *Cache) List(
+	ctx context.Context,
+	cacheDir string,
+	archiveDir string,
+) ([]report.CacheEntry, error) {
+	entries, err := c.clones(ctx, cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	archives, err := c.archives(archiveDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(entries, archives...), nil
+}
+
+// clones describes each clone in `cacheDir`.
+func (c *Cache) clones(ctx context.Context, cacheDir string) ([]report.CacheEntry, error) {
+	dirEntries, err := c.appFs.ReadDir(cacheDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	entries := make([]report.CacheEntry, 0, len(dirEntries))
+	for _, d := range dirEntries {
+		if !d.IsDir() {
+			continue
+		}
+		entry, err := c.describe(ctx, c.appFs.Join(cacheDir, d.Name()))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// archives describes each archive unpacked in `archiveDir`, in a directory
+// named by its checksum.  Anything else, e.g. an unpack left behind by an
+// interrupted fetch, is not an archive.
+func (c *Cache) archives(archiveDir string) ([]report.CacheEntry, error) {
+	dirEntries, err := c.appFs.ReadDir(archiveDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	entries := make([]report.CacheEntry, 0, len(dirEntries))
+	for _, d := range dirEntries {
+		if !d.IsDir() || !checksum.MatchString(d.Name()) {
+			continue
+		}
+		dir := c.appFs.Join(archiveDir, d.Name())
+		entry := report.CacheEntry{SHA256: d.Name(), Dir: dir}
+		if entry.Size, err = c.size(dir); err != nil {
+			return nil, err
+		}
+		// Nothing in an unpacked archive changes once it is renamed into
+		// place, so its directory dates the unpack
+		if info, err := d.Info(); err == nil {
+			entry.LastFetch = info.ModTime()
+		}
+		if info, err := c.appFs.Stat(dir + "." + repository.LASTUSED); err == nil {
+			entry.LastUsed = info.ModTime()
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// Inspect describes the clone in `cacheDir` of the Git repository `name`,
+// along with its tags, or the archive in `archiveDir` with the checksum
+// `name`.  The repository is named by its url, by the base name of that url
+// without the ".git" suffix, or by the name of its clone.
+func (c *Cache) Inspect(
+	ctx context.Context,
+	cacheDir string,
+	archiveDir string,
+	name string,
+) (report.CacheEntry, error) {
+	entries, err := c.List(ctx, cacheDir, archiveDir)
+	if err != nil {
+		return report.CacheEntry{}, err
+	}
+
+	for _, entry := range entries {
+		base := strings.TrimSuffix(path.Base(entry.Git), ".git")
+		if name != entry.Git && name != base && name != c.appFs.Base(entry.Dir) {
+			continue
+		}
+		if entry.Git == "" {
+			return entry, nil
+		}
+		if entry.Tags, err = c.gitManager.Tags(ctx, entry.Dir); err != nil {
+			return entry, err
+		}
+		return entry, nil
+	}
+
+	return report.CacheEntry{}, fmt.Errorf("no clone of %s in %s", name, cacheDir)
+}
+
+// describe the clone at `dir`.
+func (c *Cache) describe(ctx context.Context, dir string) (report.CacheEntry, error) {
+	entry := report.CacheEntry{Dir: dir}
+	// A clone without a readable remote is still listed, so it can be pruned
+	if url, err := c.gitManager.RemoteURL(ctx, dir, repository.ORIGIN); err == nil {
+		entry.Git = url
+	}
+
+	var err error
+	if entry.Size, err = c.size(dir); err != nil {
+		return entry, err
+	}
+
+	// FETCH_HEAD is written by every fetch; a clone never fetched since it
+	// was cloned falls back to the clone itself
+	if info, err := c.appFs.Stat(c.appFs.Join(dir, "FETCH_HEAD")); err == nil {
+		entry.LastFetch = info.ModTime()
+	} else if info, err := c.appFs.Stat(dir); err == nil {
+		entry.LastFetch = info.ModTime()
+	}
+	if info, err := c.appFs.Stat(c.appFs.Join(dir, repository.LASTUSED)); err == nil {
+		entry.LastUsed = info.ModTime()
+	}
+
+	return entry, nil
+}
+
+// size returns the total size of the files below `dir`.
+func (c *Cache) size(dir string) (int64, error) {
+	var size int64
+	err := c.appFs.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+
+	return size, err
+}
+
+// Verify runs `git fsck` on each clone in `cacheDir`, and clones a corrupt
+// one again from its remote.  Unpacked archives are not verified, as their
+// checksum covers the download, which is not kept; pruning one has it
+// downloaded again.
+func (c *Cache) Verify(ctx context.Context, cacheDir string) ([]report.CacheCheck, error) {
+	entries, err := c.clones(ctx, cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := make([]report.CacheCheck, 0, len(entries))
+	for _, entry := range entries {
+		check := report.CacheCheck{Git: entry.Git, Dir: entry.Dir}
+		c.logger.Info("verifying clone", slog.String("dir", entry.Dir))
+		err := c.gitManager.Fsck(ctx, entry.Dir)
+		// An interrupted fsck says nothing about the clone
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if err == nil {
+			check.OK = true
+			checks = append(checks, check)
+			continue
+		}
+		check.Error = err.Error()
+
+		c.logger.Warn(
+			"clone is corrupt",
+			slog.String("dir", entry.Dir),
+			slog.String("err", check.Error),
+		)
+		if entry.Git == "" {
+			check.Error = fmt.Sprintf("unable to repair, remote is unknown: %s", check.Error)
+			checks = append(checks, check)
+			continue
+		}
+
+		if err := c.repair(ctx, cacheDir, entry); err != nil {
+			check.Error = fmt.Sprintf("unable to repair: %s", err)
+		} else {
+			check.Repaired = true
+		}
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
+
+// repair clones `entry` again, aside from `cacheDir`, and swaps the clone in
+// for the corrupt one only once cloning succeeded; a failed clone leaves the
+// corrupt one, which may still be usable, in place.
+func (c *Cache) repair(ctx context.Context, cacheDir string, entry report.CacheEntry) error {
+	tmpDir, err := c.appFs.MkdirTemp(c.appFs.Dir(cacheDir), "repair-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = c.appFs.RemoveAll(tmpDir) }()
+
+	dir, _, err := c.repoManager.Clone(ctx, config.Repository{Git: entry.Git}, tmpDir)
+	if err != nil {
+		return err
+	}
+	if err := c.appFs.Rename(entry.Dir, c.appFs.Join(tmpDir, "corrupt")); err != nil {
+		return err
+	}
+
+	return c.appFs.Rename(dir, entry.Dir)
+}
+
+// Prune removes each clone in `cacheDir`, and each archive unpacked in
+// `archiveDir`, which has not been used for `unusedFor`.  One never marked as
+// used is judged by its last fetch.
+func (c *Cache) Prune(
+	ctx context.Context,
+	cacheDir string,
+	archiveDir string,
+	unusedFor time.Duration,
+) ([]report.CacheEntry, error) {
+	entries, err := c.List(ctx, cacheDir, archiveDir)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	pruned := make([]report.CacheEntry, 0, len(entries))
+	for _, entry := range entries {
+		last := entry.LastUsed
+		if last.IsZero() {
+			last = entry.LastFetch
+		}
+		if now.Sub(last) < unusedFor {
+			continue
+		}
+
+		c.logger.Info(
+			"removing from cache",
+			slog.String("dir", entry.Dir),
+			slog.Time("lastUsed", last),
+		)
+		if err := c.appFs.RemoveAll(entry.Dir); err != nil {
+			return nil, err
+		}
+		if entry.SHA256 != "" {
+			_ = c.appFs.Remove(entry.Dir + "." + repository.LASTUSED)
+		}
+		pruned = append(pruned, entry)
+	}
+
+	return pruned, nil
+}
@@ -0,0 +1,420 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/vfs/memfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+
+	"github.com/retr0h/gilt/v2/internal"
+	"github.com/retr0h/gilt/v2/internal/cache"
+	"github.com/retr0h/gilt/v2/internal/mocks/git"
+	"github.com/retr0h/gilt/v2/internal/mocks/repository"
+	intRepo "github.com/retr0h/gilt/v2/internal/repository"
+	"github.com/retr0h/gilt/v2/pkg/config"
+	"github.com/retr0h/gilt/v2/pkg/report"
+)
+
+type CachePublicTestSuite struct {
+	suite.Suite
+
+	ctrl     *gomock.Controller
+	mockRepo *repository.MockRepositoryManager
+	mockGit  *git.MockGitManager
+
+	appFs      avfs.VFS
+	cacheDir   string
+	archiveDir string
+	cloneDir   string
+	gitURL     string
+	logger     *slog.Logger
+}
+
+func (suite *CachePublicTestSuite) NewTestCacheManager() internal.CacheManager {
+	return cache.New(
+		suite.appFs,
+		suite.mockRepo,
+		suite.mockGit,
+		suite.logger,
+	)
+}
+
+func (suite *CachePublicTestSuite) SetupTest() {
+	suite.ctrl = gomock.NewController(suite.T())
+	suite.mockRepo = repository.NewMockRepositoryManager(suite.ctrl)
+	suite.mockGit = git.NewMockGitManager(suite.ctrl)
+
+	suite.appFs = memfs.New()
+	suite.cacheDir = "/giltDir/cache"
+	suite.archiveDir = "/giltDir/archives"
+	suite.cloneDir = "/giltDir/cache/https---example.com-user-repo.git"
+	suite.gitURL = "https://example.com/user/repo.git"
+	suite.logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	_ = suite.appFs.MkdirAll(suite.objectsDir(), 0o755)
+	_ = suite.appFs.WriteFile(suite.appFs.Join(suite.cloneDir, "HEAD"), []byte("ref"), 0o644)
+	_ = suite.appFs.WriteFile(
+		suite.appFs.Join(suite.objectsDir(), "pack"),
+		make([]byte, 1024),
+		0o644,
+	)
+}
+
+func (suite *CachePublicTestSuite) objectsDir() string {
+	return suite.appFs.Join(suite.cloneDir, "objects")
+}
+
+func (suite *CachePublicTestSuite) TearDownTest() {
+	defer suite.ctrl.Finish()
+}
+
+func (suite *CachePublicTestSuite) age(name string, age time.Duration) time.Time {
+	t := time.Now().Add(-age)
+	_ = suite.appFs.WriteFile(suite.appFs.Join(suite.cloneDir, name), nil, 0o644)
+	_ = suite.appFs.Chtimes(suite.appFs.Join(suite.cloneDir, name), t, t)
+	return t
+}
+
+// archive unpacks an archive with the checksum `sum` in the archive dir, last
+// used `used` ago, and returns its dir.
+func (suite *CachePublicTestSuite) archive(sum string, used time.Duration) string {
+	dir := suite.appFs.Join(suite.archiveDir, sum)
+	_ = suite.appFs.MkdirAll(dir, 0o755)
+	_ = suite.appFs.WriteFile(suite.appFs.Join(dir, "a.yml"), make([]byte, 10), 0o644)
+	t := time.Now().Add(-used)
+	_ = suite.appFs.WriteFile(dir+"."+intRepo.LASTUSED, nil, 0o644)
+	_ = suite.appFs.Chtimes(dir+"."+intRepo.LASTUSED, t, t)
+	return dir
+}
+
+func (suite *CachePublicTestSuite) TestListOk() {
+	cm := suite.NewTestCacheManager()
+	fetched := suite.age("FETCH_HEAD", time.Hour)
+	used := suite.age(intRepo.LASTUSED, time.Minute)
+
+	suite.mockGit.EXPECT().
+		RemoteURL(gomock.Any(), suite.cloneDir, intRepo.ORIGIN).
+		Return(suite.gitURL, nil)
+
+	got, err := cm.List(context.Background(), suite.cacheDir, suite.archiveDir)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), got, 1)
+	assert.Equal(suite.T(), suite.gitURL, got[0].Git)
+	assert.Equal(suite.T(), suite.cloneDir, got[0].Dir)
+	assert.Equal(suite.T(), int64(1027), got[0].Size)
+	assert.True(suite.T(), fetched.Equal(got[0].LastFetch))
+	assert.True(suite.T(), used.Equal(got[0].LastUsed))
+}
+
+func (suite *CachePublicTestSuite) TestListOkWhenRemoteUnknown() {
+	cm := suite.NewTestCacheManager()
+	errors := errors.New("tests error")
+
+	suite.mockGit.EXPECT().RemoteURL(gomock.Any(), gomock.Any(), gomock.Any()).Return("", errors)
+
+	got, err := cm.List(context.Background(), suite.cacheDir, suite.archiveDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "", got[0].Git)
+	assert.True(suite.T(), got[0].LastUsed.IsZero())
+	assert.False(suite.T(), got[0].LastFetch.IsZero())
+}
+
+func (suite *CachePublicTestSuite) TestListIncludesArchives() {
+	cm := suite.NewTestCacheManager()
+	sum := strings.Repeat("ab", 32)
+	dir := suite.archive(sum, time.Hour)
+	// Left behind by an interrupted fetch
+	_ = suite.appFs.MkdirAll(suite.appFs.Join(suite.archiveDir, "unpack123"), 0o755)
+
+	suite.mockGit.EXPECT().
+		RemoteURL(gomock.Any(), suite.cloneDir, intRepo.ORIGIN).
+		Return(suite.gitURL, nil)
+
+	got, err := cm.List(context.Background(), suite.cacheDir, suite.archiveDir)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), got, 2)
+	assert.Equal(suite.T(), "", got[1].Git)
+	assert.Equal(suite.T(), sum, got[1].SHA256)
+	assert.Equal(suite.T(), dir, got[1].Dir)
+	assert.Equal(suite.T(), int64(10), got[1].Size)
+	assert.False(suite.T(), got[1].LastFetch.IsZero())
+	assert.WithinDuration(suite.T(), time.Now().Add(-time.Hour), got[1].LastUsed, time.Minute)
+}
+
+func (suite *CachePublicTestSuite) TestListOkWhenCacheMissing() {
+	cm := suite.NewTestCacheManager()
+
+	got, err := cm.List(context.Background(), "/missing", "/missing")
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), got)
+}
+
+func (suite *CachePublicTestSuite) TestInspectOk() {
+	cm := suite.NewTestCacheManager()
+
+	for _, name := range []string{suite.gitURL, "repo", suite.appFs.Base(suite.cloneDir)} {
+		suite.mockGit.EXPECT().
+			RemoteURL(gomock.Any(), suite.cloneDir, intRepo.ORIGIN).
+			Return(suite.gitURL, nil)
+		suite.mockGit.EXPECT().
+			Tags(gomock.Any(), suite.cloneDir).
+			Return([]string{"v1.0.0", "v1.1.0"}, nil)
+
+		got, err := cm.Inspect(context.Background(), suite.cacheDir, suite.archiveDir, name)
+		assert.NoError(suite.T(), err, name)
+		assert.Equal(suite.T(), suite.gitURL, got.Git)
+		assert.Equal(suite.T(), suite.cloneDir, got.Dir)
+		assert.Equal(suite.T(), []string{"v1.0.0", "v1.1.0"}, got.Tags)
+	}
+}
+
+func (suite *CachePublicTestSuite) TestInspectArchiveOk() {
+	cm := suite.NewTestCacheManager()
+	sum := strings.Repeat("ab", 32)
+	dir := suite.archive(sum, time.Hour)
+
+	suite.mockGit.EXPECT().
+		RemoteURL(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitURL, nil)
+
+	got, err := cm.Inspect(context.Background(), suite.cacheDir, suite.archiveDir, sum)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), dir, got.Dir)
+	assert.Empty(suite.T(), got.Tags)
+}
+
+func (suite *CachePublicTestSuite) TestInspectReturnsErrorWhenCloneMissing() {
+	cm := suite.NewTestCacheManager()
+
+	suite.mockGit.EXPECT().
+		RemoteURL(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitURL, nil)
+	suite.mockGit.EXPECT().Tags(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := cm.Inspect(context.Background(), suite.cacheDir, suite.archiveDir, "other")
+	assert.EqualError(suite.T(), err, "no clone of other in /giltDir/cache")
+}
+
+func (suite *CachePublicTestSuite) TestInspectReturnsErrorWhenTagsErrors() {
+	cm := suite.NewTestCacheManager()
+	errors := errors.New("tests error")
+
+	suite.mockGit.EXPECT().
+		RemoteURL(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitURL, nil)
+	suite.mockGit.EXPECT().Tags(gomock.Any(), suite.cloneDir).Return(nil, errors)
+
+	_, err := cm.Inspect(context.Background(), suite.cacheDir, suite.archiveDir, "repo")
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CachePublicTestSuite) TestVerifyOk() {
+	cm := suite.NewTestCacheManager()
+
+	suite.mockGit.EXPECT().
+		RemoteURL(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitURL, nil)
+	suite.mockGit.EXPECT().Fsck(gomock.Any(), suite.cloneDir).Return(nil)
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	got, err := cm.Verify(context.Background(), suite.cacheDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []report.CacheCheck{
+		{Git: suite.gitURL, Dir: suite.cloneDir, OK: true},
+	}, got)
+}
+
+func (suite *CachePublicTestSuite) TestVerifyRepairsCorruptClone() {
+	cm := suite.NewTestCacheManager()
+	errors := errors.New("tests error")
+
+	suite.mockGit.EXPECT().
+		RemoteURL(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitURL, nil)
+	suite.mockGit.EXPECT().Fsck(gomock.Any(), suite.cloneDir).Return(errors)
+	suite.mockRepo.EXPECT().
+		Clone(gomock.Any(), config.Repository{Git: suite.gitURL}, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ config.Repository, cloneDir string) (string, string, error) {
+			// The corrupt clone stays in place while cloning
+			_, err := suite.appFs.Stat(suite.objectsDir())
+			assert.NoError(suite.T(), err)
+			assert.NotEqual(suite.T(), suite.cacheDir, cloneDir)
+			dir := suite.appFs.Join(cloneDir, suite.appFs.Base(suite.cloneDir))
+			_ = suite.appFs.MkdirAll(dir, 0o755)
+			_ = suite.appFs.WriteFile(suite.appFs.Join(dir, "HEAD"), []byte("fresh"), 0o644)
+			return dir, report.CloneFresh, nil
+		})
+
+	got, err := cm.Verify(context.Background(), suite.cacheDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []report.CacheCheck{
+		{Git: suite.gitURL, Dir: suite.cloneDir, Repaired: true, Error: "tests error"},
+	}, got)
+
+	// The fresh clone replaced the corrupt one, and nothing is left aside
+	data, err := suite.appFs.ReadFile(suite.appFs.Join(suite.cloneDir, "HEAD"))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "fresh", string(data))
+	_, err = suite.appFs.Stat(suite.objectsDir())
+	assert.Error(suite.T(), err)
+	entries, err := suite.appFs.ReadDir("/giltDir")
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), entries, 1)
+}
+
+func (suite *CachePublicTestSuite) TestVerifyLeavesCloneWhenCancelled() {
+	cm := suite.NewTestCacheManager()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	suite.mockGit.EXPECT().
+		RemoteURL(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitURL, nil)
+	suite.mockGit.EXPECT().
+		Fsck(gomock.Any(), suite.cloneDir).
+		DoAndReturn(func(context.Context, string) error {
+			cancel()
+			return context.Canceled
+		})
+
+	_, err := cm.Verify(ctx, suite.cacheDir)
+	assert.ErrorIs(suite.T(), err, context.Canceled)
+	// An interrupted fsck does not make the clone corrupt
+	_, err = suite.appFs.Stat(suite.objectsDir())
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *CachePublicTestSuite) TestVerifyReportsCloneFailure() {
+	cm := suite.NewTestCacheManager()
+	errors := errors.New("tests error")
+
+	suite.mockGit.EXPECT().
+		RemoteURL(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitURL, nil)
+	suite.mockGit.EXPECT().Fsck(gomock.Any(), suite.cloneDir).Return(errors)
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", errors)
+
+	got, err := cm.Verify(context.Background(), suite.cacheDir)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), got[0].Repaired)
+	assert.Equal(suite.T(), "unable to repair: tests error", got[0].Error)
+	// A failed clone leaves the corrupt clone in place
+	_, err = suite.appFs.Stat(suite.objectsDir())
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *CachePublicTestSuite) TestVerifyLeavesCloneWhenRemoteUnknown() {
+	cm := suite.NewTestCacheManager()
+	errors := errors.New("tests error")
+
+	suite.mockGit.EXPECT().RemoteURL(gomock.Any(), gomock.Any(), gomock.Any()).Return("", errors)
+	suite.mockGit.EXPECT().Fsck(gomock.Any(), suite.cloneDir).Return(errors)
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	got, err := cm.Verify(context.Background(), suite.cacheDir)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), got[0].Repaired)
+	assert.Contains(suite.T(), got[0].Error, "remote is unknown")
+	_, err = suite.appFs.Stat(suite.cloneDir)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *CachePublicTestSuite) TestPruneRemovesUnusedClones() {
+	cm := suite.NewTestCacheManager()
+	suite.age(intRepo.LASTUSED, 31*24*time.Hour)
+
+	suite.mockGit.EXPECT().
+		RemoteURL(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitURL, nil)
+
+	got, err := cm.Prune(context.Background(), suite.cacheDir, suite.archiveDir, 30*24*time.Hour)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), got, 1)
+	_, err = suite.appFs.Stat(suite.cloneDir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CachePublicTestSuite) TestPruneKeepsRecentlyUsedClones() {
+	cm := suite.NewTestCacheManager()
+	suite.age("FETCH_HEAD", 31*24*time.Hour)
+	suite.age(intRepo.LASTUSED, time.Hour)
+
+	suite.mockGit.EXPECT().
+		RemoteURL(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitURL, nil)
+
+	got, err := cm.Prune(context.Background(), suite.cacheDir, suite.archiveDir, 30*24*time.Hour)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), got)
+	_, err = suite.appFs.Stat(suite.cloneDir)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *CachePublicTestSuite) TestPruneFallsBackToLastFetch() {
+	cm := suite.NewTestCacheManager()
+	suite.age("FETCH_HEAD", 31*24*time.Hour)
+
+	suite.mockGit.EXPECT().
+		RemoteURL(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitURL, nil)
+
+	got, err := cm.Prune(context.Background(), suite.cacheDir, suite.archiveDir, 30*24*time.Hour)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), got, 1)
+}
+
+func (suite *CachePublicTestSuite) TestPruneRemovesUnusedArchives() {
+	cm := suite.NewTestCacheManager()
+	suite.age(intRepo.LASTUSED, time.Hour)
+	unused := suite.archive(strings.Repeat("ab", 32), 31*24*time.Hour)
+	used := suite.archive(strings.Repeat("cd", 32), time.Hour)
+
+	suite.mockGit.EXPECT().
+		RemoteURL(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitURL, nil)
+
+	got, err := cm.Prune(context.Background(), suite.cacheDir, suite.archiveDir, 30*24*time.Hour)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), got, 1)
+	assert.Equal(suite.T(), unused, got[0].Dir)
+	_, err = suite.appFs.Stat(unused)
+	assert.Error(suite.T(), err)
+	_, err = suite.appFs.Stat(unused + "." + intRepo.LASTUSED)
+	assert.Error(suite.T(), err)
+	_, err = suite.appFs.Stat(used)
+	assert.NoError(suite.T(), err)
+}
+
+// In order for `go test` to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run.
+func TestCachePublicTestSuite(t *testing.T) {
+	suite.Run(t, new(CachePublicTestSuite))
+}
@@ -21,9 +21,20 @@
 // Package internal defines interfaces for gilt's internal components.
 package internal
 
+import (
+	"context"
+)
+
 // ExecManager manager responsible for exec operations.
 type ExecManager interface {
-	RunCmd(name string, args []string) (string, error)
-	RunCmdInDir(name string, args []string, cwd string) (string, error)
+	RunCmd(ctx context.Context, name string, args []string) (string, error)
+	RunCmdInDir(ctx context.Context, name string, args []string, cwd string) (string, error)
+	RunCmdWithEnv(
+		ctx context.Context,
+		name string,
+		args []string,
+		cwd string,
+		env []string,
+	) (string, error)
 	RunInTempDir(dir, pattern string, fn func(string) error) error
 }
@@ -22,7 +22,10 @@
 package exec
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
+	"os"
 	"os/exec"
 	"strings"
 
@@ -40,16 +43,26 @@ func New(
 	}
 }
 
-// RunCmdImpl executes a command with optional working directory.
+// RunCmdImpl executes a command with optional working directory, and
+// optional variables added to gilt's own environment.  The command, and any
+// process it started, is killed once `ctx` is done.
 func (e *Exec) RunCmdImpl(
+	ctx context.Context,
 	name string,
 	args []string,
 	cwd string,
+	env []string,
 ) (string, error) {
-	cmd := exec.Command(name, args...)
+	cmd := exec.CommandContext(ctx, name, args...)
+	killProcessGroup(cmd)
+	// Children which outlive the command may hold its output open
+	cmd.WaitDelay = waitDelay
 	if cwd != "" {
 		cmd.Dir = cwd
 	}
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	out, err := cmd.CombinedOutput()
 	e.logger.Debug(
 		"exec",
@@ -59,6 +72,9 @@ func (e *Exec) RunCmdImpl(
 		slog.Any("error", err),
 	)
 	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return string(out), fmt.Errorf("%s: %s", strings.Join(cmd.Args, " "), ctxErr)
+		}
 		return string(out), err
 	}
 
@@ -68,19 +84,33 @@ func (e *Exec) RunCmdImpl(
 // RunCmd execute the provided command with args.
 // Yeah, yeah, yeah, I know I cheated by using Exec in this package.
 func (e *Exec) RunCmd(
+	ctx context.Context,
 	name string,
 	args []string,
 ) (string, error) {
-	return e.RunCmdImpl(name, args, "")
+	return e.RunCmdImpl(ctx, name, args, "", nil)
 }
 
 // RunCmdInDir executes a command in the given working directory.
 func (e *Exec) RunCmdInDir(
+	ctx context.Context,
+	name string,
+	args []string,
+	cwd string,
+) (string, error) {
+	return e.RunCmdImpl(ctx, name, args, cwd, nil)
+}
+
+// RunCmdWithEnv executes a command in the given working directory, with the
+// `KEY=value` pairs in `env` added to its environment; later pairs win.
+func (e *Exec) RunCmdWithEnv(
+	ctx context.Context,
 	name string,
 	args []string,
 	cwd string,
+	env []string,
 ) (string, error) {
-	return e.RunCmdImpl(name, args, cwd)
+	return e.RunCmdImpl(ctx, name, args, cwd, env)
 }
 
 // RunInTempDir creates a temporary directory, and runs the provided function
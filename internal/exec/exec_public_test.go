@@ -21,9 +21,11 @@
 package exec_test
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/avfs/avfs/vfs/memfs"
 	"github.com/stretchr/testify/assert"
@@ -49,7 +51,7 @@ func (suite *ExecManagerPublicTestSuite) NewTestExecManager() internal.ExecManag
 func (suite *ExecManagerPublicTestSuite) TestRunCmdOk() {
 	em := suite.NewTestExecManager()
 
-	_, err := em.RunCmd("ls", []string{})
+	_, err := em.RunCmd(context.Background(), "ls", []string{})
 	assert.NoError(suite.T(), err)
 }
 
@@ -58,22 +60,35 @@ func (suite *ExecManagerPublicTestSuite) TestRunCmdWithDebug() {
 
 	em := suite.NewTestExecManager()
 
-	_, err := em.RunCmd("echo", []string{"-n", "foo"})
+	_, err := em.RunCmd(context.Background(), "echo", []string{"-n", "foo"})
 	assert.NoError(suite.T(), err)
 }
 
 func (suite *ExecManagerPublicTestSuite) TestRunCmdReturnsError() {
 	em := suite.NewTestExecManager()
 
-	_, err := em.RunCmd("invalid", []string{"foo"})
+	_, err := em.RunCmd(context.Background(), "invalid", []string{"foo"})
 	assert.Error(suite.T(), err)
 	assert.Contains(suite.T(), err.Error(), "not found")
 }
 
+func (suite *ExecManagerPublicTestSuite) TestRunCmdReturnsErrorWhenContextDone() {
+	em := suite.NewTestExecManager()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	// The shell's child must be killed too, or its output pipe stays open
+	_, err := em.RunCmd(ctx, "sh", []string{"-c", "sleep 10; true"})
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "context deadline exceeded")
+	assert.Less(suite.T(), time.Since(start), 5*time.Second)
+}
+
 func (suite *ExecManagerPublicTestSuite) TestRunCmdInDirOk() {
 	em := suite.NewTestExecManager()
 
-	_, err := em.RunCmdInDir("ls", []string{}, "/tmp")
+	_, err := em.RunCmdInDir(context.Background(), "ls", []string{}, "/tmp")
 	assert.NoError(suite.T(), err)
 }
 
@@ -82,14 +97,51 @@ func (suite *ExecManagerPublicTestSuite) TestRunCmdInDirWithDebug() {
 
 	em := suite.NewTestExecManager()
 
-	_, err := em.RunCmdInDir("echo", []string{"-n", "foo"}, "/tmp")
+	_, err := em.RunCmdInDir(context.Background(), "echo", []string{"-n", "foo"}, "/tmp")
 	assert.NoError(suite.T(), err)
 }
 
 func (suite *ExecManagerPublicTestSuite) TestRunCmdInDirReturnsError() {
 	em := suite.NewTestExecManager()
 
-	_, err := em.RunCmdInDir("invalid", []string{"foo"}, "/tmp")
+	_, err := em.RunCmdInDir(context.Background(), "invalid", []string{"foo"}, "/tmp")
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "not found")
+}
+
+func (suite *ExecManagerPublicTestSuite) TestRunCmdWithEnvOk() {
+	em := suite.NewTestExecManager()
+
+	out, err := em.RunCmdWithEnv(
+		context.Background(),
+		"sh",
+		[]string{"-c", `printf "%s %s" "$(pwd)" "$GILT_TEST"`},
+		"/",
+		[]string{"GILT_TEST=foo"},
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "/ foo", out)
+}
+
+func (suite *ExecManagerPublicTestSuite) TestRunCmdWithEnvKeepsEnvironment() {
+	suite.T().Setenv("GILT_INHERITED", "bar")
+	em := suite.NewTestExecManager()
+
+	out, err := em.RunCmdWithEnv(
+		context.Background(),
+		"sh",
+		[]string{"-c", `printf "%s" "$GILT_INHERITED"`},
+		"",
+		[]string{"GILT_TEST=foo"},
+	)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "bar", out)
+}
+
+func (suite *ExecManagerPublicTestSuite) TestRunCmdWithEnvReturnsError() {
+	em := suite.NewTestExecManager()
+
+	_, err := em.RunCmdWithEnv(context.Background(), "invalid", []string{"foo"}, "/tmp", nil)
 	assert.Error(suite.T(), err)
 	assert.Contains(suite.T(), err.Error(), "not found")
 }
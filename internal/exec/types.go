@@ -22,10 +22,14 @@ package exec
 
 import (
 	"log/slog"
+	"time"
 
 	"github.com/avfs/avfs"
 )
 
+// waitDelay how long a killed command's output is waited for.
+const waitDelay = 5 * time.Second
+
 // Exec disk implementation.
 type Exec struct {
 	appFs  avfs.VFS
@@ -0,0 +1,202 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+// Package giltfile edits a Giltfile in place.  Edits are spliced into the
+// original bytes, so comments, key order, anchors, and formatting are kept.
+package giltfile
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SetVersions returns `data` with the version of each repository, keyed by
+// its index in the Giltfile, replaced with the value from `versions`.  When a
+// version is an alias, the anchored value it refers to is replaced, so every
+// repository sharing it must be set to the same value.
+func SetVersions(data []byte, versions map[int]string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("giltfile is empty")
+	}
+
+	repos := lookup(doc.Content[0], "repositories")
+	if repos == nil || repos.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("giltfile has no repositories")
+	}
+
+	indexes := make([]int, 0, len(versions))
+	for i := range versions {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	// Repositories sharing an anchored version share the node it is set at
+	edited := make(map[*yaml.Node]int, len(versions))
+	edits := make([]edit, 0, len(versions))
+	for _, i := range indexes {
+		v := versions[i]
+		if i < 0 || i >= len(repos.Content) {
+			return nil, fmt.Errorf("repository %d does not exist", i)
+		}
+		node := lookup(repos.Content[i], "version")
+		if node == nil {
+			return nil, fmt.Errorf("repository %d has no version", i)
+		}
+		if first, ok := edited[node]; ok {
+			if versions[first] != v {
+				return nil, fmt.Errorf(
+					"repositories %d and %d share a version, which cannot be set to both %s and %s",
+					first,
+					i,
+					versions[first],
+					v,
+				)
+			}
+			continue
+		}
+		edited[node] = i
+
+		e, err := newEdit(data, node, v)
+		if err != nil {
+			return nil, fmt.Errorf("repository %d: %s", i, err)
+		}
+		edits = append(edits, e)
+	}
+
+	// Apply from the end of the file backwards, so earlier offsets stay valid
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	out := bytes.Clone(data)
+	for _, e := range edits {
+		out = append(out[:e.start], append([]byte(e.value), out[e.end:]...)...)
+	}
+
+	return out, nil
+}
+
+// lookup returns the value of `key` in the mapping `node`, following aliases.
+// Keys are matched case-insensitively, as Viper does.
+func lookup(node *yaml.Node, key string) *yaml.Node {
+	node = resolve(node)
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if strings.EqualFold(node.Content[i].Value, key) {
+			return resolve(node.Content[i+1])
+		}
+	}
+
+	return nil
+}
+
+// resolve follows `node` to the node it is an alias of.
+func resolve(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	return node
+}
+
+// newEdit locate the scalar `node` within `data`, and build the edit which
+// replaces it with `value`, keeping the original quoting style.
+func newEdit(data []byte, node *yaml.Node, value string) (edit, error) {
+	start, err := offset(data, node.Line, node.Column)
+	if err != nil {
+		return edit{}, err
+	}
+	// Skip over the anchor, which precedes the value
+	if node.Anchor != "" {
+		start += len("&" + node.Anchor)
+		for start < len(data) && (data[start] == ' ' || data[start] == '\t') {
+			start++
+		}
+	}
+
+	rest := data[start:]
+	switch node.Style {
+	case 0:
+		if !bytes.HasPrefix(rest, []byte(node.Value)) {
+			return edit{}, fmt.Errorf("unable to locate version %q", node.Value)
+		}
+		return edit{start, start + len(node.Value), value}, nil
+	case yaml.SingleQuotedStyle:
+		end := closingQuote(rest, '\'')
+		if end < 0 {
+			return edit{}, fmt.Errorf("unterminated version %q", node.Value)
+		}
+		return edit{start, start + end + 1, "'" + value + "'"}, nil
+	case yaml.DoubleQuotedStyle:
+		end := closingQuote(rest, '"')
+		if end < 0 {
+			return edit{}, fmt.Errorf("unterminated version %q", node.Value)
+		}
+		return edit{start, start + end + 1, `"` + value + `"`}, nil
+	default:
+		return edit{}, fmt.Errorf("unsupported style for version %q", node.Value)
+	}
+}
+
+// offset convert a 1-based line and (character) column to a byte offset.
+func offset(data []byte, line, column int) (int, error) {
+	pos := 0
+	for l := 1; l < line; l++ {
+		i := bytes.IndexByte(data[pos:], '\n')
+		if i < 0 {
+			return 0, fmt.Errorf("line %d is out of range", line)
+		}
+		pos += i + 1
+	}
+	for c := 1; c < column; c++ {
+		if pos >= len(data) {
+			return 0, fmt.Errorf("column %d is out of range", column)
+		}
+		_, size := utf8.DecodeRune(data[pos:])
+		pos += size
+	}
+
+	return pos, nil
+}
+
+// closingQuote returns the index of the quote which closes the quoted scalar
+// at the start of `data`, or -1.
+func closingQuote(data []byte, quote byte) int {
+	for i := 1; i < len(data); i++ {
+		switch {
+		case quote == '"' && data[i] == '\\':
+			i++
+		case data[i] == quote && quote == '\'' && i+1 < len(data) && data[i+1] == '\'':
+			i++
+		case data[i] == quote:
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package giltfile_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/retr0h/gilt/v2/internal/giltfile"
+)
+
+type GiltfilePublicTestSuite struct {
+	suite.Suite
+}
+
+func (suite *GiltfilePublicTestSuite) TestSetVersionsKeepsFormatting() {
+	data := []byte(`---
+# Pinned dependencies.
+giltDir: ~/.gilt/clone
+repositories:
+  # The first repository.
+  - git: https://github.com/example/a.git
+    version: v1.0.0  # bumped by hand
+    dstDir: vendor/a
+  - git: https://github.com/example/b.git
+    version: 'v2.0.0'
+    dstDir: vendor/b
+  - {git: "https://github.com/example/c.git", version: "v3.0.0", dstDir: vendor/c}
+`)
+	want := []byte(`---
+# Pinned dependencies.
+giltDir: ~/.gilt/clone
+repositories:
+  # The first repository.
+  - git: https://github.com/example/a.git
+    version: v1.10.0  # bumped by hand
+    dstDir: vendor/a
+  - git: https://github.com/example/b.git
+    version: 'v2.1.0'
+    dstDir: vendor/b
+  - {git: "https://github.com/example/c.git", version: "v3.0.1", dstDir: vendor/c}
+`)
+
+	got, err := giltfile.SetVersions(data, map[int]string{
+		0: "v1.10.0",
+		1: "v2.1.0",
+		2: "v3.0.1",
+	})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), string(want), string(got))
+}
+
+func (suite *GiltfilePublicTestSuite) TestSetVersionsFollowsAliases() {
+	data := []byte(`repositories:
+  - git: https://github.com/example/a.git
+    version: &release v1.0.0
+    dstDir: vendor/a
+  - git: https://github.com/example/b.git
+    version: *release
+    dstDir: vendor/b
+`)
+	want := []byte(`repositories:
+  - git: https://github.com/example/a.git
+    version: &release v1.1.0
+    dstDir: vendor/a
+  - git: https://github.com/example/b.git
+    version: *release
+    dstDir: vendor/b
+`)
+
+	got, err := giltfile.SetVersions(data, map[int]string{0: "v1.1.0", 1: "v1.1.0"})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), string(want), string(got))
+}
+
+func (suite *GiltfilePublicTestSuite) TestSetVersionsReturnsErrorWhenAliasesDiffer() {
+	data := []byte(`repositories:
+  - git: https://github.com/example/a.git
+    version: &release v1.0.0
+    dstDir: vendor/a
+  - git: https://github.com/example/b.git
+    version: *release
+    dstDir: vendor/b
+`)
+
+	_, err := giltfile.SetVersions(data, map[int]string{0: "v1.1.0", 1: "v2.0.0"})
+	assert.EqualError(
+		suite.T(),
+		err,
+		"repositories 0 and 1 share a version, which cannot be set to both v1.1.0 and v2.0.0",
+	)
+}
+
+func (suite *GiltfilePublicTestSuite) TestSetVersionsReturnsErrorWhenIndexMissing() {
+	data := []byte(`repositories:
+  - git: https://github.com/example/a.git
+    version: v1.0.0
+`)
+
+	_, err := giltfile.SetVersions(data, map[int]string{1: "v1.1.0"})
+	assert.EqualError(suite.T(), err, "repository 1 does not exist")
+}
+
+func (suite *GiltfilePublicTestSuite) TestSetVersionsReturnsErrorWithoutRepositories() {
+	_, err := giltfile.SetVersions([]byte("giltDir: /tmp\n"), map[int]string{0: "v1.1.0"})
+	assert.EqualError(suite.T(), err, "giltfile has no repositories")
+}
+
+func (suite *GiltfilePublicTestSuite) TestSetVersionsReturnsErrorOnInvalidYAML() {
+	_, err := giltfile.SetVersions([]byte("repositories: [\n"), map[int]string{0: "v1.1.0"})
+	assert.Error(suite.T(), err)
+}
+
+// In order for `go test` to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run.
+func TestGiltfilePublicTestSuite(t *testing.T) {
+	suite.Run(t, new(GiltfilePublicTestSuite))
+}
@@ -20,10 +20,18 @@
 
 package internal
 
+import (
+	"context"
+)
+
 // GitManager manager responsible for Git operations.
 type GitManager interface {
-	Clone(gitURL, origin, cloneDir string) error
-	Worktree(cloneDir, version, dstDir string) error
-	Update(origin, cloneDir string) error
-	Remote(cloneDir string) (string, error)
+	Clone(ctx context.Context, gitURL, origin, cloneDir string) error
+	Worktree(ctx context.Context, cloneDir, version, dstDir string) error
+	Update(ctx context.Context, origin, cloneDir string) error
+	Remote(ctx context.Context, cloneDir string) (string, error)
+	RevParse(ctx context.Context, cloneDir, version string) (string, error)
+	Tags(ctx context.Context, cloneDir string) ([]string, error)
+	RemoteURL(ctx context.Context, cloneDir, origin string) (string, error)
+	Fsck(ctx context.Context, cloneDir string) error
 }
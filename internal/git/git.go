@@ -18,13 +18,16 @@
 // FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
 // DEALINGS IN THE SOFTWARE.
 
-// Package git package needs reworked into proper Git libraries.  However, this
-// package will remain using exec as it was easiest to port from gilt's
-// python counterpart.
+// Package git performs Git operations.  Git shells out to the git binary, as
+// it was easiest to port from gilt's python counterpart, and Native uses
+// go-git, for hosts without git installed.
 package git
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
+	"strings"
 
 	"github.com/avfs/avfs"
 
@@ -45,8 +48,9 @@ func New(
 }
 
 // Clone the repo.  This is a bare repo, with only metadata to start with.
-func (g *Git) Clone(gitURL, origin, cloneDir string) error {
+func (g *Git) Clone(ctx context.Context, gitURL, origin, cloneDir string) error {
 	_, err := g.execManager.RunCmd(
+		ctx,
 		"git",
 		[]string{
 			"-c", "clone.defaultRemoteName=" + origin,
@@ -61,6 +65,7 @@ func (g *Git) Clone(gitURL, origin, cloneDir string) error {
 	//  we want to throw out the result.
 	if err == nil {
 		_, _ = g.execManager.RunCmdInDir(
+			ctx,
 			"git",
 			[]string{"remote", "rename", "origin", origin},
 			cloneDir,
@@ -71,8 +76,9 @@ func (g *Git) Clone(gitURL, origin, cloneDir string) error {
 
 // Update the repo.  Fetch the current HEAD and any new tags that may have
 // appeared, and update the cache.
-func (g *Git) Update(origin, cloneDir string) error {
+func (g *Git) Update(ctx context.Context, origin, cloneDir string) error {
 	_, err := g.execManager.RunCmdInDir(
+		ctx,
 		"git",
 		[]string{"fetch", "--tags", "--force", origin, "+refs/heads/*:refs/heads/*"},
 		cloneDir,
@@ -84,6 +90,7 @@ func (g *Git) Update(origin, cloneDir string) error {
 // Under the covers, this will download any/all required objects from origin
 // into the cache
 func (g *Git) Worktree(
+	ctx context.Context,
 	cloneDir string,
 	version string,
 	dstDir string,
@@ -101,6 +108,7 @@ func (g *Git) Worktree(
 	)
 
 	_, err = g.execManager.RunCmdInDir(
+		ctx,
 		"git",
 		[]string{"worktree", "add", "--force", dst, version},
 		cloneDir,
@@ -110,6 +118,7 @@ func (g *Git) Worktree(
 	if err == nil {
 		_ = g.appFs.Remove(g.appFs.Join(dst, ".git"))
 		_, _ = g.execManager.RunCmdInDir(
+			ctx,
 			"git",
 			[]string{"worktree", "prune", "--verbose"},
 			cloneDir,
@@ -119,6 +128,55 @@ func (g *Git) Worktree(
 }
 
 // Remote returns the name of the repo remote.
-func (g *Git) Remote(cloneDir string) (string, error) {
-	return g.execManager.RunCmdInDir("git", []string{"remote"}, cloneDir)
+func (g *Git) Remote(ctx context.Context, cloneDir string) (string, error) {
+	return g.execManager.RunCmdInDir(ctx, "git", []string{"remote"}, cloneDir)
+}
+
+// RevParse resolves `version` to the full commit SHA it currently points to
+// in the repo at `cloneDir`.
+func (g *Git) RevParse(ctx context.Context, cloneDir, version string) (string, error) {
+	out, err := g.execManager.RunCmdInDir(
+		ctx,
+		"git",
+		[]string{"rev-parse", "--verify", "--quiet", version + "^{commit}"},
+		cloneDir,
+	)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve version %s: %s", version, err)
+	}
+
+	return strings.TrimSpace(out), nil
+}
+
+// Tags lists the tags known to the repo at `cloneDir`.
+func (g *Git) Tags(ctx context.Context, cloneDir string) ([]string, error) {
+	out, err := g.execManager.RunCmdInDir(ctx, "git", []string{"tag", "--list"}, cloneDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Fields(out), nil
+}
+
+// RemoteURL returns the url of the remote named `origin` in the repo at
+// `cloneDir`.
+func (g *Git) RemoteURL(ctx context.Context, cloneDir, origin string) (string, error) {
+	out, err := g.execManager.RunCmdInDir(
+		ctx,
+		"git",
+		[]string{"config", "--get", "remote." + origin + ".url"},
+		cloneDir,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(out), nil
+}
+
+// Fsck verifies the connectivity and validity of the objects in the repo at
+// `cloneDir`.
+func (g *Git) Fsck(ctx context.Context, cloneDir string) error {
+	_, err := g.execManager.RunCmdInDir(ctx, "git", []string{"fsck", "--no-progress"}, cloneDir)
+	return err
 }
@@ -21,6 +21,7 @@
 package git_test
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"os"
@@ -82,46 +83,46 @@ func (suite *GitManagerPublicTestSuite) TearDownTest() {
 
 func (suite *GitManagerPublicTestSuite) TestCloneOk() {
 	suite.mockExec.EXPECT().
-		RunCmd("git", []string{
+		RunCmd(gomock.Any(), "git", []string{
 			"-c", "clone.defaultRemoteName=" + suite.origin,
 			"clone", "--bare", "--filter=blob:none", suite.gitURL, suite.cloneDir,
 		}).
 		Return("", nil)
 	suite.mockExec.EXPECT().
-		RunCmdInDir("git", []string{"remote", "rename", "origin", suite.origin}, suite.cloneDir).
+		RunCmdInDir(gomock.Any(), "git", []string{"remote", "rename", "origin", suite.origin}, suite.cloneDir).
 		Return("", nil)
 
-	err := suite.gm.Clone(suite.gitURL, suite.origin, suite.cloneDir)
+	err := suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir)
 	assert.NoError(suite.T(), err)
 }
 
 func (suite *GitManagerPublicTestSuite) TestCloneReturnsError() {
 	errors := errors.New("tests error")
-	suite.mockExec.EXPECT().RunCmd(gomock.Any(), gomock.Any()).Return("", errors)
+	suite.mockExec.EXPECT().RunCmd(gomock.Any(), gomock.Any(), gomock.Any()).Return("", errors)
 	// `git remote rename` is not called if the clone throws errors
-	suite.mockExec.EXPECT().RunCmdInDir("git", gomock.Any(), suite.cloneDir).Times(0)
+	suite.mockExec.EXPECT().RunCmdInDir(gomock.Any(), "git", gomock.Any(), suite.cloneDir).Times(0)
 
-	err := suite.gm.Clone(suite.gitURL, suite.origin, suite.cloneDir)
+	err := suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir)
 	assert.Error(suite.T(), err)
 }
 
 func (suite *GitManagerPublicTestSuite) TestWorktreeOk() {
 	suite.mockExec.EXPECT().
-		RunCmdInDir("git", []string{"worktree", "add", "--force", suite.dstDir, suite.gitVersion}, suite.cloneDir).
+		RunCmdInDir(gomock.Any(), "git", []string{"worktree", "add", "--force", suite.dstDir, suite.gitVersion}, suite.cloneDir).
 		Return("", nil)
 	suite.mockExec.EXPECT().
-		RunCmdInDir("git", []string{"worktree", "prune", "--verbose"}, suite.cloneDir).
+		RunCmdInDir(gomock.Any(), "git", []string{"worktree", "prune", "--verbose"}, suite.cloneDir).
 		Return("", nil)
-	err := suite.gm.Worktree(suite.cloneDir, suite.gitVersion, suite.dstDir)
+	err := suite.gm.Worktree(context.Background(), suite.cloneDir, suite.gitVersion, suite.dstDir)
 	assert.NoError(suite.T(), err)
 }
 
 func (suite *GitManagerPublicTestSuite) TestWorktreeError() {
 	errors := errors.New("tests error")
 	suite.mockExec.EXPECT().
-		RunCmdInDir("git", []string{"worktree", "add", "--force", suite.dstDir, suite.gitVersion}, suite.cloneDir).
+		RunCmdInDir(gomock.Any(), "git", []string{"worktree", "add", "--force", suite.dstDir, suite.gitVersion}, suite.cloneDir).
 		Return("", errors)
-	err := suite.gm.Worktree(suite.cloneDir, suite.gitVersion, suite.dstDir)
+	err := suite.gm.Worktree(context.Background(), suite.cloneDir, suite.gitVersion, suite.dstDir)
 	assert.Error(suite.T(), err)
 }
 
@@ -138,40 +139,114 @@ func (suite *GitManagerPublicTestSuite) TestWorktreeErrorWhenAbsErrors() {
 
 	gm := suite.NewTestGitManager()
 
-	err := gm.Worktree(suite.cloneDir, suite.gitVersion, suite.dstDir)
+	err := gm.Worktree(context.Background(), suite.cloneDir, suite.gitVersion, suite.dstDir)
 	assert.Error(suite.T(), err)
 	assert.Equal(suite.T(), "failFS", err.Error())
 }
 
 func (suite *GitManagerPublicTestSuite) TestUpdateOk() {
 	suite.mockExec.EXPECT().
-		RunCmdInDir("git", []string{"fetch", "--tags", "--force", suite.origin, "+refs/heads/*:refs/heads/*"}, suite.cloneDir).
+		RunCmdInDir(gomock.Any(), "git", []string{"fetch", "--tags", "--force", suite.origin, "+refs/heads/*:refs/heads/*"}, suite.cloneDir).
 		Return("", nil)
-	err := suite.gm.Update(suite.origin, suite.cloneDir)
+	err := suite.gm.Update(context.Background(), suite.origin, suite.cloneDir)
 	assert.NoError(suite.T(), err)
 }
 
 func (suite *GitManagerPublicTestSuite) TestUpdateError() {
 	errors := errors.New("tests error")
 	suite.mockExec.EXPECT().
-		RunCmdInDir("git", []string{"fetch", "--tags", "--force", suite.origin, "+refs/heads/*:refs/heads/*"}, suite.cloneDir).
+		RunCmdInDir(gomock.Any(), "git", []string{"fetch", "--tags", "--force", suite.origin, "+refs/heads/*:refs/heads/*"}, suite.cloneDir).
 		Return("", errors)
-	err := suite.gm.Update(suite.origin, suite.cloneDir)
+	err := suite.gm.Update(context.Background(), suite.origin, suite.cloneDir)
 	assert.Error(suite.T(), err)
 }
 
 func (suite *GitManagerPublicTestSuite) TestRemoteOk() {
-	suite.mockExec.EXPECT().RunCmdInDir("git", []string{"remote"}, suite.cloneDir).Return("", nil)
-	_, err := suite.gm.Remote(suite.cloneDir)
+	suite.mockExec.EXPECT().
+		RunCmdInDir(gomock.Any(), "git", []string{"remote"}, suite.cloneDir).
+		Return("", nil)
+	_, err := suite.gm.Remote(context.Background(), suite.cloneDir)
 	assert.NoError(suite.T(), err)
 }
 
 func (suite *GitManagerPublicTestSuite) TestRemoteError() {
 	errors := errors.New("tests error")
 	suite.mockExec.EXPECT().
-		RunCmdInDir("git", []string{"remote"}, suite.cloneDir).
+		RunCmdInDir(gomock.Any(), "git", []string{"remote"}, suite.cloneDir).
+		Return("", errors)
+	_, err := suite.gm.Remote(context.Background(), suite.cloneDir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *GitManagerPublicTestSuite) TestRevParseOk() {
+	suite.mockExec.EXPECT().
+		RunCmdInDir(gomock.Any(), "git", []string{"rev-parse", "--verify", "--quiet", suite.gitVersion + "^{commit}"}, suite.cloneDir).
+		Return("abc1234def\n", nil)
+	got, err := suite.gm.RevParse(context.Background(), suite.cloneDir, suite.gitVersion)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "abc1234def", got)
+}
+
+func (suite *GitManagerPublicTestSuite) TestRevParseError() {
+	errors := errors.New("tests error")
+	suite.mockExec.EXPECT().
+		RunCmdInDir(gomock.Any(), "git", gomock.Any(), suite.cloneDir).
+		Return("", errors)
+	_, err := suite.gm.RevParse(context.Background(), suite.cloneDir, suite.gitVersion)
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "unable to resolve version abc123")
+}
+
+func (suite *GitManagerPublicTestSuite) TestTagsOk() {
+	suite.mockExec.EXPECT().
+		RunCmdInDir(gomock.Any(), "git", []string{"tag", "--list"}, suite.cloneDir).
+		Return("v1.0.0\nv1.1.0\n", nil)
+	got, err := suite.gm.Tags(context.Background(), suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"v1.0.0", "v1.1.0"}, got)
+}
+
+func (suite *GitManagerPublicTestSuite) TestTagsError() {
+	errors := errors.New("tests error")
+	suite.mockExec.EXPECT().
+		RunCmdInDir(gomock.Any(), "git", []string{"tag", "--list"}, suite.cloneDir).
+		Return("", errors)
+	_, err := suite.gm.Tags(context.Background(), suite.cloneDir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *GitManagerPublicTestSuite) TestRemoteURLOk() {
+	suite.mockExec.EXPECT().
+		RunCmdInDir(gomock.Any(), "git", []string{"config", "--get", "remote.gilt.url"}, suite.cloneDir).
+		Return("https://example.com/user/repo.git\n", nil)
+	got, err := suite.gm.RemoteURL(context.Background(), suite.cloneDir, "gilt")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "https://example.com/user/repo.git", got)
+}
+
+func (suite *GitManagerPublicTestSuite) TestRemoteURLError() {
+	errors := errors.New("tests error")
+	suite.mockExec.EXPECT().
+		RunCmdInDir(gomock.Any(), "git", []string{"config", "--get", "remote.gilt.url"}, suite.cloneDir).
+		Return("", errors)
+	_, err := suite.gm.RemoteURL(context.Background(), suite.cloneDir, "gilt")
+	assert.Error(suite.T(), err)
+}
+
+func (suite *GitManagerPublicTestSuite) TestFsckOk() {
+	suite.mockExec.EXPECT().
+		RunCmdInDir(gomock.Any(), "git", []string{"fsck", "--no-progress"}, suite.cloneDir).
+		Return("", nil)
+	err := suite.gm.Fsck(context.Background(), suite.cloneDir)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *GitManagerPublicTestSuite) TestFsckError() {
+	errors := errors.New("tests error")
+	suite.mockExec.EXPECT().
+		RunCmdInDir(gomock.Any(), "git", []string{"fsck", "--no-progress"}, suite.cloneDir).
 		Return("", errors)
-	_, err := suite.gm.Remote(suite.cloneDir)
+	err := suite.gm.Fsck(context.Background(), suite.cloneDir)
 	assert.Error(suite.T(), err)
 }
 
@@ -0,0 +1,613 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/avfs/avfs"
+	gogit "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/packfile"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp/capability"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp/sideband"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// blobFilter leaves every blob out of a clone, as `--filter=blob:none` does.
+const blobFilter = "blob:none"
+
+// headsRefSpec mirrors the remote's branches as local branches, as a bare
+// clone does.
+const headsRefSpec = "+refs/heads/*:refs/heads/*"
+
+// NewNative factory to create a new Native instance.
+func NewNative(
+	appFs avfs.VFS,
+	logger *slog.Logger,
+) *Native {
+	return &Native{
+		appFs:  appFs,
+		logger: logger,
+	}
+}
+
+// Clone the repo.  This is a bare, partial clone, like the one the git binary
+// makes: blobs are left on the remote when it supports filtering, and fetched
+// by Worktree as needed.
+func (g *Native) Clone(ctx context.Context, gitURL, origin, cloneDir string) error {
+	g.logger.Debug("init", slog.String("repository", gitURL), slog.String("dstDir", cloneDir))
+	// Whatever is left at `cloneDir` cannot be used
+	if err := g.appFs.RemoveAll(cloneDir); err != nil {
+		return err
+	}
+
+	repo, err := gogit.PlainInit(cloneDir, true)
+	if err != nil {
+		return err
+	}
+	remote, err := repo.CreateRemote(&gitconfig.RemoteConfig{
+		Name:  origin,
+		URLs:  []string{gitURL},
+		Fetch: []gitconfig.RefSpec{headsRefSpec},
+	})
+	if err != nil {
+		return err
+	}
+	// Record the remote as the promisor of the objects it leaves out, as
+	// `git clone --filter=blob:none` does
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	subsection := cfg.Raw.Section("remote").Subsection(origin)
+	subsection.SetOption("promisor", "true")
+	subsection.SetOption("partialclonefilter", blobFilter)
+	if err := repo.SetConfig(cfg); err != nil {
+		return err
+	}
+	if err := g.fetch(ctx, repo, origin); err != nil {
+		return err
+	}
+
+	// Point HEAD at the remote's default branch, as `git clone` does
+	refs, err := remote.ListContext(ctx, &gogit.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if ref.Name() == plumbing.HEAD && ref.Type() == plumbing.SymbolicReference {
+			return repo.Storer.SetReference(
+				plumbing.NewSymbolicReference(plumbing.HEAD, ref.Target()),
+			)
+		}
+	}
+
+	return nil
+}
+
+// Update the repo.  Fetch the current HEAD and any new tags that may have
+// appeared, and update the cache.
+func (g *Native) Update(ctx context.Context, origin, cloneDir string) error {
+	repo, err := gogit.PlainOpen(cloneDir)
+	if err != nil {
+		return err
+	}
+
+	return g.fetch(ctx, repo, origin)
+}
+
+// fetch every branch and tag of `origin`, moving any which were rewritten.
+// The objects left out by the remote's `partialclonefilter` are not fetched,
+// when the remote supports filtering.
+func (g *Native) fetch(ctx context.Context, repo *gogit.Repository, origin string) error {
+	g.logger.Debug("fetching", slog.String("remote", origin))
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	remote, ok := cfg.Remotes[origin]
+	if !ok || len(remote.URLs) == 0 {
+		return fmt.Errorf("remote %s has no url", origin)
+	}
+
+	session, adv, err := g.session(ctx, remote.URLs[0])
+	if err != nil {
+		return err
+	}
+	defer func() { _ = session.Close() }()
+	advertised, err := adv.AllReferences()
+	if err != nil {
+		return err
+	}
+
+	req := packp.NewUploadPackRequestFromCapabilities(adv.Capabilities)
+	var refs []*plumbing.Reference
+	for _, ref := range advertised {
+		name := ref.Name()
+		if ref.Type() != plumbing.HashReference || !name.IsBranch() && !name.IsTag() {
+			continue
+		}
+		refs = append(refs, ref)
+		if repo.Storer.HasEncodedObject(ref.Hash()) != nil {
+			req.Wants = append(req.Wants, ref.Hash())
+		}
+	}
+	if len(req.Wants) > 0 {
+		if req.Haves, err = g.haves(repo); err != nil {
+			return err
+		}
+		filter := cfg.Raw.Section("remote").Subsection(origin).Option("partialclonefilter")
+		if filter != "" && adv.Capabilities.Supports(capability.Filter) {
+			if err := req.Capabilities.Set(capability.Filter); err != nil {
+				return err
+			}
+			req.Filter = packp.Filter(filter)
+		}
+		if err := g.uploadPack(ctx, repo, session, req); err != nil {
+			return err
+		}
+	}
+
+	for _, ref := range refs {
+		if err := repo.Storer.SetReference(ref); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// haves lists the commits and tags the repo already holds, so the remote
+// leaves them out of the pack.
+func (g *Native) haves(repo *gogit.Repository) ([]plumbing.Hash, error) {
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+
+	var haves []plumbing.Hash
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.HashReference {
+			haves = append(haves, ref.Hash())
+		}
+		return nil
+	})
+
+	return haves, err
+}
+
+// session opens an upload-pack session with the remote at `url`, returning
+// the references and capabilities it advertises.
+func (g *Native) session(
+	ctx context.Context,
+	url string,
+) (transport.UploadPackSession, *packp.AdvRefs, error) {
+	ep, err := transport.NewEndpoint(url)
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := client.NewClient(ep)
+	if err != nil {
+		return nil, nil, err
+	}
+	session, err := c.NewUploadPackSession(ep, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	adv, err := session.AdvertisedReferencesContext(ctx)
+	if err != nil {
+		_ = session.Close()
+		return nil, nil, err
+	}
+
+	return session, adv, nil
+}
+
+// uploadPack requests the pack described by `req`, and stores its objects in
+// the repo.
+func (g *Native) uploadPack(
+	ctx context.Context,
+	repo *gogit.Repository,
+	session transport.UploadPackSession,
+	req *packp.UploadPackRequest,
+) error {
+	res, err := session.UploadPack(ctx, req)
+	if errors.Is(err, transport.ErrEmptyUploadPackRequest) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Close() }()
+
+	var r io.Reader = res
+	switch {
+	case req.Capabilities.Supports(capability.Sideband64k):
+		r = sideband.NewDemuxer(sideband.Sideband64k, res)
+	case req.Capabilities.Supports(capability.Sideband):
+		r = sideband.NewDemuxer(sideband.Sideband, res)
+	}
+
+	if err := packfile.UpdateObjectStorage(repo.Storer, r); err != nil {
+		return err
+	}
+
+	return g.markPromisorPacks(repo)
+}
+
+// markPromisorPacks marks the packs of a partial clone as fetched from its
+// promisor remote, as the git binary does, so `git fsck` expects the objects
+// they leave out to be missing.
+func (g *Native) markPromisorPacks(repo *gogit.Repository) error {
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	st, ok := repo.Storer.(*filesystem.Storage)
+	if promisor(cfg) == "" || !ok {
+		return nil
+	}
+
+	dotGit := st.Filesystem()
+	packDir := dotGit.Join("objects", "pack")
+	infos, err := dotGit.ReadDir(packDir)
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		pack, ok := strings.CutSuffix(info.Name(), ".pack")
+		if !ok {
+			continue
+		}
+		marker := dotGit.Join(packDir, pack+".promisor")
+		if _, err := dotGit.Stat(marker); err == nil {
+			continue
+		}
+		f, err := dotGit.Create(marker)
+		if err != nil {
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Worktree create a working tree from the repo in `cloneDir` at `version` in
+// `dstDir`.  Files are written straight from the object store, so no `.git`
+// breadcrumb is left behind.  Blobs left out of a partial clone are fetched
+// from its promisor remote first.
+func (g *Native) Worktree(
+	ctx context.Context,
+	cloneDir string,
+	version string,
+	dstDir string,
+) error {
+	dst, err := g.appFs.Abs(dstDir)
+	if err != nil {
+		return err
+	}
+
+	g.logger.Info(
+		"extracting",
+		slog.String("from", cloneDir),
+		slog.String("version", version),
+		slog.String("to", dst),
+	)
+
+	repo, err := gogit.PlainOpen(cloneDir)
+	if err != nil {
+		return err
+	}
+	commit, err := g.commit(repo, version)
+	if err != nil {
+		return err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
+
+	var entries []object.TreeEntry
+	var lacking []plumbing.Hash
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if !entry.Mode.IsFile() {
+			continue
+		}
+		if repo.Storer.HasEncodedObject(entry.Hash) != nil {
+			lacking = append(lacking, entry.Hash)
+		}
+		entries = append(entries, object.TreeEntry{Name: name, Mode: entry.Mode, Hash: entry.Hash})
+	}
+	if len(lacking) > 0 {
+		if err := g.fetchObjects(ctx, repo, lacking); err != nil {
+			return err
+		}
+	}
+
+	if err := g.appFs.MkdirAll(dst, 0o755); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		blob, err := repo.BlobObject(entry.Hash)
+		if err != nil {
+			return fmt.Errorf("%s: %s", entry.Name, err)
+		}
+		f := object.NewFile(entry.Name, entry.Mode, blob)
+
+		path := g.appFs.Join(dst, g.appFs.FromSlash(f.Name))
+		if err := g.appFs.MkdirAll(g.appFs.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if f.Mode == filemode.Symlink {
+			target, err := f.Contents()
+			if err != nil {
+				return err
+			}
+			if err := g.appFs.Symlink(target, path); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := g.writeFile(f, path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fetchObjects fetches the objects `wants`, which a partial clone left out,
+// from its promisor remote.
+func (g *Native) fetchObjects(
+	ctx context.Context,
+	repo *gogit.Repository,
+	wants []plumbing.Hash,
+) error {
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	origin := promisor(cfg)
+	remote, ok := cfg.Remotes[origin]
+	if origin == "" || !ok || len(remote.URLs) == 0 {
+		return fmt.Errorf(
+			"%d objects are missing, and there is no remote to fetch them from",
+			len(wants),
+		)
+	}
+	g.logger.Debug(
+		"fetching missing objects",
+		slog.String("remote", origin),
+		slog.Int("count", len(wants)),
+	)
+
+	session, adv, err := g.session(ctx, remote.URLs[0])
+	if err != nil {
+		return err
+	}
+	defer func() { _ = session.Close() }()
+
+	req := packp.NewUploadPackRequestFromCapabilities(adv.Capabilities)
+	req.Wants = wants
+
+	return g.uploadPack(ctx, repo, session, req)
+}
+
+// promisor returns the name of the remote a partial clone fetches the objects
+// it lacks from, as the git binary records it.
+func promisor(cfg *gitconfig.Config) string {
+	for name := range cfg.Remotes {
+		if cfg.Raw.Section("remote").Subsection(name).Option("promisor") == "true" {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// writeFile copies the contents of the blob `f` to `path`, keeping its
+// executable bit.
+func (g *Native) writeFile(f *object.File, path string) error {
+	var perm fs.FileMode = 0o644
+	if f.Mode == filemode.Executable {
+		perm = 0o755
+	}
+
+	r, err := f.Reader()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+
+	w, err := g.appFs.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		_ = w.Close()
+		return err
+	}
+
+	return w.Close()
+}
+
+// Remote returns the name of the repo remote.
+func (g *Native) Remote(_ context.Context, cloneDir string) (string, error) {
+	repo, err := gogit.PlainOpen(cloneDir)
+	if err != nil {
+		return "", err
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(cfg.Remotes))
+	for name := range cfg.Remotes {
+		names = append(names, name)
+	}
+
+	return strings.Join(names, "\n"), nil
+}
+
+// RevParse resolves `version` to the full commit SHA it currently points to
+// in the repo at `cloneDir`.
+func (g *Native) RevParse(_ context.Context, cloneDir, version string) (string, error) {
+	repo, err := gogit.PlainOpen(cloneDir)
+	if err != nil {
+		return "", err
+	}
+	commit, err := g.commit(repo, version)
+	if err != nil {
+		return "", err
+	}
+
+	return commit.Hash.String(), nil
+}
+
+// commit resolves `version` to the commit it points to, peeling annotated
+// tags.
+func (g *Native) commit(repo *gogit.Repository, version string) (*object.Commit, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(version))
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve version %s: %s", version, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve version %s: %s", version, err)
+	}
+
+	return commit, nil
+}
+
+// Tags lists the tags known to the repo at `cloneDir`.
+func (g *Native) Tags(_ context.Context, cloneDir string) ([]string, error) {
+	repo, err := gogit.PlainOpen(cloneDir)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		tags = append(tags, ref.Name().Short())
+		return nil
+	})
+
+	return tags, err
+}
+
+// RemoteURL returns the url of the remote named `origin` in the repo at
+// `cloneDir`.
+func (g *Native) RemoteURL(_ context.Context, cloneDir, origin string) (string, error) {
+	repo, err := gogit.PlainOpen(cloneDir)
+	if err != nil {
+		return "", err
+	}
+	remote, err := repo.Remote(origin)
+	if err != nil {
+		return "", err
+	}
+	urls := remote.Config().URLs
+	if len(urls) == 0 {
+		return "", fmt.Errorf("remote %s has no url", origin)
+	}
+
+	return urls[0], nil
+}
+
+// Fsck verifies the objects in the repo at `cloneDir` can all be read, and
+// that every reference points to one of them.
+func (g *Native) Fsck(ctx context.Context, cloneDir string) error {
+	repo, err := gogit.PlainOpen(cloneDir)
+	if err != nil {
+		return err
+	}
+
+	objects, err := repo.Storer.IterEncodedObjects(plumbing.AnyObject)
+	if err != nil {
+		return err
+	}
+	err = objects.ForEach(func(o plumbing.EncodedObject) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r, err := o.Reader()
+		if err != nil {
+			return fmt.Errorf("object %s: %s", o.Hash(), err)
+		}
+		defer func() { _ = r.Close() }()
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			return fmt.Errorf("object %s: %s", o.Hash(), err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	refs, err := repo.References()
+	if err != nil {
+		return err
+	}
+
+	return refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		if _, err := repo.Storer.EncodedObject(plumbing.AnyObject, ref.Hash()); err != nil {
+			return fmt.Errorf("%s: %s", ref.Name(), err)
+		}
+		return nil
+	})
+}
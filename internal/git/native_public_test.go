@@ -0,0 +1,451 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package git_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/vfs/osfs"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/retr0h/gilt/v2/internal"
+	"github.com/retr0h/gilt/v2/internal/git"
+)
+
+type NativeGitManagerPublicTestSuite struct {
+	suite.Suite
+
+	appFs avfs.VFS
+
+	upstream   *gogit.Repository
+	gitURL     string
+	gitVersion string
+	origin     string
+	cloneDir   string
+	dstDir     string
+
+	gm internal.GitManager
+}
+
+func (suite *NativeGitManagerPublicTestSuite) NewTestGitManager() internal.GitManager {
+	return git.NewNative(
+		suite.appFs,
+		slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	)
+}
+
+// commit writes `files` into the upstream worktree and commits them.
+func (suite *NativeGitManagerPublicTestSuite) commit(files map[string]string) plumbing.Hash {
+	wt, err := suite.upstream.Worktree()
+	assert.NoError(suite.T(), err)
+	for name, content := range files {
+		path := filepath.Join(suite.gitURL, name)
+		assert.NoError(suite.T(), os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(suite.T(), os.WriteFile(path, []byte(content), 0o644))
+		_, err := wt.Add(name)
+		assert.NoError(suite.T(), err)
+	}
+	hash, err := wt.Commit("commit", &gogit.CommitOptions{
+		Author: &object.Signature{Name: "gilt", Email: "gilt@example.com", When: time.Now()},
+	})
+	assert.NoError(suite.T(), err)
+
+	return hash
+}
+
+// allowFilter lets the upstream serve partial clones, and the blobs they
+// later ask for.
+func (suite *NativeGitManagerPublicTestSuite) allowFilter() {
+	cfg, err := suite.upstream.Config()
+	assert.NoError(suite.T(), err)
+	cfg.Raw.Section("uploadpack").SetOption("allowFilter", "true")
+	cfg.Raw.Section("uploadpack").SetOption("allowAnySHA1InWant", "true")
+	assert.NoError(suite.T(), suite.upstream.SetConfig(cfg))
+}
+
+// blob returns the hash of the blob at `name` in the upstream HEAD.
+func (suite *NativeGitManagerPublicTestSuite) blob(name string) plumbing.Hash {
+	head, err := suite.upstream.Head()
+	assert.NoError(suite.T(), err)
+	commit, err := suite.upstream.CommitObject(head.Hash())
+	assert.NoError(suite.T(), err)
+	f, err := commit.File(name)
+	assert.NoError(suite.T(), err)
+
+	return f.Hash
+}
+
+func (suite *NativeGitManagerPublicTestSuite) SetupTest() {
+	suite.appFs = osfs.NewWithNoIdm()
+
+	dir := suite.T().TempDir()
+	suite.gitURL = filepath.Join(dir, "upstream")
+	suite.origin = "gilt"
+	suite.cloneDir = filepath.Join(dir, "cloneDir")
+	suite.dstDir = filepath.Join(dir, "dstDir")
+
+	upstream, err := gogit.PlainInit(suite.gitURL, false)
+	assert.NoError(suite.T(), err)
+	suite.upstream = upstream
+
+	hash := suite.commit(map[string]string{"README.md": "v1", "roles/x/a.yml": "a"})
+	suite.gitVersion = hash.String()
+	_, err = upstream.CreateTag("v1.0.0", hash, nil)
+	assert.NoError(suite.T(), err)
+	_, err = upstream.CreateTag("v1.1.0", hash, &gogit.CreateTagOptions{
+		Tagger:  &object.Signature{Name: "gilt", Email: "gilt@example.com", When: time.Now()},
+		Message: "annotated",
+	})
+	assert.NoError(suite.T(), err)
+
+	suite.gm = suite.NewTestGitManager()
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestCloneOk() {
+	err := suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+
+	remote, err := suite.gm.Remote(context.Background(), suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.origin, remote)
+
+	got, err := suite.gm.RevParse(context.Background(), suite.cloneDir, "HEAD")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.gitVersion, got)
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestCloneLeavesOutBlobs() {
+	suite.allowFilter()
+
+	err := suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+
+	repo, err := gogit.PlainOpen(suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	cfg, err := repo.Config()
+	assert.NoError(suite.T(), err)
+	remote := cfg.Raw.Section("remote").Subsection(suite.origin)
+	assert.Equal(suite.T(), "true", remote.Option("promisor"))
+	assert.Equal(suite.T(), "blob:none", remote.Option("partialclonefilter"))
+	assert.Error(suite.T(), repo.Storer.HasEncodedObject(suite.blob("roles/x/a.yml")))
+	assert.NoError(suite.T(), repo.Storer.HasEncodedObject(plumbing.NewHash(suite.gitVersion)))
+	packs, err := filepath.Glob(filepath.Join(suite.cloneDir, "objects", "pack", "*.pack"))
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), packs)
+	for _, pack := range packs {
+		assert.FileExists(suite.T(), strings.TrimSuffix(pack, ".pack")+".promisor")
+	}
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestCloneKeepsBlobsWhenFilterUnsupported() {
+	err := suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+
+	repo, err := gogit.PlainOpen(suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), repo.Storer.HasEncodedObject(suite.blob("roles/x/a.yml")))
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestCloneReplacesExistingDir() {
+	assert.NoError(suite.T(), os.MkdirAll(suite.cloneDir, 0o755))
+	assert.NoError(
+		suite.T(),
+		os.WriteFile(filepath.Join(suite.cloneDir, "junk"), []byte("junk"), 0o644),
+	)
+
+	err := suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.NoFileExists(suite.T(), filepath.Join(suite.cloneDir, "junk"))
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestCloneError() {
+	err := suite.gm.Clone(
+		context.Background(),
+		filepath.Join(suite.gitURL, "missing"),
+		suite.origin,
+		suite.cloneDir,
+	)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestUpdateOk() {
+	assert.NoError(
+		suite.T(),
+		suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir),
+	)
+	hash := suite.commit(map[string]string{"README.md": "v2"})
+	_, err := suite.upstream.CreateTag("v2.0.0", hash, nil)
+	assert.NoError(suite.T(), err)
+
+	err = suite.gm.Update(context.Background(), suite.origin, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+
+	got, err := suite.gm.RevParse(context.Background(), suite.cloneDir, "v2.0.0")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), hash.String(), got)
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestUpdateLeavesOutBlobs() {
+	suite.allowFilter()
+	assert.NoError(
+		suite.T(),
+		suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir),
+	)
+	hash := suite.commit(map[string]string{"roles/x/b.yml": "b"})
+
+	err := suite.gm.Update(context.Background(), suite.origin, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+
+	repo, err := gogit.PlainOpen(suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), repo.Storer.HasEncodedObject(hash))
+	assert.Error(suite.T(), repo.Storer.HasEncodedObject(suite.blob("roles/x/b.yml")))
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestUpdateAlreadyUpToDateOk() {
+	assert.NoError(
+		suite.T(),
+		suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir),
+	)
+
+	err := suite.gm.Update(context.Background(), suite.origin, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestUpdateError() {
+	err := suite.gm.Update(context.Background(), suite.origin, suite.cloneDir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestRemoteReturnsErrorWhenPartialCloneExtension() {
+	assert.NoError(
+		suite.T(),
+		suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir),
+	)
+	repo, err := gogit.PlainOpen(suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	cfg, err := repo.Config()
+	assert.NoError(suite.T(), err)
+	cfg.Raw.Section("extensions").SetOption("partialclone", suite.origin)
+	assert.NoError(suite.T(), repo.SetConfig(cfg))
+
+	_, err = suite.gm.Remote(context.Background(), suite.cloneDir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestRemoteError() {
+	_, err := suite.gm.Remote(context.Background(), suite.cloneDir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestRevParsePeelsAnnotatedTag() {
+	assert.NoError(
+		suite.T(),
+		suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir),
+	)
+
+	got, err := suite.gm.RevParse(context.Background(), suite.cloneDir, "v1.1.0")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.gitVersion, got)
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestRevParseShortHashOk() {
+	assert.NoError(
+		suite.T(),
+		suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir),
+	)
+
+	got, err := suite.gm.RevParse(context.Background(), suite.cloneDir, suite.gitVersion[:7])
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.gitVersion, got)
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestRevParseError() {
+	assert.NoError(
+		suite.T(),
+		suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir),
+	)
+
+	_, err := suite.gm.RevParse(context.Background(), suite.cloneDir, "v9.9.9")
+	assert.EqualError(
+		suite.T(),
+		err,
+		"unable to resolve version v9.9.9: reference not found",
+	)
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestTagsOk() {
+	assert.NoError(
+		suite.T(),
+		suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir),
+	)
+
+	got, err := suite.gm.Tags(context.Background(), suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.ElementsMatch(suite.T(), []string{"v1.0.0", "v1.1.0"}, got)
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestTagsError() {
+	_, err := suite.gm.Tags(context.Background(), suite.cloneDir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestRemoteURLOk() {
+	assert.NoError(
+		suite.T(),
+		suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir),
+	)
+
+	got, err := suite.gm.RemoteURL(context.Background(), suite.cloneDir, suite.origin)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.gitURL, got)
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestRemoteURLError() {
+	assert.NoError(
+		suite.T(),
+		suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir),
+	)
+
+	_, err := suite.gm.RemoteURL(context.Background(), suite.cloneDir, "missing")
+	assert.Error(suite.T(), err)
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestFsckOk() {
+	assert.NoError(
+		suite.T(),
+		suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir),
+	)
+
+	err := suite.gm.Fsck(context.Background(), suite.cloneDir)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestFsckError() {
+	assert.NoError(
+		suite.T(),
+		suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir),
+	)
+	assert.NoError(
+		suite.T(),
+		os.WriteFile(
+			filepath.Join(suite.cloneDir, "refs", "heads", "broken"),
+			[]byte("0123456789012345678901234567890123456789\n"),
+			0o644,
+		),
+	)
+
+	err := suite.gm.Fsck(context.Background(), suite.cloneDir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestWorktreeOk() {
+	assert.NoError(
+		suite.T(),
+		suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir),
+	)
+
+	err := suite.gm.Worktree(context.Background(), suite.cloneDir, "v1.0.0", suite.dstDir)
+	assert.NoError(suite.T(), err)
+
+	got, err := os.ReadFile(filepath.Join(suite.dstDir, "roles", "x", "a.yml"))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "a", string(got))
+	assert.NoFileExists(suite.T(), filepath.Join(suite.dstDir, ".git"))
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestWorktreeFetchesLackingBlobs() {
+	suite.allowFilter()
+	assert.NoError(
+		suite.T(),
+		suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir),
+	)
+
+	err := suite.gm.Worktree(context.Background(), suite.cloneDir, "v1.0.0", suite.dstDir)
+	assert.NoError(suite.T(), err)
+
+	got, err := os.ReadFile(filepath.Join(suite.dstDir, "roles", "x", "a.yml"))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "a", string(got))
+	repo, err := gogit.PlainOpen(suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), repo.Storer.HasEncodedObject(suite.blob("roles/x/a.yml")))
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestWorktreeReturnsErrorWhenBlobsUnavailable() {
+	suite.allowFilter()
+	assert.NoError(
+		suite.T(),
+		suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir),
+	)
+	assert.NoError(suite.T(), os.RemoveAll(suite.gitURL))
+
+	err := suite.gm.Worktree(context.Background(), suite.cloneDir, "v1.0.0", suite.dstDir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestWorktreeKeepsExecutableBit() {
+	script := filepath.Join(suite.gitURL, "run.sh")
+	assert.NoError(suite.T(), os.WriteFile(script, []byte("#!/bin/sh\n"), 0o755))
+	wt, err := suite.upstream.Worktree()
+	assert.NoError(suite.T(), err)
+	_, err = wt.Add("run.sh")
+	assert.NoError(suite.T(), err)
+	hash := suite.commit(map[string]string{})
+	assert.NoError(
+		suite.T(),
+		suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir),
+	)
+
+	err = suite.gm.Worktree(context.Background(), suite.cloneDir, hash.String(), suite.dstDir)
+	assert.NoError(suite.T(), err)
+
+	info, err := os.Stat(filepath.Join(suite.dstDir, "run.sh"))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), os.FileMode(0o755), info.Mode().Perm())
+}
+
+func (suite *NativeGitManagerPublicTestSuite) TestWorktreeError() {
+	assert.NoError(
+		suite.T(),
+		suite.gm.Clone(context.Background(), suite.gitURL, suite.origin, suite.cloneDir),
+	)
+
+	err := suite.gm.Worktree(context.Background(), suite.cloneDir, "v9.9.9", suite.dstDir)
+	assert.Error(suite.T(), err)
+}
+
+// In order for `go test` to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run.
+func TestNativeGitManagerPublicTestSuite(t *testing.T) {
+	suite.Run(t, new(NativeGitManagerPublicTestSuite))
+}
@@ -34,3 +34,10 @@ type Git struct {
 	execManager internal.ExecManager
 	logger      *slog.Logger
 }
+
+// Native implementation responsible for Git operations, performed in-process
+// with go-git rather than by the git binary.
+type Native struct {
+	appFs  avfs.VFS
+	logger *slog.Logger
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+// Package glob matches slash separated paths against doublestar patterns,
+// in which `**` matches any number of directories.
+package glob
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/avfs/avfs"
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// Valid reports whether `pattern` is a well formed pattern.
+func Valid(pattern string) bool {
+	return doublestar.ValidatePattern(trim(pattern))
+}
+
+// trim drops the trailing `/` of a pattern written as a directory, e.g.
+// `tests/`, as the paths it is matched against have none.
+func trim(pattern string) string {
+	if len(pattern) > 1 {
+		return strings.TrimSuffix(pattern, "/")
+	}
+
+	return pattern
+}
+
+// Match reports whether `name`, or one of the directories it is below,
+// matches one of `patterns`.  A pattern naming a directory, with or without
+// a trailing `/`, therefore matches everything in it.
+func Match(patterns []string, name string) bool {
+	for name != "." && name != "/" && name != "" {
+		for _, pattern := range patterns {
+			if ok, _ := doublestar.Match(trim(pattern), name); ok {
+				return true
+			}
+		}
+		name = path.Dir(name)
+	}
+
+	return false
+}
+
+// Selected reports whether `name` is matched by `include`, or `include` is
+// empty, and is not matched by `exclude`.
+func Selected(include []string, exclude []string, name string) bool {
+	if len(include) > 0 && !Match(include, name) {
+		return false
+	}
+
+	return !Match(exclude, name)
+}
+
+// Glob returns the paths below `dir` which `pattern`, relative to `dir`,
+// matches, in lexical order.  A trailing `/` is ignored.  A pattern without
+// `**` is matched by avfs.VFS.Glob.  Otherwise, nothing below a matched
+// directory is matched again, as the directory already holds it.
+func Glob(appFs avfs.VFS, dir string, pattern string) ([]string, error) {
+	pattern = trim(pattern)
+	if !strings.Contains(pattern, "**") {
+		return appFs.Glob(appFs.Join(dir, pattern))
+	}
+	if !doublestar.ValidatePattern(pattern) {
+		return nil, doublestar.ErrBadPattern
+	}
+
+	// Only walk the part of the tree the pattern can match
+	base, _ := doublestar.SplitPattern(pattern)
+	root := appFs.Join(dir, appFs.FromSlash(base))
+
+	var matches []string
+	err := appFs.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if p == root && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rel, err := appFs.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if ok, _ := doublestar.Match(pattern, appFs.ToSlash(rel)); !ok {
+			return nil
+		}
+
+		matches = append(matches, p)
+		if d.IsDir() {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
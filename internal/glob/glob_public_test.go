@@ -0,0 +1,169 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+package glob_test
+
+import (
+	"testing"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/vfs/memfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/retr0h/gilt/v2/internal/glob"
+)
+
+type GlobPublicTestSuite struct {
+	suite.Suite
+
+	appFs avfs.VFS
+}
+
+func (suite *GlobPublicTestSuite) SetupTest() {
+	suite.appFs = memfs.New()
+	for _, name := range []string{
+		"/work/roles/a/defaults/main.yml",
+		"/work/roles/a/tasks/main.yml",
+		"/work/roles/b/defaults/main.yml",
+		"/work/roles/b/defaults/extra.yaml",
+		"/work/README.md",
+	} {
+		_ = suite.appFs.MkdirAll(suite.appFs.Dir(name), 0o755)
+		_ = suite.appFs.WriteFile(name, []byte(name), 0o644)
+	}
+}
+
+func (suite *GlobPublicTestSuite) TestValid() {
+	assert.True(suite.T(), glob.Valid("templates/**/*.yaml"))
+	assert.True(suite.T(), glob.Valid("tests/"))
+	assert.False(suite.T(), glob.Valid("templates/[a"))
+}
+
+func (suite *GlobPublicTestSuite) TestMatch() {
+	tests := []struct {
+		patterns []string
+		name     string
+		expected bool
+	}{
+		{[]string{"tests"}, "tests", true},
+		{[]string{"tests"}, "tests/unit/a.yaml", true},
+		{[]string{"tests"}, "templates/tests/a.yaml", false},
+		{[]string{"tests/"}, "tests", true},
+		{[]string{"tests/"}, "tests/unit/a.yaml", true},
+		{[]string{"tests/"}, "templates/tests/a.yaml", false},
+		{[]string{"**/tests/"}, "templates/tests/a.yaml", true},
+		{[]string{"**/tests"}, "templates/tests/a.yaml", true},
+		{[]string{"**/*.md"}, "README.md", true},
+		{[]string{"**/*.md"}, "docs/usage/index.md", true},
+		{[]string{"*.md"}, "docs/index.md", false},
+		{[]string{".github", "ci"}, "ci/lint.sh", true},
+		{[]string{".github", "ci"}, "chart.yaml", false},
+		{nil, "chart.yaml", false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(
+			suite.T(),
+			test.expected,
+			glob.Match(test.patterns, test.name),
+			"%v %s",
+			test.patterns,
+			test.name,
+		)
+	}
+}
+
+func (suite *GlobPublicTestSuite) TestSelected() {
+	tests := []struct {
+		include  []string
+		exclude  []string
+		name     string
+		expected bool
+	}{
+		{nil, nil, "values.yaml", true},
+		{nil, []string{"tests"}, "tests/a.yaml", false},
+		{nil, []string{"tests"}, "values.yaml", true},
+		{[]string{"templates"}, nil, "templates/deployment.yaml", true},
+		{[]string{"templates"}, nil, "values.yaml", false},
+		{[]string{"templates"}, []string{"**/*_test.yaml"}, "templates/a_test.yaml", false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(
+			suite.T(),
+			test.expected,
+			glob.Selected(test.include, test.exclude, test.name),
+			"%v %v %s",
+			test.include,
+			test.exclude,
+			test.name,
+		)
+	}
+}
+
+func (suite *GlobPublicTestSuite) TestGlobRecursive() {
+	got, err := glob.Glob(suite.appFs, "/work", "roles/**/defaults/*.yml")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{
+		"/work/roles/a/defaults/main.yml",
+		"/work/roles/b/defaults/main.yml",
+	}, got)
+}
+
+func (suite *GlobPublicTestSuite) TestGlobDoesNotMatchBelowMatchedDir() {
+	got, err := glob.Glob(suite.appFs, "/work", "**/defaults")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{
+		"/work/roles/a/defaults",
+		"/work/roles/b/defaults",
+	}, got)
+}
+
+func (suite *GlobPublicTestSuite) TestGlobIgnoresTrailingSlash() {
+	got, err := glob.Glob(suite.appFs, "/work", "**/defaults/")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{
+		"/work/roles/a/defaults",
+		"/work/roles/b/defaults",
+	}, got)
+}
+
+func (suite *GlobPublicTestSuite) TestGlobWithoutDoubleStar() {
+	got, err := glob.Glob(suite.appFs, "/work", "roles/*/tasks")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"/work/roles/a/tasks"}, got)
+}
+
+func (suite *GlobPublicTestSuite) TestGlobMissingBaseMatchesNothing() {
+	got, err := glob.Glob(suite.appFs, "/work", "missing/**/*.yml")
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), got)
+}
+
+func (suite *GlobPublicTestSuite) TestGlobReturnsErrorOnGarbagePatterns() {
+	_, err := glob.Glob(suite.appFs, "/work", "roles/**/[a")
+	assert.Error(suite.T(), err)
+}
+
+// In order for `go test` to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run.
+func TestGlobPublicTestSuite(t *testing.T) {
+	suite.Run(t, new(GlobPublicTestSuite))
+}
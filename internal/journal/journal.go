@@ -0,0 +1,340 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+// Package journal sets aside the previous contents of each path an overlay
+// changes, so a failed overlay can be rolled back to exactly the state it
+// started from.
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/avfs/avfs"
+)
+
+// tmpPrefix starts the name of the directory a journal sets paths aside in.
+const tmpPrefix = "rollback-"
+
+// indexFile lists the entries of a journal, in the directory it sets paths
+// aside in.
+const indexFile = "index.json"
+
+// New factory to create a new Journal instance, which sets paths aside in a
+// temporary directory below `dir`.  It should be on the same device as the
+// paths, so setting them aside is a rename rather than a copy.
+func New(
+	appFs avfs.VFS,
+	dir string,
+	logger *slog.Logger,
+) *Journal {
+	return &Journal{
+		appFs:  appFs,
+		dir:    dir,
+		logger: logger,
+	}
+}
+
+// Create records `path` as about to be created, when it does not exist, so it
+// is removed on rollback.  An existing path is left out.
+func (j *Journal) Create(path string) error {
+	if _, err := j.appFs.Lstat(path); err == nil {
+		return nil
+	}
+
+	return j.Replace(path)
+}
+
+// Replace moves `path` aside, as it is about to be replaced or removed.  A
+// path which does not exist is recorded too, so whatever is created there is
+// removed on rollback.
+func (j *Journal) Replace(path string) error {
+	return j.record(path, func(backup string) error {
+		return j.move(path, backup)
+	})
+}
+
+// Modify copies `path` aside, as it is about to be changed in place.  The
+// copy only appears once whole.
+func (j *Journal) Modify(path string) error {
+	return j.record(path, func(backup string) error {
+		if err := j.copy(path, backup+".tmp"); err != nil {
+			return err
+		}
+		return j.appFs.Rename(backup+".tmp", backup)
+	})
+}
+
+// record set `path` aside with `save`, when it exists.  The entry is written
+// to the index before anything is set aside, so that whatever was set aside
+// is known should gilt be killed.
+func (j *Journal) record(path string, save func(backup string) error) error {
+	_, err := j.appFs.Lstat(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := j.begin(); err != nil {
+		return err
+	}
+
+	e := entry{Path: path}
+	if err == nil {
+		e.Backup = j.appFs.Join(j.tmpDir, strconv.Itoa(len(j.entries)))
+	}
+	j.entries = append(j.entries, e)
+	if err := j.writeIndex(); err != nil {
+		return err
+	}
+	if e.Backup == "" {
+		return nil
+	}
+	if err := save(e.Backup); err != nil {
+		// Nothing was set aside, which restore tells by the missing backup
+		j.entries = j.entries[:len(j.entries)-1]
+		return fmt.Errorf("unable to set aside %s: %s", path, err)
+	}
+
+	return nil
+}
+
+// begin make the directory paths are set aside in, on first use.
+func (j *Journal) begin() error {
+	if j.tmpDir != "" {
+		return nil
+	}
+	for dir := j.dir; dir != j.appFs.Dir(dir); dir = j.appFs.Dir(dir) {
+		if _, err := j.appFs.Stat(dir); err == nil {
+			break
+		}
+		j.madeDirs = append(j.madeDirs, dir)
+	}
+	if err := j.appFs.MkdirAll(j.dir, 0o755); err != nil {
+		return err
+	}
+	tmpDir, err := j.appFs.MkdirTemp(j.dir, tmpPrefix)
+	if err != nil {
+		return err
+	}
+	j.tmpDir = tmpDir
+
+	return nil
+}
+
+// writeIndex write the entries recorded so far to the index, replacing it
+// whole.
+func (j *Journal) writeIndex() error {
+	data, err := json.Marshal(j.entries)
+	if err != nil {
+		return err
+	}
+	path := j.appFs.Join(j.tmpDir, indexFile)
+	if err := j.appFs.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return err
+	}
+
+	return j.appFs.Rename(path+".tmp", path)
+}
+
+// move renames `src` to `dst`, or copies it when they are on different
+// devices.  A copy only appears at `dst` once whole.
+func (j *Journal) move(src string, dst string) error {
+	if err := j.appFs.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := j.copy(src, dst+".tmp"); err != nil {
+		return err
+	}
+	if err := j.appFs.Rename(dst+".tmp", dst); err != nil {
+		return err
+	}
+
+	return j.appFs.RemoveAll(src)
+}
+
+// copy copies the file, or directory tree, `src` to `dst`, keeping modes and
+// symlinks.
+func (j *Journal) copy(src string, dst string) error {
+	return j.appFs.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := j.appFs.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := j.appFs.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return j.appFs.MkdirAll(target, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := j.appFs.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return j.appFs.Symlink(link, target)
+		}
+		data, err := j.appFs.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return j.appFs.WriteFile(target, data, info.Mode().Perm())
+	})
+}
+
+// Commit drops what was set aside, keeping every change.
+func (j *Journal) Commit() error {
+	if j.tmpDir != "" {
+		// Removed first, so a journal only half removed has nothing to recover
+		err := j.appFs.Remove(j.appFs.Join(j.tmpDir, indexFile))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if err := j.appFs.RemoveAll(j.tmpDir); err != nil {
+			return err
+		}
+	}
+	j.reset()
+
+	return nil
+}
+
+// Rollback puts back what was set aside, and removes what was created, most
+// recent change first.  It keeps going past a path it cannot restore, and
+// reports every such path.
+func (j *Journal) Rollback() error {
+	var failed []error
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		e := j.entries[i]
+		j.logger.Info("rolling back", slog.String("path", e.Path))
+		if err := j.restore(e); err != nil {
+			failed = append(failed, fmt.Errorf("unable to restore %s: %s", e.Path, err))
+		}
+	}
+	// Left in place when something could not be restored from it
+	if len(failed) == 0 {
+		if err := j.Commit(); err != nil {
+			return err
+		}
+	}
+	j.reset()
+
+	return errors.Join(failed...)
+}
+
+// restore put `e` back the way it was recorded.  A path whose backup is
+// missing was never set aside, or was already restored, so is left alone.
+func (j *Journal) restore(e entry) error {
+	if e.Backup != "" {
+		if _, err := j.appFs.Lstat(e.Backup); errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+	}
+	if err := j.appFs.RemoveAll(e.Path); err != nil {
+		return err
+	}
+	if e.Backup == "" {
+		return nil
+	}
+	// Its parent may have been removed since
+	if err := j.appFs.MkdirAll(j.appFs.Dir(e.Path), 0o755); err != nil {
+		return err
+	}
+
+	return j.move(e.Backup, e.Path)
+}
+
+// Recover roll back each journal left below the journal's directory by an
+// overlay which never finished, e.g. as gilt was killed, so every path it
+// changed is put back.  It should run before anything else is recorded.
+func (j *Journal) Recover() error {
+	dirEntries, err := j.appFs.ReadDir(j.dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	var failed []error
+	for _, d := range dirEntries {
+		if !d.IsDir() || !strings.HasPrefix(d.Name(), tmpPrefix) {
+			continue
+		}
+		tmpDir := j.appFs.Join(j.dir, d.Name())
+		j.logger.Warn("recovering an unfinished overlay", slog.String("journal", tmpDir))
+		entries, err := j.readIndex(tmpDir)
+		if err != nil {
+			failed = append(failed, fmt.Errorf("unable to recover %s: %s", tmpDir, err))
+			continue
+		}
+		left := &Journal{
+			appFs:   j.appFs,
+			dir:     j.dir,
+			logger:  j.logger,
+			entries: entries,
+			tmpDir:  tmpDir,
+		}
+		if err := left.Rollback(); err != nil {
+			failed = append(failed, fmt.Errorf("unable to recover %s: %s", tmpDir, err))
+		}
+	}
+
+	return errors.Join(failed...)
+}
+
+// readIndex read the entries recorded in the index in `tmpDir`.  A journal
+// without one recorded nothing.
+func (j *Journal) readIndex(tmpDir string) ([]entry, error) {
+	data, err := j.appFs.ReadFile(j.appFs.Join(tmpDir, indexFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// reset forget everything recorded.  The directories the journal set paths
+// aside in are only removed once empty, and only when the journal made them.
+func (j *Journal) reset() {
+	for _, dir := range j.madeDirs {
+		_ = j.appFs.Remove(dir)
+	}
+	j.entries = nil
+	j.tmpDir = ""
+	j.madeDirs = nil
+}
@@ -0,0 +1,253 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package journal_test
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/vfs/memfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/retr0h/gilt/v2/internal/journal"
+)
+
+type JournalPublicTestSuite struct {
+	suite.Suite
+
+	appFs  avfs.VFS
+	dir    string
+	logger *slog.Logger
+}
+
+func (suite *JournalPublicTestSuite) NewTestJournal() *journal.Journal {
+	return journal.New(suite.appFs, suite.dir, suite.logger)
+}
+
+func (suite *JournalPublicTestSuite) SetupTest() {
+	suite.appFs = memfs.New()
+	suite.dir = "/.gilt"
+	suite.logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	_ = suite.appFs.MkdirAll("/library/sub", 0o755)
+	_ = suite.appFs.WriteFile("/library/1.txt", []byte("one"), 0o644)
+	_ = suite.appFs.WriteFile("/library/sub/2.txt", []byte("two"), 0o600)
+}
+
+func (suite *JournalPublicTestSuite) readFile(path string) string {
+	data, err := suite.appFs.ReadFile(path)
+	assert.NoError(suite.T(), err, path)
+	return string(data)
+}
+
+func (suite *JournalPublicTestSuite) TestRollbackRestoresReplaced() {
+	j := suite.NewTestJournal()
+
+	err := j.Replace("/library")
+	assert.NoError(suite.T(), err)
+	_, err = suite.appFs.Stat("/library")
+	assert.Error(suite.T(), err)
+	_ = suite.appFs.MkdirAll("/library", 0o755)
+	_ = suite.appFs.WriteFile("/library/new.txt", nil, 0o644)
+
+	err = j.Rollback()
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), "one", suite.readFile("/library/1.txt"))
+	assert.Equal(suite.T(), "two", suite.readFile("/library/sub/2.txt"))
+	_, err = suite.appFs.Stat("/library/new.txt")
+	assert.Error(suite.T(), err)
+	// Nothing is left set aside
+	_, err = suite.appFs.Stat(suite.dir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *JournalPublicTestSuite) TestRollbackRestoresModified() {
+	j := suite.NewTestJournal()
+
+	err := j.Modify("/library")
+	assert.NoError(suite.T(), err)
+	// Left in place to be changed
+	assert.Equal(suite.T(), "one", suite.readFile("/library/1.txt"))
+	_ = suite.appFs.WriteFile("/library/1.txt", []byte("changed"), 0o644)
+	_ = suite.appFs.WriteFile("/library/new.txt", nil, 0o644)
+
+	err = j.Rollback()
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), "one", suite.readFile("/library/1.txt"))
+	info, err := suite.appFs.Stat("/library/sub/2.txt")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), os.FileMode(0o600), info.Mode().Perm())
+	_, err = suite.appFs.Stat("/library/new.txt")
+	assert.Error(suite.T(), err)
+}
+
+func (suite *JournalPublicTestSuite) TestRollbackRemovesCreated() {
+	j := suite.NewTestJournal()
+
+	err := j.Create("/roles")
+	assert.NoError(suite.T(), err)
+	err = j.Replace("/roles/etcd")
+	assert.NoError(suite.T(), err)
+	_ = suite.appFs.MkdirAll("/roles/etcd", 0o755)
+
+	err = j.Rollback()
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.appFs.Stat("/roles")
+	assert.Error(suite.T(), err)
+}
+
+func (suite *JournalPublicTestSuite) TestCreateLeavesOutExisting() {
+	j := suite.NewTestJournal()
+
+	err := j.Create("/library")
+	assert.NoError(suite.T(), err)
+
+	err = j.Rollback()
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), "one", suite.readFile("/library/1.txt"))
+}
+
+func (suite *JournalPublicTestSuite) TestRollbackMostRecentFirst() {
+	j := suite.NewTestJournal()
+
+	// A file, and then the directory holding it
+	err := j.Replace("/library/1.txt")
+	assert.NoError(suite.T(), err)
+	_ = suite.appFs.WriteFile("/library/1.txt", []byte("changed"), 0o644)
+	err = j.Replace("/library")
+	assert.NoError(suite.T(), err)
+	_ = suite.appFs.MkdirAll("/library", 0o755)
+
+	err = j.Rollback()
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), "one", suite.readFile("/library/1.txt"))
+	assert.Equal(suite.T(), "two", suite.readFile("/library/sub/2.txt"))
+}
+
+func (suite *JournalPublicTestSuite) TestCommitKeepsChanges() {
+	j := suite.NewTestJournal()
+
+	err := j.Replace("/library/1.txt")
+	assert.NoError(suite.T(), err)
+	_ = suite.appFs.WriteFile("/library/1.txt", []byte("changed"), 0o644)
+
+	err = j.Commit()
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), "changed", suite.readFile("/library/1.txt"))
+	_, err = suite.appFs.Stat(suite.dir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *JournalPublicTestSuite) TestCommitKeepsExistingDir() {
+	_ = suite.appFs.MkdirAll(suite.dir, 0o755)
+	j := suite.NewTestJournal()
+
+	err := j.Replace("/library")
+	assert.NoError(suite.T(), err)
+	err = j.Commit()
+	assert.NoError(suite.T(), err)
+
+	entries, err := suite.appFs.ReadDir(suite.dir)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), entries)
+}
+
+func (suite *JournalPublicTestSuite) TestRecoverRestoresUnfinishedJournal() {
+	// Killed before either committing or rolling back
+	j := suite.NewTestJournal()
+	err := j.Replace("/library/1.txt")
+	assert.NoError(suite.T(), err)
+	_ = suite.appFs.WriteFile("/library/1.txt", []byte("changed"), 0o644)
+	err = j.Modify("/library/sub")
+	assert.NoError(suite.T(), err)
+	_ = suite.appFs.WriteFile("/library/sub/2.txt", []byte("changed"), 0o644)
+	err = j.Create("/roles")
+	assert.NoError(suite.T(), err)
+	_ = suite.appFs.MkdirAll("/roles/etcd", 0o755)
+
+	err = suite.NewTestJournal().Recover()
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), "one", suite.readFile("/library/1.txt"))
+	assert.Equal(suite.T(), "two", suite.readFile("/library/sub/2.txt"))
+	_, err = suite.appFs.Stat("/roles")
+	assert.Error(suite.T(), err)
+	entries, err := suite.appFs.ReadDir(suite.dir)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), entries)
+}
+
+func (suite *JournalPublicTestSuite) TestRecoverLeavesPathsNeverSetAside() {
+	j := suite.NewTestJournal()
+	err := j.Replace("/library/1.txt")
+	assert.NoError(suite.T(), err)
+	_ = suite.appFs.WriteFile("/library/1.txt", []byte("changed"), 0o644)
+	// Killed once the next path was recorded, but before it was set aside
+	journals, err := suite.appFs.ReadDir(suite.dir)
+	assert.NoError(suite.T(), err)
+	tmpDir := suite.appFs.Join(suite.dir, journals[0].Name())
+	_ = suite.appFs.WriteFile(
+		suite.appFs.Join(tmpDir, "index.json"),
+		[]byte(`[{"path":"/library/1.txt","backup":"`+tmpDir+`/0"},`+
+			`{"path":"/library/sub/2.txt","backup":"`+tmpDir+`/1"}]`),
+		0o644,
+	)
+
+	err = suite.NewTestJournal().Recover()
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), "one", suite.readFile("/library/1.txt"))
+	assert.Equal(suite.T(), "two", suite.readFile("/library/sub/2.txt"))
+}
+
+func (suite *JournalPublicTestSuite) TestRecoverIgnoresCommittedJournal() {
+	j := suite.NewTestJournal()
+	err := j.Replace("/library/1.txt")
+	assert.NoError(suite.T(), err)
+	_ = suite.appFs.WriteFile("/library/1.txt", []byte("changed"), 0o644)
+	err = j.Commit()
+	assert.NoError(suite.T(), err)
+
+	err = suite.NewTestJournal().Recover()
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), "changed", suite.readFile("/library/1.txt"))
+}
+
+func (suite *JournalPublicTestSuite) TestRecoverOkWhenDirMissing() {
+	err := suite.NewTestJournal().Recover()
+	assert.NoError(suite.T(), err)
+}
+
+// In order for `go test` to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run.
+func TestJournalPublicTestSuite(t *testing.T) {
+	suite.Run(t, new(JournalPublicTestSuite))
+}
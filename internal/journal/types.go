@@ -0,0 +1,51 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package journal
+
+import (
+	"log/slog"
+
+	"github.com/avfs/avfs"
+)
+
+// Journal records the state of each path an overlay is about to change, so
+// the change can be rolled back.
+type Journal struct {
+	appFs  avfs.VFS
+	dir    string
+	logger *slog.Logger
+
+	entries []entry
+	// tmpDir where paths are set aside, along with the index of entries, made
+	// below dir on first use
+	tmpDir string
+	// madeDirs dir, and those of its parents, made along with tmpDir; the
+	// deepest first
+	madeDirs []string
+}
+
+// entry a path, and where its previous contents were set aside.  Entries are
+// also written to the journal's index, so they can be recovered.
+type entry struct {
+	Path string `json:"path"`
+	// Backup empty when the path did not exist
+	Backup string `json:"backup,omitempty"`
+}
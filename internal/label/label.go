@@ -0,0 +1,218 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+// Package label matches the labels of a Repository against label
+// expressions, in which labels are combined with `&&`, `||`, `!`, and
+// parentheses, such as `helm && !experimental`.
+package label
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expr a parsed label expression.
+type Expr struct {
+	match func(labels map[string]bool) bool
+}
+
+// Match reports whether `labels` satisfy the expression.
+func (e Expr) Match(labels []string) bool {
+	set := make(map[string]bool, len(labels))
+	for _, l := range labels {
+		set[l] = true
+	}
+
+	return e.match(set)
+}
+
+// ValidName reports whether `name` is a well formed label; it may hold
+// letters, digits, and `-`, `_`, `.`, or `/`.
+func ValidName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, ch := range name {
+		if !isLabelChar(ch) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Valid reports whether `expr` is a well formed label expression.
+func Valid(expr string) bool {
+	_, err := Parse(expr)
+	return err == nil
+}
+
+// Parse parses the label expression `expr`.  `!` binds tighter than `&&`,
+// which binds tighter than `||`.
+func Parse(expr string) (Expr, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return Expr{}, err
+	}
+	if len(tokens) == 0 {
+		return Expr{}, fmt.Errorf("empty label expression")
+	}
+
+	p := &parser{expr: expr, tokens: tokens}
+	match, err := p.or()
+	if err != nil {
+		return Expr{}, err
+	}
+	if p.pos < len(p.tokens) {
+		return Expr{}, p.unexpected()
+	}
+
+	return Expr{match: match}, nil
+}
+
+// isLabelChar reports whether `ch` may appear in a label.
+func isLabelChar(ch rune) bool {
+	switch {
+	case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		return true
+	}
+
+	return strings.ContainsRune("-_./", ch)
+}
+
+// tokenize splits `expr` into labels and operators.
+func tokenize(expr string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(expr); {
+		ch := rune(expr[i])
+		switch {
+		case ch == ' ' || ch == '\t':
+			i++
+		case ch == '!' || ch == '(' || ch == ')':
+			tokens = append(tokens, string(ch))
+			i++
+		case strings.HasPrefix(expr[i:], "&&"), strings.HasPrefix(expr[i:], "||"):
+			tokens = append(tokens, expr[i:i+2])
+			i += 2
+		case isLabelChar(ch):
+			start := i
+			for i < len(expr) && isLabelChar(rune(expr[i])) {
+				i++
+			}
+			tokens = append(tokens, expr[start:i])
+		default:
+			return nil, fmt.Errorf("unexpected %q in label expression %q", expr[i:i+1], expr)
+		}
+	}
+
+	return tokens, nil
+}
+
+// parser a recursive descent parser over the tokens of a label expression.
+type parser struct {
+	expr   string
+	tokens []string
+	pos    int
+}
+
+// peek returns the next token, or "" at the end of the expression.
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+
+	return ""
+}
+
+// unexpected an error for the next token.
+func (p *parser) unexpected() error {
+	if p.pos >= len(p.tokens) {
+		return fmt.Errorf("label expression %q ends unexpectedly", p.expr)
+	}
+
+	return fmt.Errorf("unexpected %q in label expression %q", p.tokens[p.pos], p.expr)
+}
+
+// or parses `and ( "||" and )*`.
+func (p *parser) or() (func(map[string]bool) bool, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(labels map[string]bool) bool { return l(labels) || right(labels) }
+	}
+
+	return left, nil
+}
+
+// and parses `not ( "&&" not )*`.
+func (p *parser) and() (func(map[string]bool) bool, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.pos++
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(labels map[string]bool) bool { return l(labels) && right(labels) }
+	}
+
+	return left, nil
+}
+
+// not parses `"!" not | "(" or ")" | label`.
+func (p *parser) not() (func(map[string]bool) bool, error) {
+	switch token := p.peek(); {
+	case token == "!":
+		p.pos++
+		operand, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return func(labels map[string]bool) bool { return !operand(labels) }, nil
+	case token == "(":
+		p.pos++
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, p.unexpected()
+		}
+		p.pos++
+		return inner, nil
+	case ValidName(token):
+		p.pos++
+		return func(labels map[string]bool) bool { return labels[token] }, nil
+	}
+
+	return nil, p.unexpected()
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package label_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/retr0h/gilt/v2/internal/label"
+)
+
+type LabelPublicTestSuite struct {
+	suite.Suite
+}
+
+func (suite *LabelPublicTestSuite) TestValidName() {
+	assert.True(suite.T(), label.ValidName("helm"))
+	assert.True(suite.T(), label.ValidName("team/platform-v1.2_x"))
+	assert.False(suite.T(), label.ValidName(""))
+	assert.False(suite.T(), label.ValidName("helm charts"))
+	assert.False(suite.T(), label.ValidName("!helm"))
+}
+
+func (suite *LabelPublicTestSuite) TestMatch() {
+	tests := []struct {
+		expr     string
+		labels   []string
+		expected bool
+	}{
+		{"helm", []string{"ansible", "helm"}, true},
+		{"helm", []string{"ansible"}, false},
+		{"helm", nil, false},
+		{"!helm", nil, true},
+		{"helm && !experimental", []string{"helm"}, true},
+		{"helm && !experimental", []string{"helm", "experimental"}, false},
+		{"helm || docs", []string{"docs"}, true},
+		{"ansible || helm && experimental", []string{"ansible"}, true},
+		{"(ansible || helm) && experimental", []string{"ansible"}, false},
+		{"!!helm", []string{"helm"}, true},
+		{"!(helm || docs)", []string{"ansible"}, true},
+	}
+
+	for _, test := range tests {
+		expr, err := label.Parse(test.expr)
+		assert.NoError(suite.T(), err, test.expr)
+		assert.Equal(suite.T(), test.expected, expr.Match(test.labels), test.expr)
+	}
+}
+
+func (suite *LabelPublicTestSuite) TestParseReturnsError() {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"", `empty label expression`},
+		{"  ", `empty label expression`},
+		{"helm &&", `label expression "helm &&" ends unexpectedly`},
+		{"helm docs", `unexpected "docs" in label expression "helm docs"`},
+		{"(helm", `label expression "(helm" ends unexpectedly`},
+		{"helm)", `unexpected ")" in label expression "helm)"`},
+		{"helm & docs", `unexpected "&" in label expression "helm & docs"`},
+		{"&& helm", `unexpected "&&" in label expression "&& helm"`},
+	}
+
+	for _, test := range tests {
+		_, err := label.Parse(test.expr)
+		assert.EqualError(suite.T(), err, test.expected)
+		assert.False(suite.T(), label.Valid(test.expr))
+	}
+}
+
+// In order for `go test` to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run.
+func TestLabelPublicTestSuite(t *testing.T) {
+	suite.Run(t, new(LabelPublicTestSuite))
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+// Package lockfile reads and writes Giltfile.lock, which pins every
+// repository entry to an immutable commit SHA and content hash.
+package lockfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/avfs/avfs"
+	"gopkg.in/yaml.v3"
+)
+
+// Path returns the lock file that belongs to `giltFile`; the Giltfile's
+// extension is replaced with ".lock".
+func Path(giltFile string) string {
+	return strings.TrimSuffix(giltFile, filepath.Ext(giltFile)) + ".lock"
+}
+
+// Load reads the lock file at `path`.  A missing lock file is not an error;
+// an empty Lockfile is returned instead.
+func Load(appFs avfs.VFS, path string) (*Lockfile, error) {
+	l := &Lockfile{}
+
+	data, err := appFs.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return l, nil
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %s", path, err)
+	}
+
+	return l, nil
+}
+
+// Save writes the lock file to `path`.
+func (l *Lockfile) Save(appFs avfs.VFS, path string) error {
+	var b bytes.Buffer
+	b.WriteString(header)
+
+	ye := yaml.NewEncoder(&b)
+	ye.SetIndent(2)
+	if err := ye.Encode(l); err != nil {
+		return err
+	}
+
+	return appFs.WriteFile(path, b.Bytes(), 0o644)
+}
+
+// Verify checks that the Repository at `index` was locked with the same
+// source, Git or archive URL or local path, and version, and resolved to `commit`.
+func (l *Lockfile) Verify(index int, source, version, commit string) error {
+	if index >= len(l.Repositories) {
+		return fmt.Errorf("%s is missing from the lock file", ref(source, version))
+	}
+
+	locked := l.Repositories[index]
+	if locked.source() != source || locked.Version != version {
+		return fmt.Errorf(
+			"lock file is out of date: entry %d is %s, but the Giltfile has %s",
+			index, ref(locked.source(), locked.Version), ref(source, version),
+		)
+	}
+	if locked.Commit != commit {
+		return fmt.Errorf(
+			"%s resolved to %s, but the lock file has %s",
+			ref(source, version), commit, locked.Commit,
+		)
+	}
+
+	return nil
+}
+
+// VerifyCount checks that the lock file holds exactly `count` entries, one for
+// each Repository, and none left from entries since removed.
+func (l *Lockfile) VerifyCount(count int) error {
+	if len(l.Repositories) != count {
+		return fmt.Errorf(
+			"lock file is out of date: it has %d entries, but the Giltfile has %d",
+			len(l.Repositories), count,
+		)
+	}
+
+	return nil
+}
+
+// Locked returns the entry at `index`, when it was locked with the same
+// source, Git or archive URL or local path, and version.
+func (l *Lockfile) Locked(index int, source, version string) (Repository, bool) {
+	if index >= len(l.Repositories) {
+		return Repository{}, false
+	}
+
+	locked := l.Repositories[index]
+	if locked.source() != source || locked.Version != version {
+		return Repository{}, false
+	}
+
+	return locked, true
+}
+
+// VerifyHash checks that the content overlaid for the Repository at `index`
+// matches the locked content hash.
+func (l *Lockfile) VerifyHash(index int, hash string) error {
+	locked := l.Repositories[index]
+	if locked.Hash != hash {
+		return fmt.Errorf(
+			"%s content hash %s does not match the lock file's %s",
+			ref(locked.source(), locked.Version), hash, locked.Hash,
+		)
+	}
+
+	return nil
+}
+
+// source returns the URL, or local path, the Repository was vendored from.
+func (r Repository) source() string {
+	switch {
+	case r.Archive != "":
+		return r.Archive
+	case r.Path != "":
+		return r.Path
+	}
+
+	return r.Git
+}
+
+// ref formats a Repository's source and version for messages; archives and
+// local paths have no version.
+func ref(source, version string) string {
+	if version == "" {
+		return source
+	}
+
+	return source + "@" + version
+}
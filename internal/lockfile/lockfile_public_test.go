@@ -0,0 +1,176 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package lockfile_test
+
+import (
+	"testing"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/vfs/memfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/retr0h/gilt/v2/internal/lockfile"
+)
+
+type LockfilePublicTestSuite struct {
+	suite.Suite
+
+	appFs avfs.VFS
+	path  string
+	lock  *lockfile.Lockfile
+}
+
+func (suite *LockfilePublicTestSuite) SetupTest() {
+	suite.appFs = memfs.New()
+	suite.path = "/Giltfile.lock"
+	suite.lock = &lockfile.Lockfile{
+		Repositories: []lockfile.Repository{
+			{
+				Git:     "https://example.com/user/repo.git",
+				Version: "v1.1",
+				Commit:  "abc1234",
+				Hash:    "sha256:0123abcd",
+			},
+		},
+	}
+}
+
+func (suite *LockfilePublicTestSuite) TestPath() {
+	assert.Equal(suite.T(), "Giltfile.lock", lockfile.Path("Giltfile.yaml"))
+	assert.Equal(suite.T(), "/tmp/gilt.lock", lockfile.Path("/tmp/gilt.yml"))
+	assert.Equal(suite.T(), "Giltfile.lock", lockfile.Path("Giltfile"))
+}
+
+func (suite *LockfilePublicTestSuite) TestSaveAndLoadOk() {
+	err := suite.lock.Save(suite.appFs, suite.path)
+	assert.NoError(suite.T(), err)
+
+	got, err := lockfile.Load(suite.appFs, suite.path)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.lock, got)
+}
+
+func (suite *LockfilePublicTestSuite) TestLoadMissingFileReturnsEmptyLock() {
+	got, err := lockfile.Load(suite.appFs, suite.path)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), got.Repositories)
+}
+
+func (suite *LockfilePublicTestSuite) TestLoadReturnsErrorOnGarbage() {
+	_ = suite.appFs.WriteFile(suite.path, []byte("repositories: {"), 0o644)
+
+	_, err := lockfile.Load(suite.appFs, suite.path)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *LockfilePublicTestSuite) TestVerify() {
+	repo := suite.lock.Repositories[0]
+	tests := []struct {
+		index    int
+		git      string
+		version  string
+		commit   string
+		expected string
+	}{
+		{0, repo.Git, repo.Version, repo.Commit, ""},
+		{
+			0,
+			repo.Git,
+			repo.Version,
+			"fedcba9",
+			"resolved to fedcba9, but the lock file has abc1234",
+		},
+		{0, repo.Git, "v1.2", repo.Commit, "lock file is out of date"},
+		{
+			0,
+			"https://example.com/user/other.git",
+			repo.Version,
+			repo.Commit,
+			"lock file is out of date",
+		},
+		{1, repo.Git, repo.Version, repo.Commit, "missing from the lock file"},
+	}
+
+	for _, test := range tests {
+		err := suite.lock.Verify(test.index, test.git, test.version, test.commit)
+		if test.expected != "" {
+			assert.ErrorContains(suite.T(), err, test.expected)
+		} else {
+			assert.NoError(suite.T(), err)
+		}
+	}
+}
+
+func (suite *LockfilePublicTestSuite) TestVerifyArchive() {
+	archive := "https://example.com/user/repo-1.1.tar.gz"
+	suite.lock.Repositories = append(suite.lock.Repositories, lockfile.Repository{
+		Archive: archive,
+		Commit:  "sha256:0123abcd",
+		Hash:    "sha256:4567cdef",
+	})
+
+	assert.NoError(suite.T(), suite.lock.Verify(1, archive, "", "sha256:0123abcd"))
+	assert.EqualError(
+		suite.T(),
+		suite.lock.Verify(1, archive, "", "sha256:fedcba98"),
+		archive+" resolved to sha256:fedcba98, but the lock file has sha256:0123abcd",
+	)
+	assert.ErrorContains(
+		suite.T(),
+		suite.lock.Verify(0, archive, "", "sha256:0123abcd"),
+		"lock file is out of date",
+	)
+}
+
+func (suite *LockfilePublicTestSuite) TestVerifyCount() {
+	assert.NoError(suite.T(), suite.lock.VerifyCount(1))
+	assert.EqualError(
+		suite.T(),
+		suite.lock.VerifyCount(2),
+		"lock file is out of date: it has 1 entries, but the Giltfile has 2",
+	)
+	assert.ErrorContains(suite.T(), suite.lock.VerifyCount(0), "lock file is out of date")
+}
+
+func (suite *LockfilePublicTestSuite) TestLocked() {
+	repo := suite.lock.Repositories[0]
+
+	got, ok := suite.lock.Locked(0, repo.Git, repo.Version)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), repo, got)
+
+	_, ok = suite.lock.Locked(0, repo.Git, "v1.2")
+	assert.False(suite.T(), ok)
+	_, ok = suite.lock.Locked(1, repo.Git, repo.Version)
+	assert.False(suite.T(), ok)
+}
+
+func (suite *LockfilePublicTestSuite) TestVerifyHash() {
+	assert.NoError(suite.T(), suite.lock.VerifyHash(0, "sha256:0123abcd"))
+	assert.ErrorContains(suite.T(), suite.lock.VerifyHash(0, "sha256:fedcba98"), "does not match")
+}
+
+// In order for `go test` to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run.
+func TestLockfilePublicTestSuite(t *testing.T) {
+	suite.Run(t, new(LockfilePublicTestSuite))
+}
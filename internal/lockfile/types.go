@@ -0,0 +1,51 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package lockfile
+
+// header is written at the top of every lock file.
+const header = "# Generated by gilt overlay. DO NOT EDIT.\n---\n"
+
+// Lockfile the resolved state of every Repository in a Giltfile.
+type Lockfile struct {
+	// Repositories locked Repository entries, in Giltfile order.
+	Repositories []Repository `yaml:"repositories"`
+}
+
+// Repository the resolved state of a single Repository entry.
+type Repository struct {
+	// Name the Repository's name in the Giltfile, when it has one.
+	Name string `yaml:"name,omitempty"`
+	// Git url of the Git repository.
+	Git string `yaml:"git,omitempty"`
+	// Archive url of the archive, when not vendored from Git.
+	Archive string `yaml:"archive,omitempty"`
+	// Path local directory, when not vendored from Git or an archive.
+	Path string `yaml:"path,omitempty"`
+	// Version the version requested in the Giltfile.
+	Version string `yaml:"version,omitempty"`
+	// Tag the tag a semantic version constraint in Version resolved to.
+	Tag string `yaml:"tag,omitempty"`
+	// Commit the commit SHA the version resolved to, or the archive's
+	// checksum.  A local path has none.
+	Commit string `yaml:"commit,omitempty"`
+	// Hash content hash of the files that were overlaid.
+	Hash string `yaml:"hash"`
+}
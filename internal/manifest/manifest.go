@@ -0,0 +1,134 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+// Package manifest reads and writes the install manifest, which records every
+// file and directory gilt overlaid into the project, so they can be removed
+// again.
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/avfs/avfs"
+)
+
+// Path returns the manifest that belongs to `giltFile`; it lives in the
+// ".gilt" directory next to the Giltfile, below a directory named after the
+// Giltfile without its extension, so each Giltfile keeps its own.
+func Path(giltFile string) string {
+	base := filepath.Base(giltFile)
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+	return filepath.Join(filepath.Dir(giltFile), ".gilt", name, "manifest.json")
+}
+
+// Load reads the manifest at `path`.  A missing manifest is not an error; an
+// empty Manifest is returned instead.
+func Load(appFs avfs.VFS, path string) (*Manifest, error) {
+	m := &Manifest{}
+
+	data, err := appFs.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return m, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %s", path, err)
+	}
+
+	return m, nil
+}
+
+// Save writes the manifest to `path`, creating its directory if needed.
+func (m *Manifest) Save(appFs avfs.VFS, path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := appFs.MkdirAll(appFs.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return appFs.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Dirs returns the set of directories recorded in the manifest.
+func (m *Manifest) Dirs() map[string]bool {
+	dirs := make(map[string]bool)
+	for _, r := range m.Repositories {
+		for _, dir := range r.Dirs {
+			dirs[dir] = true
+		}
+	}
+
+	return dirs
+}
+
+// Orphans returns the entries of `previous` trimmed to the paths which are
+// not recorded in `m`, and which still exist.  Entries left with no paths are
+// dropped.
+func (m *Manifest) Orphans(appFs avfs.VFS, previous *Manifest) []Repository {
+	recorded := make(map[string]bool)
+	for _, r := range m.Repositories {
+		for _, path := range r.Files {
+			recorded[path] = true
+		}
+		for _, path := range r.Dirs {
+			recorded[path] = true
+		}
+	}
+	keep := func(paths []string) []string {
+		var kept []string
+		for _, path := range paths {
+			if recorded[path] {
+				continue
+			}
+			if _, err := appFs.Lstat(path); err == nil {
+				kept = append(kept, path)
+			}
+		}
+		return kept
+	}
+
+	var orphans []Repository
+	for _, r := range previous.Repositories {
+		orphan := Repository{
+			Name:    r.Name,
+			Git:     r.Git,
+			Archive: r.Archive,
+			Path:    r.Path,
+			Files:   keep(r.Files),
+			Dirs:    keep(r.Dirs),
+		}
+		if len(orphan.Files)+len(orphan.Dirs) > 0 {
+			orphans = append(orphans, orphan)
+		}
+	}
+
+	return orphans
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package manifest_test
+
+import (
+	"testing"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/vfs/memfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/retr0h/gilt/v2/internal/manifest"
+)
+
+type ManifestPublicTestSuite struct {
+	suite.Suite
+
+	appFs    avfs.VFS
+	path     string
+	manifest *manifest.Manifest
+}
+
+func (suite *ManifestPublicTestSuite) SetupTest() {
+	suite.appFs = memfs.New()
+	suite.path = "/.gilt/Giltfile/manifest.json"
+	suite.manifest = &manifest.Manifest{
+		Repositories: []manifest.Repository{
+			{
+				Name:  "repo",
+				Git:   "https://example.com/user/repo.git",
+				Files: []string{"/library/1.txt", "/library/2.txt"},
+				Dirs:  []string{"/library"},
+			},
+		},
+	}
+}
+
+func (suite *ManifestPublicTestSuite) TestPath() {
+	assert.Equal(suite.T(), ".gilt/Giltfile/manifest.json", manifest.Path("Giltfile.yaml"))
+	assert.Equal(suite.T(), "/tmp/.gilt/gilt/manifest.json", manifest.Path("/tmp/gilt.yml"))
+	// Giltfiles sharing a directory keep their own manifests
+	assert.Equal(suite.T(), "/tmp/.gilt/a/manifest.json", manifest.Path("/tmp/a.yaml"))
+	assert.Equal(suite.T(), "/tmp/.gilt/b/manifest.json", manifest.Path("/tmp/b.yaml"))
+}
+
+func (suite *ManifestPublicTestSuite) TestSaveAndLoadOk() {
+	err := suite.manifest.Save(suite.appFs, suite.path)
+	assert.NoError(suite.T(), err)
+
+	got, err := manifest.Load(suite.appFs, suite.path)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.manifest, got)
+}
+
+func (suite *ManifestPublicTestSuite) TestLoadMissingFileReturnsEmptyManifest() {
+	got, err := manifest.Load(suite.appFs, suite.path)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), got.Repositories)
+}
+
+func (suite *ManifestPublicTestSuite) TestLoadReturnsErrorOnGarbage() {
+	_ = suite.appFs.WriteFile("/manifest.json", []byte("{"), 0o644)
+
+	_, err := manifest.Load(suite.appFs, "/manifest.json")
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ManifestPublicTestSuite) TestDirs() {
+	assert.Equal(suite.T(), map[string]bool{"/library": true}, suite.manifest.Dirs())
+}
+
+func (suite *ManifestPublicTestSuite) TestOrphans() {
+	_ = suite.appFs.MkdirAll("/library", 0o755)
+	_ = suite.appFs.WriteFile("/library/1.txt", []byte("one"), 0o644)
+	_ = suite.appFs.WriteFile("/library/2.txt", []byte("two"), 0o644)
+	current := &manifest.Manifest{
+		Repositories: []manifest.Repository{
+			{
+				Git:   "https://example.com/user/repo.git",
+				Files: []string{"/library/1.txt"},
+				Dirs:  []string{"/library"},
+			},
+		},
+	}
+	previous := &manifest.Manifest{
+		Repositories: append(suite.manifest.Repositories, manifest.Repository{
+			Git:   "https://example.com/user/gone.git",
+			Files: []string{"/gone/1.txt"},
+		}),
+	}
+
+	got := current.Orphans(suite.appFs, previous)
+	assert.Equal(suite.T(), []manifest.Repository{
+		{
+			Name:  "repo",
+			Git:   "https://example.com/user/repo.git",
+			Files: []string{"/library/2.txt"},
+		},
+	}, got)
+}
+
+// In order for `go test` to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run.
+func TestManifestPublicTestSuite(t *testing.T) {
+	suite.Run(t, new(ManifestPublicTestSuite))
+}
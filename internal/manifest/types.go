@@ -0,0 +1,43 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package manifest
+
+// Manifest the files and directories gilt overlaid into the project.
+type Manifest struct {
+	// Repositories the paths each Repository overlaid.
+	Repositories []Repository `json:"repositories"`
+}
+
+// Repository the paths a single Repository entry overlaid.
+type Repository struct {
+	// Name the Repository's name in the Giltfile, when it has one.
+	Name string `json:"name,omitempty"`
+	// Git url of the Git repository.
+	Git string `json:"git,omitempty"`
+	// Archive url of the archive, when not vendored from Git.
+	Archive string `json:"archive,omitempty"`
+	// Path local directory, when not vendored from Git or an archive.
+	Path string `json:"path,omitempty"`
+	// Files files gilt created or overwrote.
+	Files []string `json:"files,omitempty"`
+	// Dirs directories gilt created.
+	Dirs []string `json:"dirs,omitempty"`
+}
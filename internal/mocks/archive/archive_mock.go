@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/archive.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/archive.go -destination=internal/mocks/archive/archive_mock.go -package=archive
+//
+
+// Package archive is a generated GoMock package.
+package archive
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockArchiveManager is a mock of ArchiveManager interface.
+type MockArchiveManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockArchiveManagerMockRecorder
+	isgomock struct{}
+}
+
+// MockArchiveManagerMockRecorder is the mock recorder for MockArchiveManager.
+type MockArchiveManagerMockRecorder struct {
+	mock *MockArchiveManager
+}
+
+// NewMockArchiveManager creates a new mock instance.
+func NewMockArchiveManager(ctrl *gomock.Controller) *MockArchiveManager {
+	mock := &MockArchiveManager{ctrl: ctrl}
+	mock.recorder = &MockArchiveManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockArchiveManager) EXPECT() *MockArchiveManagerMockRecorder {
+	return m.recorder
+}
+
+// Fetch mocks base method.
+func (m *MockArchiveManager) Fetch(ctx context.Context, url, checksum, dstDir string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fetch", ctx, url, checksum, dstDir)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Fetch indicates an expected call of Fetch.
+func (mr *MockArchiveManagerMockRecorder) Fetch(ctx, url, checksum, dstDir any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fetch", reflect.TypeOf((*MockArchiveManager)(nil).Fetch), ctx, url, checksum, dstDir)
+}
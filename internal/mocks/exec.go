@@ -21,9 +21,14 @@
 // Package mocks provides mock interfaces for testing.
 package mocks
 
+import (
+	"context"
+)
+
 // ExecManager manager responsible for exec operations.
 type ExecManager interface {
-	RunCmd(name string, args []string) error
-	RunCmdInDir(name string, args []string, cwd string) error
+	RunCmd(ctx context.Context, name string, args []string) error
+	RunCmdInDir(ctx context.Context, name string, args []string, cwd string) error
+	RunCmdWithEnv(ctx context.Context, name string, args []string, cwd string, env []string) error
 	RunInTempDir(dir, pattern string, fn func(string) error) error
 }
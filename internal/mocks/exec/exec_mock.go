@@ -1,10 +1,16 @@
 // Code generated by MockGen. DO NOT EDIT.
 // Source: internal/exec.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/exec.go -destination=internal/mocks/exec/exec_mock.go -package=exec
+//
 
 // Package exec is a generated GoMock package.
 package exec
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "go.uber.org/mock/gomock"
@@ -14,6 +20,7 @@ import (
 type MockExecManager struct {
 	ctrl     *gomock.Controller
 	recorder *MockExecManagerMockRecorder
+	isgomock struct{}
 }
 
 // MockExecManagerMockRecorder is the mock recorder for MockExecManager.
@@ -34,33 +41,48 @@ func (m *MockExecManager) EXPECT() *MockExecManagerMockRecorder {
 }
 
 // RunCmd mocks base method.
-func (m *MockExecManager) RunCmd(name string, args []string) (string, error) {
+func (m *MockExecManager) RunCmd(ctx context.Context, name string, args []string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RunCmd", name, args)
+	ret := m.ctrl.Call(m, "RunCmd", ctx, name, args)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // RunCmd indicates an expected call of RunCmd.
-func (mr *MockExecManagerMockRecorder) RunCmd(name, args interface{}) *gomock.Call {
+func (mr *MockExecManagerMockRecorder) RunCmd(ctx, name, args any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunCmd", reflect.TypeOf((*MockExecManager)(nil).RunCmd), name, args)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunCmd", reflect.TypeOf((*MockExecManager)(nil).RunCmd), ctx, name, args)
 }
 
 // RunCmdInDir mocks base method.
-func (m *MockExecManager) RunCmdInDir(name string, args []string, cwd string) (string, error) {
+func (m *MockExecManager) RunCmdInDir(ctx context.Context, name string, args []string, cwd string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RunCmdInDir", name, args, cwd)
+	ret := m.ctrl.Call(m, "RunCmdInDir", ctx, name, args, cwd)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // RunCmdInDir indicates an expected call of RunCmdInDir.
-func (mr *MockExecManagerMockRecorder) RunCmdInDir(name, args, cwd interface{}) *gomock.Call {
+func (mr *MockExecManagerMockRecorder) RunCmdInDir(ctx, name, args, cwd any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunCmdInDir", reflect.TypeOf((*MockExecManager)(nil).RunCmdInDir), name, args, cwd)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunCmdInDir", reflect.TypeOf((*MockExecManager)(nil).RunCmdInDir), ctx, name, args, cwd)
+}
+
+// RunCmdWithEnv mocks base method.
+func (m *MockExecManager) RunCmdWithEnv(ctx context.Context, name string, args []string, cwd string, env []string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunCmdWithEnv", ctx, name, args, cwd, env)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunCmdWithEnv indicates an expected call of RunCmdWithEnv.
+func (mr *MockExecManagerMockRecorder) RunCmdWithEnv(ctx, name, args, cwd, env any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunCmdWithEnv", reflect.TypeOf((*MockExecManager)(nil).RunCmdWithEnv), ctx, name, args, cwd, env)
 }
 
 // RunInTempDir mocks base method.
@@ -72,7 +94,7 @@ func (m *MockExecManager) RunInTempDir(dir, pattern string, fn func(string) erro
 }
 
 // RunInTempDir indicates an expected call of RunInTempDir.
-func (mr *MockExecManagerMockRecorder) RunInTempDir(dir, pattern, fn interface{}) *gomock.Call {
+func (mr *MockExecManagerMockRecorder) RunInTempDir(dir, pattern, fn any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunInTempDir", reflect.TypeOf((*MockExecManager)(nil).RunInTempDir), dir, pattern, fn)
 }
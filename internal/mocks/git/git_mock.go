@@ -1,10 +1,16 @@
 // Code generated by MockGen. DO NOT EDIT.
 // Source: internal/git.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/git.go -destination=internal/mocks/git/git_mock.go -package=git
+//
 
 // Package git is a generated GoMock package.
 package git
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "go.uber.org/mock/gomock"
@@ -14,6 +20,7 @@ import (
 type MockGitManager struct {
 	ctrl     *gomock.Controller
 	recorder *MockGitManagerMockRecorder
+	isgomock struct{}
 }
 
 // MockGitManagerMockRecorder is the mock recorder for MockGitManager.
@@ -34,58 +41,117 @@ func (m *MockGitManager) EXPECT() *MockGitManagerMockRecorder {
 }
 
 // Clone mocks base method.
-func (m *MockGitManager) Clone(gitURL, origin, cloneDir string) error {
+func (m *MockGitManager) Clone(ctx context.Context, gitURL, origin, cloneDir string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Clone", gitURL, origin, cloneDir)
+	ret := m.ctrl.Call(m, "Clone", ctx, gitURL, origin, cloneDir)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Clone indicates an expected call of Clone.
-func (mr *MockGitManagerMockRecorder) Clone(gitURL, origin, cloneDir interface{}) *gomock.Call {
+func (mr *MockGitManagerMockRecorder) Clone(ctx, gitURL, origin, cloneDir any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clone", reflect.TypeOf((*MockGitManager)(nil).Clone), gitURL, origin, cloneDir)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clone", reflect.TypeOf((*MockGitManager)(nil).Clone), ctx, gitURL, origin, cloneDir)
+}
+
+// Fsck mocks base method.
+func (m *MockGitManager) Fsck(ctx context.Context, cloneDir string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fsck", ctx, cloneDir)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Fsck indicates an expected call of Fsck.
+func (mr *MockGitManagerMockRecorder) Fsck(ctx, cloneDir any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fsck", reflect.TypeOf((*MockGitManager)(nil).Fsck), ctx, cloneDir)
 }
 
 // Remote mocks base method.
-func (m *MockGitManager) Remote(cloneDir string) (string, error) {
+func (m *MockGitManager) Remote(ctx context.Context, cloneDir string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Remote", cloneDir)
+	ret := m.ctrl.Call(m, "Remote", ctx, cloneDir)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Remote indicates an expected call of Remote.
-func (mr *MockGitManagerMockRecorder) Remote(cloneDir interface{}) *gomock.Call {
+func (mr *MockGitManagerMockRecorder) Remote(ctx, cloneDir any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remote", reflect.TypeOf((*MockGitManager)(nil).Remote), ctx, cloneDir)
+}
+
+// RemoteURL mocks base method.
+func (m *MockGitManager) RemoteURL(ctx context.Context, cloneDir, origin string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoteURL", ctx, cloneDir, origin)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoteURL indicates an expected call of RemoteURL.
+func (mr *MockGitManagerMockRecorder) RemoteURL(ctx, cloneDir, origin any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoteURL", reflect.TypeOf((*MockGitManager)(nil).RemoteURL), ctx, cloneDir, origin)
+}
+
+// RevParse mocks base method.
+func (m *MockGitManager) RevParse(ctx context.Context, cloneDir, version string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevParse", ctx, cloneDir, version)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevParse indicates an expected call of RevParse.
+func (mr *MockGitManagerMockRecorder) RevParse(ctx, cloneDir, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevParse", reflect.TypeOf((*MockGitManager)(nil).RevParse), ctx, cloneDir, version)
+}
+
+// Tags mocks base method.
+func (m *MockGitManager) Tags(ctx context.Context, cloneDir string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Tags", ctx, cloneDir)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Tags indicates an expected call of Tags.
+func (mr *MockGitManagerMockRecorder) Tags(ctx, cloneDir any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remote", reflect.TypeOf((*MockGitManager)(nil).Remote), cloneDir)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tags", reflect.TypeOf((*MockGitManager)(nil).Tags), ctx, cloneDir)
 }
 
 // Update mocks base method.
-func (m *MockGitManager) Update(origin, cloneDir string) error {
+func (m *MockGitManager) Update(ctx context.Context, origin, cloneDir string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", origin, cloneDir)
+	ret := m.ctrl.Call(m, "Update", ctx, origin, cloneDir)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockGitManagerMockRecorder) Update(origin, cloneDir interface{}) *gomock.Call {
+func (mr *MockGitManagerMockRecorder) Update(ctx, origin, cloneDir any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockGitManager)(nil).Update), origin, cloneDir)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockGitManager)(nil).Update), ctx, origin, cloneDir)
 }
 
 // Worktree mocks base method.
-func (m *MockGitManager) Worktree(cloneDir, version, dstDir string) error {
+func (m *MockGitManager) Worktree(ctx context.Context, cloneDir, version, dstDir string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Worktree", cloneDir, version, dstDir)
+	ret := m.ctrl.Call(m, "Worktree", ctx, cloneDir, version, dstDir)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Worktree indicates an expected call of Worktree.
-func (mr *MockGitManagerMockRecorder) Worktree(cloneDir, version, dstDir interface{}) *gomock.Call {
+func (mr *MockGitManagerMockRecorder) Worktree(ctx, cloneDir, version, dstDir any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Worktree", reflect.TypeOf((*MockGitManager)(nil).Worktree), cloneDir, version, dstDir)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Worktree", reflect.TypeOf((*MockGitManager)(nil).Worktree), ctx, cloneDir, version, dstDir)
 }
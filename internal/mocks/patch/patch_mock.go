@@ -0,0 +1,54 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/patch.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/patch.go -destination=internal/mocks/patch/patch_mock.go -package=patch
+//
+
+// Package patch is a generated GoMock package.
+package patch
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPatchManager is a mock of PatchManager interface.
+type MockPatchManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockPatchManagerMockRecorder
+	isgomock struct{}
+}
+
+// MockPatchManagerMockRecorder is the mock recorder for MockPatchManager.
+type MockPatchManagerMockRecorder struct {
+	mock *MockPatchManager
+}
+
+// NewMockPatchManager creates a new mock instance.
+func NewMockPatchManager(ctrl *gomock.Controller) *MockPatchManager {
+	mock := &MockPatchManager{ctrl: ctrl}
+	mock.recorder = &MockPatchManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPatchManager) EXPECT() *MockPatchManagerMockRecorder {
+	return m.recorder
+}
+
+// Apply mocks base method.
+func (m *MockPatchManager) Apply(patchFile, dir string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Apply", patchFile, dir)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Apply indicates an expected call of Apply.
+func (mr *MockPatchManagerMockRecorder) Apply(patchFile, dir any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Apply", reflect.TypeOf((*MockPatchManager)(nil).Apply), patchFile, dir)
+}
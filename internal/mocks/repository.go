@@ -21,12 +21,27 @@
 package mocks
 
 import (
+	"context"
+
+	"github.com/retr0h/gilt/v2/internal"
 	"github.com/retr0h/gilt/v2/pkg/config"
 )
 
 // RepositoryManager manager responsible for Repository operations.
 type RepositoryManager interface {
-	Clone(config config.Repository, cloneDir string) (string, error)
-	Worktree(config config.Repository, cloneDir string, targetDir string) error
-	CopySources(config config.Repository, cloneDir string) error
+	Clone(
+		ctx context.Context,
+		config config.Repository,
+		cloneDir string,
+	) (dir string, state string, err error)
+	Worktree(ctx context.Context, config config.Repository, cloneDir string, targetDir string) error
+	CopySources(config config.Repository, cloneDir string, copied func(dst string)) error
+	Resolve(
+		ctx context.Context,
+		config config.Repository,
+		cloneDir string,
+	) (commit string, tag string, err error)
+	Hash(config config.Repository, worktreeDir string) (string, error)
+	Tags(ctx context.Context, config config.Repository, cloneDir string) ([]string, error)
+	Targets(config config.Repository, worktreeDir string) ([]internal.Target, error)
 }
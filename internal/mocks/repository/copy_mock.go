@@ -1,5 +1,10 @@
 // Code generated by MockGen. DO NOT EDIT.
 // Source: internal/repository/types.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/types.go -destination=internal/mocks/repository/copy_mock.go -package=repository
+//
 
 // Package repository is a generated GoMock package.
 package repository
@@ -14,6 +19,7 @@ import (
 type MockCopyManager struct {
 	ctrl     *gomock.Controller
 	recorder *MockCopyManagerMockRecorder
+	isgomock struct{}
 }
 
 // MockCopyManagerMockRecorder is the mock recorder for MockCopyManager.
@@ -42,11 +48,25 @@ func (m *MockCopyManager) CopyDir(src, dst string) error {
 }
 
 // CopyDir indicates an expected call of CopyDir.
-func (mr *MockCopyManagerMockRecorder) CopyDir(src, dst interface{}) *gomock.Call {
+func (mr *MockCopyManagerMockRecorder) CopyDir(src, dst any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyDir", reflect.TypeOf((*MockCopyManager)(nil).CopyDir), src, dst)
 }
 
+// CopyDirMatching mocks base method.
+func (m *MockCopyManager) CopyDirMatching(src, dst string, include, exclude []string, copied func(string)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyDirMatching", src, dst, include, exclude, copied)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CopyDirMatching indicates an expected call of CopyDirMatching.
+func (mr *MockCopyManagerMockRecorder) CopyDirMatching(src, dst, include, exclude, copied any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyDirMatching", reflect.TypeOf((*MockCopyManager)(nil).CopyDirMatching), src, dst, include, exclude, copied)
+}
+
 // CopyFile mocks base method.
 func (m *MockCopyManager) CopyFile(src, dst string) error {
 	m.ctrl.T.Helper()
@@ -56,7 +76,21 @@ func (m *MockCopyManager) CopyFile(src, dst string) error {
 }
 
 // CopyFile indicates an expected call of CopyFile.
-func (mr *MockCopyManagerMockRecorder) CopyFile(src, dst interface{}) *gomock.Call {
+func (mr *MockCopyManagerMockRecorder) CopyFile(src, dst any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyFile", reflect.TypeOf((*MockCopyManager)(nil).CopyFile), src, dst)
 }
+
+// MergeDir mocks base method.
+func (m *MockCopyManager) MergeDir(src, dst string, include, exclude []string, copied func(string)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeDir", src, dst, include, exclude, copied)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MergeDir indicates an expected call of MergeDir.
+func (mr *MockCopyManagerMockRecorder) MergeDir(src, dst, include, exclude, copied any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeDir", reflect.TypeOf((*MockCopyManager)(nil).MergeDir), src, dst, include, exclude, copied)
+}
@@ -1,12 +1,19 @@
 // Code generated by MockGen. DO NOT EDIT.
 // Source: internal/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository.go -destination=internal/mocks/repository/repository_mock.go -package=repository
+//
 
 // Package repository is a generated GoMock package.
 package repository
 
 import (
+	context "context"
 	reflect "reflect"
 
+	internal "github.com/retr0h/gilt/v2/internal"
 	config "github.com/retr0h/gilt/v2/pkg/config"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -15,6 +22,7 @@ import (
 type MockRepositoryManager struct {
 	ctrl     *gomock.Controller
 	recorder *MockRepositoryManagerMockRecorder
+	isgomock struct{}
 }
 
 // MockRepositoryManagerMockRecorder is the mock recorder for MockRepositoryManager.
@@ -35,44 +43,106 @@ func (m *MockRepositoryManager) EXPECT() *MockRepositoryManagerMockRecorder {
 }
 
 // Clone mocks base method.
-func (m *MockRepositoryManager) Clone(config config.Repository, cloneDir string) (string, error) {
+func (m *MockRepositoryManager) Clone(ctx context.Context, arg1 config.Repository, cloneDir string) (string, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Clone", config, cloneDir)
+	ret := m.ctrl.Call(m, "Clone", ctx, arg1, cloneDir)
 	ret0, _ := ret[0].(string)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // Clone indicates an expected call of Clone.
-func (mr *MockRepositoryManagerMockRecorder) Clone(config, cloneDir interface{}) *gomock.Call {
+func (mr *MockRepositoryManagerMockRecorder) Clone(ctx, arg1, cloneDir any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clone", reflect.TypeOf((*MockRepositoryManager)(nil).Clone), config, cloneDir)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clone", reflect.TypeOf((*MockRepositoryManager)(nil).Clone), ctx, arg1, cloneDir)
 }
 
 // CopySources mocks base method.
-func (m *MockRepositoryManager) CopySources(config config.Repository, cloneDir string) error {
+func (m *MockRepositoryManager) CopySources(arg0 config.Repository, cloneDir string, copied func(string)) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CopySources", config, cloneDir)
+	ret := m.ctrl.Call(m, "CopySources", arg0, cloneDir, copied)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CopySources indicates an expected call of CopySources.
-func (mr *MockRepositoryManagerMockRecorder) CopySources(config, cloneDir interface{}) *gomock.Call {
+func (mr *MockRepositoryManagerMockRecorder) CopySources(arg0, cloneDir, copied any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopySources", reflect.TypeOf((*MockRepositoryManager)(nil).CopySources), arg0, cloneDir, copied)
+}
+
+// Hash mocks base method.
+func (m *MockRepositoryManager) Hash(arg0 config.Repository, worktreeDir string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Hash", arg0, worktreeDir)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Hash indicates an expected call of Hash.
+func (mr *MockRepositoryManagerMockRecorder) Hash(arg0, worktreeDir any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Hash", reflect.TypeOf((*MockRepositoryManager)(nil).Hash), arg0, worktreeDir)
+}
+
+// Resolve mocks base method.
+func (m *MockRepositoryManager) Resolve(ctx context.Context, arg1 config.Repository, cloneDir string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resolve", ctx, arg1, cloneDir)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Resolve indicates an expected call of Resolve.
+func (mr *MockRepositoryManagerMockRecorder) Resolve(ctx, arg1, cloneDir any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resolve", reflect.TypeOf((*MockRepositoryManager)(nil).Resolve), ctx, arg1, cloneDir)
+}
+
+// Tags mocks base method.
+func (m *MockRepositoryManager) Tags(ctx context.Context, arg1 config.Repository, cloneDir string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Tags", ctx, arg1, cloneDir)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Tags indicates an expected call of Tags.
+func (mr *MockRepositoryManagerMockRecorder) Tags(ctx, arg1, cloneDir any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tags", reflect.TypeOf((*MockRepositoryManager)(nil).Tags), ctx, arg1, cloneDir)
+}
+
+// Targets mocks base method.
+func (m *MockRepositoryManager) Targets(arg0 config.Repository, worktreeDir string) ([]internal.Target, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Targets", arg0, worktreeDir)
+	ret0, _ := ret[0].([]internal.Target)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Targets indicates an expected call of Targets.
+func (mr *MockRepositoryManagerMockRecorder) Targets(arg0, worktreeDir any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopySources", reflect.TypeOf((*MockRepositoryManager)(nil).CopySources), config, cloneDir)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Targets", reflect.TypeOf((*MockRepositoryManager)(nil).Targets), arg0, worktreeDir)
 }
 
 // Worktree mocks base method.
-func (m *MockRepositoryManager) Worktree(config config.Repository, cloneDir, targetDir string) error {
+func (m *MockRepositoryManager) Worktree(ctx context.Context, arg1 config.Repository, cloneDir, targetDir string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Worktree", config, cloneDir, targetDir)
+	ret := m.ctrl.Call(m, "Worktree", ctx, arg1, cloneDir, targetDir)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Worktree indicates an expected call of Worktree.
-func (mr *MockRepositoryManagerMockRecorder) Worktree(config, cloneDir, targetDir interface{}) *gomock.Call {
+func (mr *MockRepositoryManagerMockRecorder) Worktree(ctx, arg1, cloneDir, targetDir any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Worktree", reflect.TypeOf((*MockRepositoryManager)(nil).Worktree), config, cloneDir, targetDir)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Worktree", reflect.TypeOf((*MockRepositoryManager)(nil).Worktree), ctx, arg1, cloneDir, targetDir)
 }
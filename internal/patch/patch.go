@@ -0,0 +1,232 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+// Package patch applies unified diffs, as made by `git diff` or `diff -u`, to
+// a directory without the `git` binary.
+package patch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/avfs/avfs"
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+)
+
+// New factory to create a new Patch instance.
+func New(
+	appFs avfs.VFS,
+	logger *slog.Logger,
+) *Patch {
+	return &Patch{
+		appFs:  appFs,
+		logger: logger,
+	}
+}
+
+// Apply the diffs in `patchFile` to the files below `dir`.  Every diff is
+// applied in memory first, so nothing in `dir` changes unless the whole patch
+// applies, and several diffs to the same file apply one after the other.  A
+// hunk which does not apply is reported by its file, number, and header.
+func (p *Patch) Apply(patchFile, dir string) error {
+	p.logger.Info("applying patch", slog.String("patch", patchFile), slog.String("dir", dir))
+
+	data, err := p.appFs.ReadFile(patchFile)
+	if err != nil {
+		return err
+	}
+	files, _, err := gitdiff.Parse(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("unable to parse %s: %s", patchFile, err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("%s contains no diffs", patchFile)
+	}
+	// Like `git apply`, the leading directory of names in a traditional
+	// diff, such as "a/" and "b/", is stripped; Git's own diffs already are
+	if !bytes.Contains(data, []byte("diff --git ")) {
+		for _, f := range files {
+			f.OldName = stripDir(f.OldName)
+			f.NewName = stripDir(f.NewName)
+		}
+	}
+
+	buf := &buffer{files: make(map[string]*file)}
+	for _, f := range files {
+		if err := p.apply(dir, f, buf); err != nil {
+			return fmt.Errorf("unable to apply %s: %s", patchFile, err)
+		}
+	}
+
+	for _, path := range buf.paths {
+		f := buf.files[path]
+		if !f.exists {
+			if f.existed {
+				if err := p.appFs.Remove(f.path); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := p.appFs.MkdirAll(p.appFs.Dir(f.path), 0o755); err != nil {
+			return err
+		}
+		if err := p.appFs.WriteFile(f.path, f.data, f.mode); err != nil {
+			return err
+		}
+		// WriteFile leaves the mode of an existing file alone
+		if err := p.appFs.Chmod(f.path, f.mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// apply a single file's diff `f` to the files below `dir`, as patched so far
+// in `buf`.
+func (p *Patch) apply(dir string, f *gitdiff.File, buf *buffer) error {
+	var src []byte
+	var perm fs.FileMode = 0o644
+
+	if !f.IsNew {
+		oldPath, err := p.join(dir, f.OldName)
+		if err != nil {
+			return err
+		}
+		old, err := p.load(buf, oldPath)
+		if err != nil {
+			return err
+		}
+		if !old.exists {
+			return fmt.Errorf("%s: %s", f.OldName, fs.ErrNotExist)
+		}
+		src, perm = old.data, old.mode
+		if f.IsDelete || f.IsRename {
+			old.exists = false
+			old.data = nil
+		}
+	}
+	if f.IsDelete {
+		return nil
+	}
+
+	name := f.NewName
+	newPath, err := p.join(dir, name)
+	if err != nil {
+		return err
+	}
+
+	var dst bytes.Buffer
+	if f.IsBinary {
+		if err := gitdiff.Apply(&dst, bytes.NewReader(src), f); err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+	} else {
+		applier := gitdiff.NewTextApplier(&dst, bytes.NewReader(src))
+		for i, frag := range f.TextFragments {
+			if err := applier.ApplyFragment(frag); err != nil {
+				return hunkError(name, i+1, frag, err)
+			}
+		}
+		if err := applier.Close(); err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+	}
+
+	if f.NewMode != 0 {
+		perm = f.NewMode.Perm()
+	}
+	target, err := p.load(buf, newPath)
+	if err != nil {
+		return err
+	}
+	target.exists = true
+	target.data = dst.Bytes()
+	target.mode = perm
+
+	return nil
+}
+
+// load returns the file at `path` from `buf`, reading it into `buf` when no
+// diff touched it yet.
+func (p *Patch) load(buf *buffer, path string) (*file, error) {
+	if f, ok := buf.files[path]; ok {
+		return f, nil
+	}
+
+	f := &file{path: path}
+	info, err := p.appFs.Stat(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if f.data, err = p.appFs.ReadFile(path); err != nil {
+			return nil, err
+		}
+		f.existed = true
+		f.exists = true
+		f.mode = info.Mode().Perm()
+	}
+	buf.files[path] = f
+	buf.paths = append(buf.paths, path)
+
+	return f, nil
+}
+
+// hunkError describe why the `n`th hunk, `frag`, of the diff to `name` did
+// not apply.
+func hunkError(name string, n int, frag *gitdiff.TextFragment, err error) error {
+	header := strings.TrimSpace(frag.Header())
+
+	var applyErr *gitdiff.ApplyError
+	if errors.As(err, &applyErr) && applyErr.Line > 0 {
+		return fmt.Errorf(
+			"%s: hunk #%d %s does not apply at line %d: %s",
+			name, n, header, applyErr.Line, err,
+		)
+	}
+
+	return fmt.Errorf("%s: hunk #%d %s does not apply: %s", name, n, header, err)
+}
+
+// stripDir remove the leading directory of `name`.
+func stripDir(name string) string {
+	if _, after, ok := strings.Cut(name, "/"); ok {
+		return after
+	}
+
+	return name
+}
+
+// join resolve the patched file `name` below `dir`, refusing names which
+// would leave it.
+func (p *Patch) join(dir, name string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return "", fmt.Errorf("illegal path %s", name)
+	}
+
+	return p.appFs.Join(dir, p.appFs.FromSlash(name)), nil
+}
@@ -0,0 +1,272 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+package patch_test
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/vfs/memfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/retr0h/gilt/v2/internal"
+	"github.com/retr0h/gilt/v2/internal/patch"
+)
+
+const values = `replicas: 1
+image: nginx
+tag: "1.25"
+port: 80
+`
+
+const valuesPatch = `diff --git a/values.yaml b/values.yaml
+index 1111111..2222222 100644
+--- a/values.yaml
++++ b/values.yaml
+@@ -1,4 +1,4 @@
+-replicas: 1
++replicas: 3
+ image: nginx
+ tag: "1.25"
+ port: 80
+`
+
+type PatchPublicTestSuite struct {
+	suite.Suite
+
+	appFs  avfs.VFS
+	dir    string
+	logger *slog.Logger
+}
+
+func (suite *PatchPublicTestSuite) NewPatchManager() internal.PatchManager {
+	return patch.New(
+		suite.appFs,
+		suite.logger,
+	)
+}
+
+func (suite *PatchPublicTestSuite) SetupTest() {
+	suite.appFs = memfs.New()
+	suite.dir = "/worktree"
+	suite.logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	_ = suite.appFs.MkdirAll(suite.dir, 0o755)
+	_ = suite.appFs.WriteFile(suite.appFs.Join(suite.dir, "values.yaml"), []byte(values), 0o644)
+}
+
+func (suite *PatchPublicTestSuite) writePatch(data string) string {
+	patchFile := "/fix.patch"
+	_ = suite.appFs.WriteFile(patchFile, []byte(data), 0o644)
+	return patchFile
+}
+
+func (suite *PatchPublicTestSuite) readFile(name string) string {
+	data, err := suite.appFs.ReadFile(suite.appFs.Join(suite.dir, name))
+	assert.NoError(suite.T(), err)
+	return string(data)
+}
+
+func (suite *PatchPublicTestSuite) TestApplyOk() {
+	p := suite.NewPatchManager()
+	patchFile := suite.writePatch(valuesPatch)
+
+	err := p.Apply(patchFile, suite.dir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(
+		suite.T(),
+		"replicas: 3\nimage: nginx\ntag: \"1.25\"\nport: 80\n",
+		suite.readFile("values.yaml"),
+	)
+}
+
+func (suite *PatchPublicTestSuite) TestApplyOkWithoutGitHeader() {
+	p := suite.NewPatchManager()
+	patchFile := suite.writePatch(`--- a/values.yaml
++++ b/values.yaml
+@@ -3,2 +3,2 @@
+ tag: "1.25"
+-port: 80
++port: 8080
+`)
+
+	err := p.Apply(patchFile, suite.dir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(
+		suite.T(),
+		"replicas: 1\nimage: nginx\ntag: \"1.25\"\nport: 8080\n",
+		suite.readFile("values.yaml"),
+	)
+}
+
+func (suite *PatchPublicTestSuite) TestApplyCreatesDeletesAndRenamesFiles() {
+	_ = suite.appFs.WriteFile(suite.appFs.Join(suite.dir, "old.txt"), []byte("old\n"), 0o644)
+	_ = suite.appFs.WriteFile(suite.appFs.Join(suite.dir, "moved.txt"), []byte("moved\n"), 0o644)
+	p := suite.NewPatchManager()
+	patchFile := suite.writePatch(`diff --git a/templates/new.yaml b/templates/new.yaml
+new file mode 100755
+index 0000000..3333333
+--- /dev/null
++++ b/templates/new.yaml
+@@ -0,0 +1 @@
++new
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+index 4444444..0000000
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-old
+diff --git a/moved.txt b/renamed.txt
+similarity index 100%
+rename from moved.txt
+rename to renamed.txt
+`)
+
+	err := p.Apply(patchFile, suite.dir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "new\n", suite.readFile("templates/new.yaml"))
+	info, err := suite.appFs.Stat(suite.appFs.Join(suite.dir, "templates", "new.yaml"))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), os.FileMode(0o755), info.Mode().Perm())
+	_, err = suite.appFs.Stat(suite.appFs.Join(suite.dir, "old.txt"))
+	assert.Error(suite.T(), err)
+	_, err = suite.appFs.Stat(suite.appFs.Join(suite.dir, "moved.txt"))
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), "moved\n", suite.readFile("renamed.txt"))
+}
+
+func (suite *PatchPublicTestSuite) TestApplyAppliesDiffsToSameFileInSequence() {
+	p := suite.NewPatchManager()
+	patchFile := suite.writePatch(valuesPatch + `diff --git a/values.yaml b/values.yaml
+index 2222222..3333333 100644
+--- a/values.yaml
++++ b/values.yaml
+@@ -1,4 +1,4 @@
+-replicas: 3
++replicas: 5
+ image: nginx
+ tag: "1.25"
+ port: 80
+`)
+
+	err := p.Apply(patchFile, suite.dir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(
+		suite.T(),
+		"replicas: 5\nimage: nginx\ntag: \"1.25\"\nport: 80\n",
+		suite.readFile("values.yaml"),
+	)
+}
+
+func (suite *PatchPublicTestSuite) TestApplyPatchesRenamedFile() {
+	p := suite.NewPatchManager()
+	patchFile := suite.writePatch(`diff --git a/values.yaml b/chart.yaml
+similarity index 100%
+rename from values.yaml
+rename to chart.yaml
+` + strings.ReplaceAll(valuesPatch, "values.yaml", "chart.yaml"))
+
+	err := p.Apply(patchFile, suite.dir)
+	assert.NoError(suite.T(), err)
+	_, err = suite.appFs.Stat(suite.appFs.Join(suite.dir, "values.yaml"))
+	assert.Error(suite.T(), err)
+	assert.Equal(
+		suite.T(),
+		"replicas: 3\nimage: nginx\ntag: \"1.25\"\nport: 80\n",
+		suite.readFile("chart.yaml"),
+	)
+}
+
+func (suite *PatchPublicTestSuite) TestApplyReturnsErrorNamingFailedHunk() {
+	p := suite.NewPatchManager()
+	patchFile := suite.writePatch(`--- a/values.yaml
++++ b/values.yaml
+@@ -1,2 +1,2 @@
+-replicas: 1
++replicas: 3
+ image: nginx
+@@ -3,2 +3,2 @@
+ tag: "1.25"
+-port: 443
++port: 8443
+`)
+
+	err := p.Apply(patchFile, suite.dir)
+	assert.ErrorContains(
+		suite.T(),
+		err,
+		"values.yaml: hunk #2 @@ -3,2 +3,2 @@ does not apply at line 4",
+	)
+	// Nothing is written unless the whole patch applies
+	assert.Equal(suite.T(), values, suite.readFile("values.yaml"))
+}
+
+func (suite *PatchPublicTestSuite) TestApplyReturnsErrorWhenFileMissing() {
+	p := suite.NewPatchManager()
+	patchFile := suite.writePatch(`--- a/missing.yaml
++++ b/missing.yaml
+@@ -1 +1 @@
+-a
++b
+`)
+
+	err := p.Apply(patchFile, suite.dir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PatchPublicTestSuite) TestApplyReturnsErrorOnIllegalPath() {
+	p := suite.NewPatchManager()
+	patchFile := suite.writePatch(`--- /dev/null
++++ b/../evil.yaml
+@@ -0,0 +1 @@
++evil
+`)
+
+	err := p.Apply(patchFile, suite.dir)
+	assert.ErrorContains(suite.T(), err, "illegal path")
+	_, err = suite.appFs.Stat("/evil.yaml")
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PatchPublicTestSuite) TestApplyReturnsErrorWhenPatchEmpty() {
+	p := suite.NewPatchManager()
+	patchFile := suite.writePatch("just some text\n")
+
+	err := p.Apply(patchFile, suite.dir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PatchPublicTestSuite) TestApplyReturnsErrorWhenPatchMissing() {
+	p := suite.NewPatchManager()
+
+	err := p.Apply("/missing.patch", suite.dir)
+	assert.Error(suite.T(), err)
+}
+
+// In order for `go test` to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run.
+func TestPatchPublicTestSuite(t *testing.T) {
+	suite.Run(t, new(PatchPublicTestSuite))
+}
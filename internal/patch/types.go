@@ -0,0 +1,54 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+package patch
+
+import (
+	"io/fs"
+	"log/slog"
+
+	"github.com/avfs/avfs"
+)
+
+// Patch implementation responsible for patch operations.
+type Patch struct {
+	appFs  avfs.VFS
+	logger *slog.Logger
+}
+
+// file a file the diffs touch, as patched so far.
+type file struct {
+	// path of the file below the patched directory.
+	path string
+	// existed whether the file was there before patching.
+	existed bool
+	// exists whether the file is there once patched, with `data` and `mode`;
+	// false when a diff deleted, or renamed, it.
+	exists bool
+	data   []byte
+	mode   fs.FileMode
+}
+
+// buffer holds each file the diffs touch in memory, so several diffs to the
+// same file apply in sequence, and it is written once.
+type buffer struct {
+	files map[string]*file
+	// paths of `files`, in the order they were first touched.
+	paths []string
+}
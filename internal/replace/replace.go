@@ -0,0 +1,68 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+// Package replace reads replace files, which point Git repositories named in
+// the Giltfile at local paths, as the `replace` directive in go.mod does.
+package replace
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/avfs/avfs"
+)
+
+// Load reads the replace file at `path`, and returns the local path each Git
+// url is replaced with.  Each line maps a Git url to a path, such as:
+//
+//	https://github.com/retr0h/ansible-etcd.git => ../ansible-etcd
+//
+// Blank lines, and lines starting with `#`, are ignored.  Relative paths are
+// relative to the replace file.
+func Load(appFs avfs.VFS, path string) (map[string]string, error) {
+	data, err := appFs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	replacements := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		git, dir, ok := strings.Cut(line, "=>")
+		git = strings.TrimSpace(git)
+		dir = strings.TrimSpace(dir)
+		if !ok || git == "" || dir == "" {
+			return nil, fmt.Errorf("%s:%d: expected <git url> => <path>", path, n)
+		}
+		if !appFs.IsAbs(dir) {
+			dir = appFs.Join(appFs.Dir(path), dir)
+		}
+		replacements[git] = dir
+	}
+
+	return replacements, scanner.Err()
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package replace_test
+
+import (
+	"testing"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/vfs/memfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/retr0h/gilt/v2/internal/replace"
+)
+
+type ReplacePublicTestSuite struct {
+	suite.Suite
+
+	appFs avfs.VFS
+	path  string
+}
+
+func (suite *ReplacePublicTestSuite) SetupTest() {
+	suite.appFs = memfs.New()
+	suite.path = "/project/gilt.replace"
+	_ = suite.appFs.MkdirAll("/project", 0o755)
+}
+
+func (suite *ReplacePublicTestSuite) TestLoadOk() {
+	data := `# local forks
+https://example.com/user/repo.git => ../repo
+
+https://example.com/user/other.git=>/src/other
+`
+	_ = suite.appFs.WriteFile(suite.path, []byte(data), 0o644)
+
+	got, err := replace.Load(suite.appFs, suite.path)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), map[string]string{
+		"https://example.com/user/repo.git":  "/repo",
+		"https://example.com/user/other.git": "/src/other",
+	}, got)
+}
+
+func (suite *ReplacePublicTestSuite) TestLoadReturnsErrorOnMalformedLine() {
+	data := "https://example.com/user/repo.git => ../repo\nhttps://example.com/user/other.git\n"
+	_ = suite.appFs.WriteFile(suite.path, []byte(data), 0o644)
+
+	_, err := replace.Load(suite.appFs, suite.path)
+	assert.EqualError(suite.T(), err, "/project/gilt.replace:2: expected <git url> => <path>")
+}
+
+func (suite *ReplacePublicTestSuite) TestLoadReturnsErrorWhenMissing() {
+	_, err := replace.Load(suite.appFs, suite.path)
+	assert.Error(suite.T(), err)
+}
+
+// In order for `go test` to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run.
+func TestReplacePublicTestSuite(t *testing.T) {
+	suite.Run(t, new(ReplacePublicTestSuite))
+}
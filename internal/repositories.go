@@ -20,7 +20,18 @@
 
 package internal
 
+import (
+	"context"
+
+	"github.com/retr0h/gilt/v2/pkg/report"
+)
+
 // RepositoriesManager manager responsible for Repositories operations.
 type RepositoriesManager interface {
-	Overlay() error
+	Overlay(ctx context.Context) ([]report.Overlay, error)
+	Clean() ([]report.Clean, error)
+	Plan(ctx context.Context) ([]report.Plan, error)
+	Status(ctx context.Context) ([]report.Status, error)
+	Outdated(ctx context.Context) ([]report.Outdated, error)
+	Update(ctx context.Context, constraint string, repos []string) ([]report.Update, error)
 }
@@ -22,16 +22,36 @@
 package repositories
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"log/slog"
+	"os/exec"
+	"path"
 	"runtime"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/avfs/avfs"
 
 	"github.com/retr0h/gilt/v2/internal"
+	"github.com/retr0h/gilt/v2/internal/giltfile"
+	"github.com/retr0h/gilt/v2/internal/glob"
+	"github.com/retr0h/gilt/v2/internal/journal"
+	"github.com/retr0h/gilt/v2/internal/label"
+	"github.com/retr0h/gilt/v2/internal/lockfile"
+	"github.com/retr0h/gilt/v2/internal/manifest"
 	intPath "github.com/retr0h/gilt/v2/internal/path"
+	"github.com/retr0h/gilt/v2/internal/replace"
+	"github.com/retr0h/gilt/v2/internal/version"
 	"github.com/retr0h/gilt/v2/pkg/config"
+	"github.com/retr0h/gilt/v2/pkg/event"
+	"github.com/retr0h/gilt/v2/pkg/report"
 )
 
 // This should be a nice upper bound for parallel fetches
@@ -43,6 +63,7 @@ func New(
 	c config.Repositories,
 	repoManager internal.RepositoryManager,
 	execManager internal.ExecManager,
+	observer event.Observer,
 	logger *slog.Logger,
 ) *Repositories {
 	return &Repositories{
@@ -50,68 +71,1243 @@ func New(
 		config:      c,
 		repoManager: repoManager,
 		execManager: execManager,
+		observer:    observer,
 		logger:      logger,
 		cloneCache:  make(map[string]string),
+		cloneStates: make(map[string]string),
 	}
 }
 
+// notify send `e`, which happened to Repository `c`, to the observer.
+func (r *Repositories) notify(c config.Repository, e event.Event) {
+	if r.observer == nil {
+		return
+	}
+	e.Time = time.Now()
+	e.Name = c.Name
+	e.Git = c.Git
+	e.Archive = c.Archive
+	e.Path = c.Path
+	r.observer.Observe(e)
+}
+
+// worktree extract the Repository's worktree from the clone in `cloneDir`
+// into `dir`, and notify the observer once it is.
+func (r *Repositories) worktree(
+	ctx context.Context,
+	c config.Repository,
+	cloneDir string,
+	dir string,
+) error {
+	start := time.Now()
+	if err := r.repoManager.Worktree(ctx, c, cloneDir, dir); err != nil {
+		return err
+	}
+	r.notify(c, event.Event{
+		Type:     event.WorktreeExtracted,
+		Dir:      dir,
+		Duration: time.Since(start),
+	})
+	return nil
+}
+
 func (r *Repositories) getGiltDir() (string, error) {
 	giltDir, err := intPath.ExpandUser(r.config.GiltDir)
 	return giltDir, err
 }
 
-// getCacheDir create the cacheDir if it doesn't exist.
-func (r *Repositories) getCacheDir() (string, error) {
-	giltDir, err := r.getGiltDir()
+// getCacheDir create the cacheDir if it doesn't exist.
+func (r *Repositories) getCacheDir() (string, error) {
+	giltDir, err := r.getGiltDir()
+	if err != nil {
+		return "", err
+	}
+
+	cacheDir := r.appFs.Join(giltDir, "cache")
+	if err := r.appFs.MkdirAll(cacheDir, 0o700); err != nil {
+		if i, e := r.appFs.Stat(cacheDir); e == nil && i.IsDir() {
+			return cacheDir, nil
+		}
+		return "", err
+	}
+	return cacheDir, nil
+}
+
+// getArchiveDir returns the dir holding unpacked archives.  It is only
+// created once an archive is fetched.
+func (r *Repositories) getArchiveDir() (string, error) {
+	giltDir, err := r.getGiltDir()
+	if err != nil {
+		return "", err
+	}
+
+	return r.appFs.Join(giltDir, "archives"), nil
+}
+
+// source the url a Repository is vendored from; its Git url, its archive
+// url, or its local path.
+func source(c config.Repository) string {
+	switch {
+	case c.Archive != "":
+		return c.Archive
+	case c.Path != "":
+		return c.Path
+	}
+
+	return c.Git
+}
+
+// cacheKey identifies the clone, unpacked archive, or local path a Repository
+// uses.  Archives are unpacked by checksum, so each checksum is fetched, and
+// verified, in its own right.
+func cacheKey(c config.Repository) string {
+	switch {
+	case c.Archive != "":
+		return c.SHA256
+	case c.Path != "":
+		return "path:" + c.Path
+	}
+
+	return c.Git
+}
+
+// applyReplace point each Repository whose Git url is named in the replace
+// file at its local path instead, and returns how many were replaced.
+func (r *Repositories) applyReplace() (int, error) {
+	if r.config.Replace == "" {
+		return 0, nil
+	}
+
+	replacements, err := replace.Load(r.appFs, r.config.Replace)
+	if err != nil {
+		return 0, err
+	}
+
+	replaced := 0
+	for i, c := range r.config.Repositories {
+		dir, ok := replacements[c.Git]
+		if !ok || c.Git == "" {
+			continue
+		}
+		r.logger.Info(
+			"replacing repository",
+			slog.String("repository", c.Git),
+			slog.String("path", dir),
+		)
+		r.config.Repositories[i].Git = ""
+		r.config.Repositories[i].Version = ""
+		r.config.Repositories[i].Path = dir
+		replaced++
+	}
+
+	return replaced, nil
+}
+
+// Overlay clone and extract the Repository items.  Every path it changes is
+// set aside first, and put back should any Repository, or command, fail, so
+// the project is never left half overlaid.  What an overlay killed midway set
+// aside is put back before starting.
+func (r *Repositories) Overlay(ctx context.Context) ([]report.Overlay, error) {
+	j := journal.New(r.appFs, r.appFs.Dir(manifest.Path(r.config.GiltFile)), r.logger)
+	if err := j.Recover(); err != nil {
+		return nil, err
+	}
+	results, err := r.overlay(ctx, j)
+	if err != nil {
+		r.logger.Error("overlay failed, rolling back", slog.String("err", err.Error()))
+		if rollbackErr := j.Rollback(); rollbackErr != nil {
+			return results, fmt.Errorf("%s; unable to roll back: %s", err, rollbackErr)
+		}
+		return results, err
+	}
+
+	return results, j.Commit()
+}
+
+// overlay clone and extract the Repository items, recording every path it
+// changes in `j`, and report what it did to each.  Once versions are
+// resolved, the report is returned even when the overlay fails.
+func (r *Repositories) overlay(ctx context.Context, j *journal.Journal) ([]report.Overlay, error) {
+	replaced, err := r.applyReplace()
+	if err != nil {
+		return nil, err
+	}
+	if replaced > 0 && r.config.Locked {
+		return nil, fmt.Errorf(
+			"unable to overlay locked with %s replacing repositories",
+			r.config.Replace,
+		)
+	}
+
+	selected, err := r.selectOverlay()
+	if err != nil {
+		return nil, err
+	}
+	// The files of the Repository entries left out would look orphaned
+	partial := len(selected) < len(r.config.Repositories)
+	if partial && r.config.Prune {
+		return nil, errors.New("unable to prune when overlaying only some repositories")
+	}
+
+	if err := r.populateCloneCache(ctx, r.config.Parallel, selected); err != nil {
+		return nil, err
+	}
+
+	lockPath := lockfile.Path(r.config.GiltFile)
+	lock, err := lockfile.Load(r.appFs, lockPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve every version up front, so a locked overlay refuses to proceed
+	// before any destination is touched
+	versions, err := r.resolveVersions(ctx, lock, selected)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]report.Overlay, 0, len(selected))
+	index := make(map[int]int, len(selected))
+	for i, c := range r.config.Repositories {
+		if !selected[i] {
+			continue
+		}
+		index[i] = len(results)
+		results = append(results, report.Overlay{
+			Name:    c.Name,
+			Git:     c.Git,
+			Archive: c.Archive,
+			Path:    c.Path,
+			Version: c.Version,
+			Tag:     versions[i].tag,
+			Commit:  versions[i].commit,
+			Clone:   r.cloneStates[cacheKey(c)],
+			// Empty rather than nil, so JSON holds [] when there is nothing
+			Files:    []string{},
+			Replaced: []string{},
+			Commands: []report.Command{},
+			Skipped:  []string{},
+		})
+	}
+
+	// Pre-commands run before any destination is touched, so their failure
+	// leaves nothing to roll back
+	for i, c := range r.config.Repositories {
+		if !selected[i] {
+			continue
+		}
+		result := &results[index[i]]
+		if r.config.SkipCommands {
+			result.Skipped = skipped(result.Skipped, report.StagePre, c.PreCommands)
+			continue
+		}
+		pinned := c
+		pinned.Version = versions[i].commit
+		runs, err := r.runCommands(ctx, pinned, c.Version, report.StagePre, c.PreCommands, "")
+		result.Commands = append(result.Commands, runs...)
+		if err != nil {
+			return results, err
+		}
+	}
+
+	manifestPath := manifest.Path(r.config.GiltFile)
+	previous, err := manifest.Load(r.appFs, manifestPath)
+	if err != nil {
+		return results, err
+	}
+	owned := previous.Dirs()
+
+	locked := &lockfile.Lockfile{
+		Repositories: make([]lockfile.Repository, 0, len(r.config.Repositories)),
+	}
+	installed := &manifest.Manifest{
+		Repositories: make([]manifest.Repository, 0, len(r.config.Repositories)),
+	}
+	unlocked := 0
+	for i, c := range r.config.Repositories {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		if !selected[i] {
+			// An entry left out stays locked as it was, when it was locked
+			entry, ok := lock.Locked(i, source(c), c.Version)
+			if !ok {
+				unlocked++
+				continue
+			}
+			locked.Repositories = append(locked.Repositories, entry)
+			continue
+		}
+		targetDir := r.cloneCache[cacheKey(c)]
+		version := c.Version
+		// Pin the worktree to the resolved commit
+		c.Version = versions[i].commit
+		result := &results[index[i]]
+
+		var hash string
+		var entry manifest.Repository
+		if c.DstDir != "" {
+			// Easy mode: create a full worktree, directly in DstDir
+			hash, entry, err = r.overlayTree(ctx, j, c, result, targetDir, owned)
+		} else {
+			// Hard mode: copy subtrees of the worktree from Repository.Src to
+			// Repository.DstDir (or Repository.DstFile)
+			hash, entry, err = r.overlaySubtrees(ctx, j, c, result, targetDir, owned)
+		}
+		if err != nil {
+			return results, err
+		}
+		result.Files = append(result.Files, entry.Files...)
+
+		if r.config.Locked {
+			if err := lock.VerifyHash(i, hash); err != nil {
+				return results, err
+			}
+		}
+		installed.Repositories = append(installed.Repositories, entry)
+		locked.Repositories = append(locked.Repositories, lockfile.Repository{
+			Name:    c.Name,
+			Git:     c.Git,
+			Archive: c.Archive,
+			Path:    c.Path,
+			Version: version,
+			Tag:     versions[i].tag,
+			Commit:  versions[i].commit,
+			Hash:    hash,
+		})
+
+		// run post commands
+		if r.config.SkipCommands {
+			r.logger.Info("skipping running post-commands")
+			result.Skipped = skipped(result.Skipped, report.StagePost, c.Commands)
+			continue
+		}
+		runs, err := r.runCommands(ctx, c, version, report.StagePost, c.Commands, "")
+		result.Commands = append(result.Commands, runs...)
+		if err != nil {
+			return results, err
+		}
+	}
+
+	// What sync destinations held from earlier overlays, and this one no
+	// longer produced, is deleted
+	for i, c := range r.config.Repositories {
+		if !selected[i] {
+			continue
+		}
+		stale := r.stale(c, previous, installed)
+		if len(stale.Files)+len(stale.Dirs) == 0 {
+			continue
+		}
+		r.logger.Info("syncing", slog.String("repository", source(c)))
+		if _, err := r.remove(j, []manifest.Repository{stale}); err != nil {
+			return results, err
+		}
+	}
+
+	// What earlier overlays installed, and this one did not replace, is either
+	// pruned, or kept track of so it can still be cleaned up; including what
+	// the entries left out installed
+	orphans := installed.Orphans(r.appFs, previous)
+	if r.config.Prune {
+		r.logger.Info("pruning orphaned files")
+		if _, err := r.remove(j, orphans); err != nil {
+			return results, err
+		}
+		// Directories which still hold other files are left in place
+		orphans = installed.Orphans(r.appFs, previous)
+	}
+	installed.Repositories = append(installed.Repositories, orphans...)
+	r.logger.Info("writing manifest", slog.String("manifest", manifestPath))
+	if err := j.Replace(manifestPath); err != nil {
+		return results, err
+	}
+	if err := installed.Save(r.appFs, manifestPath); err != nil {
+		return results, err
+	}
+
+	if r.config.Locked {
+		return results, nil
+	}
+	// Local replacements are for development only, and must not leak into
+	// the lock file
+	if replaced > 0 {
+		r.logger.Info("not writing lock file", slog.String("replace", r.config.Replace))
+		return results, nil
+	}
+	// The lock file is positional, so cannot leave out an entry
+	if unlocked > 0 {
+		r.logger.Info(
+			"not writing lock file",
+			slog.String("reason", "repositories left out are not locked"),
+		)
+		return results, nil
+	}
+
+	r.logger.Info("writing lock file", slog.String("lockFile", lockPath))
+	if err := j.Replace(lockPath); err != nil {
+		return results, err
+	}
+	return results, locked.Save(r.appFs, lockPath)
+}
+
+// Plan resolve each Repository's version, and expand its sources against a
+// temporary worktree, to report what Overlay would delete, create, overwrite,
+// and run; including what it would prune.  No destination is touched.
+func (r *Repositories) Plan(ctx context.Context) ([]report.Plan, error) {
+	previous, err := manifest.Load(r.appFs, manifest.Path(r.config.GiltFile))
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := r.selectOverlay()
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) < len(r.config.Repositories) && r.config.Prune {
+		return nil, errors.New("unable to prune when overlaying only some repositories")
+	}
+
+	plans := make([]report.Plan, 0, len(selected))
+	planned := make([]config.Repository, 0, len(selected))
+	err = r.eachTargets(
+		ctx,
+		selected,
+		func(c config.Repository, v resolved, targets []internal.Target) error {
+			plan := newPlan(report.Plan{
+				Name:    c.Name,
+				Git:     c.Git,
+				Archive: c.Archive,
+				Path:    c.Path,
+				Version: c.Version,
+				Tag:     v.tag,
+				Commit:  v.commit,
+			})
+			if err := r.planTargets(&plan, targets); err != nil {
+				return err
+			}
+
+			for _, command := range c.BuildCommands {
+				plan.BuildCommands = append(plan.BuildCommands, describeCommand(command))
+			}
+			if !r.config.SkipCommands {
+				for _, command := range c.PreCommands {
+					plan.PreCommands = append(plan.PreCommands, describeCommand(command))
+				}
+				for _, command := range c.Commands {
+					plan.Commands = append(plan.Commands, describeCommand(command))
+				}
+			}
+			plans = append(plans, plan)
+			planned = append(planned, c)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Whatever any Repository still overlays is not stale
+	overlaid := &manifest.Manifest{Repositories: make([]manifest.Repository, 0, len(plans))}
+	for _, plan := range plans {
+		overlaid.Repositories = append(overlaid.Repositories, manifest.Repository{
+			Files: slices.Concat(plan.Create, plan.Overwrite),
+		})
+	}
+	for i, c := range planned {
+		stale := r.stale(c, previous, overlaid)
+		plans[i].Delete = append(plans[i].Delete, stale.Files...)
+	}
+
+	if r.config.Prune {
+		return r.planPrune(plans, previous)
+	}
+	return plans, nil
+}
+
+// planPrune add to `plans` what pruning would delete once they are overlaid;
+// the paths `previous` records, which no plan overlays, and which are not
+// already deleted.  Each path is listed with the Repository which installed
+// it, or in a plan of its own when the Giltfile no longer holds it.
+func (r *Repositories) planPrune(
+	plans []report.Plan,
+	previous *manifest.Manifest,
+) ([]report.Plan, error) {
+	// An overlay records the directories it writes into, as well as the files
+	overlaid := &manifest.Manifest{Repositories: make([]manifest.Repository, 0, len(plans))}
+	var deleted []string
+	for _, plan := range plans {
+		files := slices.Concat(plan.Create, plan.Overwrite)
+		dirs := make(map[string]bool)
+		for _, path := range slices.Concat(files, plan.Delete) {
+			for dir := r.appFs.Dir(path); dir != r.appFs.Dir(dir); dir = r.appFs.Dir(dir) {
+				dirs[dir] = true
+			}
+		}
+		overlaid.Repositories = append(overlaid.Repositories, manifest.Repository{
+			Files: files,
+			Dirs:  slices.Concat(sortedKeys(dirs), plan.Delete),
+		})
+		deleted = append(deleted, plan.Delete...)
+	}
+	gone := func(path string) bool {
+		return slices.ContainsFunc(deleted, func(dir string) bool { return isWithin(dir, path) })
+	}
+
+	orphans := overlaid.Orphans(r.appFs, previous)
+	doomed := make(map[string]bool)
+	var dirs []string
+	for _, orphan := range orphans {
+		for _, path := range orphan.Files {
+			if !gone(path) {
+				doomed[path] = true
+			}
+		}
+		for _, path := range orphan.Dirs {
+			if !gone(path) {
+				dirs = append(dirs, path)
+			}
+		}
+	}
+	// Directories which would still hold other files are left in place, as
+	// Overlay leaves them; the deepest are emptied first
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, dir := range dirs {
+		entries, err := r.appFs.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		empty := true
+		for _, entry := range entries {
+			if !doomed[r.appFs.Join(dir, entry.Name())] {
+				empty = false
+				break
+			}
+		}
+		doomed[dir] = empty
+	}
+
+	for _, orphan := range orphans {
+		var paths []string
+		for _, path := range slices.Concat(orphan.Files, orphan.Dirs) {
+			if doomed[path] {
+				paths = append(paths, path)
+			}
+		}
+		if len(paths) == 0 {
+			continue
+		}
+		i := slices.IndexFunc(plans, func(plan report.Plan) bool {
+			return plan.Name == orphan.Name && plan.Git == orphan.Git &&
+				plan.Archive == orphan.Archive && plan.Path == orphan.Path
+		})
+		if i < 0 {
+			plans = append(plans, newPlan(report.Plan{
+				Name:    orphan.Name,
+				Git:     orphan.Git,
+				Archive: orphan.Archive,
+				Path:    orphan.Path,
+			}))
+			i = len(plans) - 1
+		}
+		plans[i].Delete = append(plans[i].Delete, paths...)
+	}
+
+	return plans, nil
+}
+
+// newPlan returns `plan` with every list empty rather than nil, so JSON holds
+// [] when there is nothing to list.
+func newPlan(plan report.Plan) report.Plan {
+	plan.Delete = []string{}
+	plan.Create = []string{}
+	plan.Overwrite = []string{}
+	plan.PreCommands = []string{}
+	plan.BuildCommands = []string{}
+	plan.Commands = []string{}
+
+	return plan
+}
+
+// Status compare each Repository's destinations with what its pinned version
+// would overlay, and report the files which were modified, deleted, or added
+// since.  No destination is touched.
+func (r *Repositories) Status(ctx context.Context) ([]report.Status, error) {
+	results := make([]report.Status, 0, len(r.config.Repositories))
+	err := r.eachTargets(
+		ctx,
+		r.allRepositories(),
+		func(c config.Repository, v resolved, targets []internal.Target) error {
+			status := report.Status{
+				Name:     c.Name,
+				Git:      c.Git,
+				Archive:  c.Archive,
+				Path:     c.Path,
+				Version:  c.Version,
+				Tag:      v.tag,
+				Commit:   v.commit,
+				Modified: []string{},
+				Deleted:  []string{},
+				Added:    []string{},
+			}
+			if err := r.statusTargets(&status, targets); err != nil {
+				return err
+			}
+			results = append(results, status)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// eachTargets resolve each `selected` Repository's version, extract it into a
+// temporary worktree, run its build commands there, and call `fn` with the
+// Repository, its resolved version, and the targets it overlays from that
+// worktree.  The worktree is removed once `fn` returns.
+func (r *Repositories) eachTargets(
+	ctx context.Context,
+	selected map[int]bool,
+	fn func(c config.Repository, v resolved, targets []internal.Target) error,
+) error {
+	if _, err := r.applyReplace(); err != nil {
+		return err
+	}
+	if err := r.populateCloneCache(ctx, r.config.Parallel, selected); err != nil {
+		return err
+	}
+
+	lock, err := lockfile.Load(r.appFs, lockfile.Path(r.config.GiltFile))
+	if err != nil {
+		return err
+	}
+	versions, err := r.resolveVersions(ctx, lock, selected)
+	if err != nil {
+		return err
+	}
+
+	giltDir, err := r.getGiltDir()
+	if err != nil {
+		return err
+	}
+
+	for i, c := range r.config.Repositories {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !selected[i] {
+			continue
+		}
+		targetDir := r.cloneCache[cacheKey(c)]
+		pinned := c
+		pinned.Version = versions[i].commit
+
+		err := r.execManager.RunInTempDir(giltDir, "tmp", func(tmpDir string) error {
+			tmpClone := r.appFs.Join(tmpDir, r.appFs.Base(targetDir))
+			if err := r.worktree(ctx, pinned, targetDir, tmpClone); err != nil {
+				return err
+			}
+			_, err := r.runCommands(
+				ctx,
+				pinned,
+				c.Version,
+				report.StageBuild,
+				c.BuildCommands,
+				tmpClone,
+			)
+			if err != nil {
+				return err
+			}
+			targets, err := r.repoManager.Targets(pinned, tmpClone)
+			if err != nil {
+				return err
+			}
+			return fn(c, versions[i], targets)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// planTargets record in `plan` the paths overlaying `targets` would delete,
+// create, and overwrite.
+func (r *Repositories) planTargets(plan *report.Plan, targets []internal.Target) error {
+	deleted := make(map[string]bool)
+	addFile := func(dst string) {
+		// A file under a directory which is deleted first is created afresh
+		exists := !slices.ContainsFunc(plan.Delete, func(dir string) bool {
+			return isWithin(dir, dst)
+		})
+		if _, err := r.appFs.Stat(dst); err == nil && exists {
+			plan.Overwrite = append(plan.Overwrite, dst)
+		} else {
+			plan.Create = append(plan.Create, dst)
+		}
+	}
+
+	for _, t := range targets {
+		if !t.Dir {
+			addFile(t.Dst)
+			continue
+		}
+
+		if info, err := r.appFs.Stat(t.Dst); err == nil && info.IsDir() && !deleted[t.Dst] &&
+			!t.Merges() {
+			deleted[t.Dst] = true
+			plan.Delete = append(plan.Delete, t.Dst)
+		}
+		err := r.appFs.WalkDir(t.Src, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := r.appFs.Rel(t.Src, path)
+			if err != nil {
+				return err
+			}
+			if !glob.Selected(t.Include, t.Exclude, r.appFs.ToSlash(rel)) {
+				return nil
+			}
+			addFile(r.appFs.Join(t.Dst, rel))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// statusTargets record in `status` the files under `targets` which differ
+// from what overlaying them would produce.
+func (r *Repositories) statusTargets(status *report.Status, targets []internal.Target) error {
+	// Map each destination file to the worktree file it would be copied from.
+	// A directory replaces its destination wholesale, including what earlier
+	// targets put there, unless it is merged; only then may it hold files
+	// which were not overlaid.
+	expected := make(map[string]string)
+	var dirs []string
+	for _, t := range targets {
+		if !t.Dir {
+			expected[t.Dst] = t.Src
+			continue
+		}
+
+		if !t.Merges() {
+			for dst := range expected {
+				if isWithin(t.Dst, dst) {
+					delete(expected, dst)
+				}
+			}
+			dirs = append(dirs, t.Dst)
+		}
+		err := r.appFs.WalkDir(t.Src, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := r.appFs.Rel(t.Src, path)
+			if err != nil {
+				return err
+			}
+			if !glob.Selected(t.Include, t.Exclude, r.appFs.ToSlash(rel)) {
+				return nil
+			}
+			expected[r.appFs.Join(t.Dst, rel)] = path
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for dst, src := range expected {
+		want, err := r.appFs.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		got, err := r.appFs.ReadFile(dst)
+		switch {
+		case err != nil:
+			status.Deleted = append(status.Deleted, dst)
+		case !bytes.Equal(want, got):
+			status.Modified = append(status.Modified, dst)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		err := r.appFs.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			if err != nil || d.IsDir() {
+				return err
+			}
+			if _, ok := expected[path]; !ok {
+				status.Added = append(status.Added, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	sort.Strings(status.Modified)
+	sort.Strings(status.Deleted)
+	sort.Strings(status.Added)
+
+	return nil
+}
+
+// syncDirs the destination directories of `c` in sync mode.
+func syncDirs(c config.Repository) []string {
+	var dirs []string
+	if c.DstDir != "" && c.Mode == config.ModeSync {
+		dirs = append(dirs, c.DstDir)
+	}
+	for _, source := range c.Sources {
+		if source.DstDir != "" && source.Mode == config.ModeSync {
+			dirs = append(dirs, source.DstDir)
+		}
+	}
+
+	return dirs
+}
+
+// stale returns the paths `previous` records for the Repository `c` below its
+// sync destinations, which still exist, and which `installed` does not record.
+// Files an overlay did not write are never among them.
+func (r *Repositories) stale(
+	c config.Repository,
+	previous *manifest.Manifest,
+	installed *manifest.Manifest,
+) manifest.Repository {
+	stale := manifest.Repository{Name: c.Name, Git: c.Git, Archive: c.Archive, Path: c.Path}
+	dirs := syncDirs(c)
+	if len(dirs) == 0 {
+		return stale
+	}
+
+	files := make(map[string]bool)
+	kept := installed.Dirs()
+	for _, entry := range installed.Repositories {
+		for _, path := range entry.Files {
+			files[path] = true
+		}
+	}
+	within := func(path string) bool {
+		for _, dir := range dirs {
+			if isWithin(dir, path) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, entry := range previous.Repositories {
+		if entry.Name != c.Name || entry.Git != c.Git || entry.Archive != c.Archive ||
+			entry.Path != c.Path {
+			continue
+		}
+		for _, path := range entry.Files {
+			if _, err := r.appFs.Stat(path); err != nil {
+				continue
+			}
+			if within(path) && !files[path] {
+				stale.Files = append(stale.Files, path)
+			}
+		}
+		for _, path := range entry.Dirs {
+			if within(path) && !kept[path] {
+				stale.Dirs = append(stale.Dirs, path)
+			}
+		}
+	}
+
+	return stale
+}
+
+// isWithin reports whether `path` is `dir`, or is below it.
+func isWithin(dir, path string) bool {
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, "/")+"/")
+}
+
+// Clean remove every file and directory recorded in the manifest, and then
+// the manifest itself, and report what was removed of each Repository.
+// Nothing which is not recorded is removed; directories which still hold
+// other files are left in place.
+func (r *Repositories) Clean() ([]report.Clean, error) {
+	manifestPath := manifest.Path(r.config.GiltFile)
+	j := journal.New(r.appFs, r.appFs.Dir(manifestPath), r.logger)
+	// An overlay killed midway may have set the manifest aside
+	if err := j.Recover(); err != nil {
+		return nil, err
+	}
+	installed, err := manifest.Load(r.appFs, manifestPath)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	cacheDir := r.appFs.Join(giltDir, "cache")
-	if err := r.appFs.MkdirAll(cacheDir, 0o700); err != nil {
-		if i, e := r.appFs.Stat(cacheDir); e == nil && i.IsDir() {
-			return cacheDir, nil
+	results, err := r.remove(j, installed.Repositories)
+	if err == nil {
+		err = j.Replace(manifestPath)
+	}
+	if err != nil {
+		if rollbackErr := j.Rollback(); rollbackErr != nil {
+			return nil, fmt.Errorf("%s; unable to roll back: %s", err, rollbackErr)
 		}
-		return "", err
+		return nil, err
 	}
-	return cacheDir, nil
+	if err := j.Commit(); err != nil {
+		return nil, err
+	}
+	// Only removed once empty; other Giltfiles may keep theirs alongside
+	_ = r.appFs.Remove(r.appFs.Dir(manifestPath))
+	_ = r.appFs.Remove(r.appFs.Dir(r.appFs.Dir(manifestPath)))
+
+	return results, nil
 }
 
-// Overlay clone and extract the Repository items.
-func (r *Repositories) Overlay() error {
-	if err := r.populateCloneCache(r.config.Parallel); err != nil {
-		return err
+// remove delete the files recorded in `entries`, and then the recorded
+// directories which are left empty, setting each aside in `j`, and report
+// what was removed of each entry.
+func (r *Repositories) remove(
+	j *journal.Journal,
+	entries []manifest.Repository,
+) ([]report.Clean, error) {
+	results := make([]report.Clean, 0, len(entries))
+	var dirs []string
+	owners := make(map[string]int)
+	for i, entry := range entries {
+		results = append(results, report.Clean{
+			Name:    entry.Name,
+			Git:     entry.Git,
+			Archive: entry.Archive,
+			Path:    entry.Path,
+			Removed: []string{},
+			Left:    []string{},
+		})
+		for _, path := range entry.Files {
+			_, err := r.appFs.Lstat(path)
+			switch {
+			case errors.Is(err, fs.ErrNotExist):
+				continue
+			case err != nil:
+				return nil, err
+			}
+			r.logger.Info("removing file", slog.String("path", path))
+			if err := j.Replace(path); err != nil {
+				return nil, err
+			}
+			results[i].Removed = append(results[i].Removed, path)
+		}
+		dirs = append(dirs, entry.Dirs...)
+		for _, dir := range entry.Dirs {
+			owners[dir] = i
+		}
+	}
+
+	// Remove the deepest directories first, so their parents may be empty
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, dir := range dirs {
+		result := &results[owners[dir]]
+		entries, err := r.appFs.ReadDir(dir)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return nil, err
+		case len(entries) > 0:
+			r.logger.Info(
+				"leaving dir",
+				slog.String("path", dir),
+				slog.String("reason", "directory not empty"),
+			)
+			result.Left = append(result.Left, dir)
+		default:
+			if err := j.Replace(dir); err != nil {
+				return nil, err
+			}
+			r.logger.Info("removed dir", slog.String("path", dir))
+			result.Removed = append(result.Removed, dir)
+			r.notifyRemoved(config.Repository{
+				Name:    result.Name,
+				Git:     result.Git,
+				Archive: result.Archive,
+				Path:    result.Path,
+			}, []string{dir})
+		}
+	}
+
+	return results, nil
+}
+
+// resolveVersions resolve each `selected` Repository's version to a commit
+// SHA; the version of any other is left empty.  When running locked, every
+// commit must match the one recorded in `lock`, which holds no other entry.
+func (r *Repositories) resolveVersions(
+	ctx context.Context,
+	lock *lockfile.Lockfile,
+	selected map[int]bool,
+) ([]resolved, error) {
+	versions := make([]resolved, 0, len(r.config.Repositories))
+	for i, c := range r.config.Repositories {
+		if !selected[i] {
+			versions = append(versions, resolved{})
+			continue
+		}
+		commit, tag, err := r.repoManager.Resolve(ctx, c, r.cloneCache[cacheKey(c)])
+		if err != nil {
+			return nil, err
+		}
+		if r.config.Locked {
+			if err := lock.Verify(i, source(c), c.Version, commit); err != nil {
+				return nil, err
+			}
+		}
+		versions = append(versions, resolved{commit: commit, tag: tag})
+	}
+	// Entries left from repositories since removed mean it is out of date too
+	if r.config.Locked {
+		if err := lock.VerifyCount(len(r.config.Repositories)); err != nil {
+			return nil, err
+		}
+	}
+
+	return versions, nil
+}
+
+// Outdated compare each Repository's version with the tags available in its
+// clone.
+func (r *Repositories) Outdated(ctx context.Context) ([]report.Outdated, error) {
+	if err := r.populateCloneCache(ctx, r.config.Parallel, r.allRepositories()); err != nil {
+		return nil, err
 	}
 
+	results := make([]report.Outdated, 0, len(r.config.Repositories))
 	for _, c := range r.config.Repositories {
-		targetDir := r.cloneCache[c.Git]
+		// Archives and local paths have no tags to compare
+		if c.Git == "" {
+			continue
+		}
+		tags, err := r.repoManager.Tags(ctx, c, r.cloneCache[cacheKey(c)])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, report.Outdated{
+			Name:          c.Name,
+			Git:           c.Git,
+			Version:       c.Version,
+			Latest:        version.Latest(tags),
+			LatestInMajor: version.LatestInMajor(tags, c.Version),
+		})
+	}
 
-		// Easy mode: create a full worktree, directly in DstDir
-		if err := r.overlayTree(c, targetDir); err != nil {
-			return err
+	return results, nil
+}
+
+// Update bump the version of each selected Repository to its newest tag, or
+// to the newest tag satisfying `constraint`, and rewrite the Giltfile in
+// place.  Every Repository is selected when `repos` is empty.  Versions which
+// are constraints, or not semantic versions, are left alone.
+func (r *Repositories) Update(
+	ctx context.Context,
+	constraint string, repos []string,
+) ([]report.Update, error) {
+	if constraint != "" {
+		if err := version.Validate(constraint); err != nil {
+			return nil, fmt.Errorf("invalid constraint %s: %s", constraint, err)
 		}
+	}
 
-		// Hard mode: copy subtrees of the worktree from Repository.Src to
-		// Repository.DstDir (or Repository.DstFile)
-		if err := r.overlaySubtrees(c, targetDir); err != nil {
-			return err
+	selected, err := r.selectRepositories(repos)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.populateCloneCache(ctx, r.config.Parallel, selected); err != nil {
+		return nil, err
+	}
+
+	versions := make(map[int]string, len(selected))
+	results := make([]report.Update, 0, len(selected))
+	for i, c := range r.config.Repositories {
+		if !selected[i] || c.Git == "" {
+			continue
+		}
+		if version.IsConstraint(c.Version) {
+			r.logger.Info(
+				"skipping version constraint",
+				slog.String("repository", c.Git),
+				slog.String("version", c.Version),
+			)
+			continue
 		}
 
-		// run post commands
-		if r.config.SkipCommands {
-			r.logger.Info("skipping running post-commands")
+		tags, err := r.repoManager.Tags(ctx, c, r.cloneCache[cacheKey(c)])
+		if err != nil {
+			return nil, err
+		}
+		tag, err := version.Upgrade(tags, c.Version, constraint)
+		if err != nil {
+			r.logger.Info(
+				"skipping version",
+				slog.String("repository", c.Git),
+				slog.String("reason", err.Error()),
+			)
 			continue
 		}
-		if err := r.runCommands(c); err != nil {
-			return err
+		if tag == "" {
+			continue
 		}
+
+		versions[i] = tag
+		results = append(results, report.Update{
+			Name: c.Name,
+			Git:  c.Git,
+			From: c.Version,
+			To:   tag,
+		})
 	}
 
-	return nil
+	if len(versions) == 0 {
+		return results, nil
+	}
+
+	if err := r.writeVersions(versions); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// selectOverlay map the Repository entries to overlay to their index in the
+// Giltfile; those named by Config.Only, or every entry when it names none,
+// whose labels match Config.Label, less those named by Config.Skip.
+func (r *Repositories) selectOverlay() (map[int]bool, error) {
+	selected, err := r.selectRepositories(r.config.Only)
+	if err != nil {
+		return nil, err
+	}
+	if r.config.Label != "" {
+		expr, err := label.Parse(r.config.Label)
+		if err != nil {
+			return nil, err
+		}
+		for i := range selected {
+			if !expr.Match(r.config.Repositories[i].Labels) {
+				delete(selected, i)
+			}
+		}
+		if len(selected) == 0 {
+			return nil, fmt.Errorf(
+				"no repositories match label %s in %s",
+				r.config.Label,
+				r.config.GiltFile,
+			)
+		}
+	}
+	if len(r.config.Skip) == 0 {
+		return selected, nil
+	}
+
+	skipped, err := r.selectRepositories(r.config.Skip)
+	if err != nil {
+		return nil, err
+	}
+	for i := range skipped {
+		delete(selected, i)
+	}
+
+	return selected, nil
+}
+
+// selectRepositories map the `repos` named on the command line to their
+// index in the Giltfile; every entry when `repos` is empty.  A Repository is
+// named by its name or, when no entry has that name, by its Git or archive
+// url, or local path, or by the base name of that url without the ".git"
+// suffix.
+func (r *Repositories) selectRepositories(repos []string) (map[int]bool, error) {
+	if len(repos) == 0 {
+		return r.allRepositories(), nil
+	}
+
+	selected := make(map[int]bool, len(r.config.Repositories))
+	for _, name := range repos {
+		// Names are unique, and entries vendored from the same url are told
+		// apart by them
+		found := false
+		for i, c := range r.config.Repositories {
+			if name == c.Name {
+				selected[i] = true
+				found = true
+			}
+		}
+		if found {
+			continue
+		}
+		for i, c := range r.config.Repositories {
+			base := strings.TrimSuffix(path.Base(source(c)), ".git")
+			if name == source(c) || name == base {
+				selected[i] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("repository %s not found in %s", name, r.config.GiltFile)
+		}
+	}
+
+	return selected, nil
+}
+
+// allRepositories map every Repository entry to its index in the Giltfile.
+func (r *Repositories) allRepositories() map[int]bool {
+	selected := make(map[int]bool, len(r.config.Repositories))
+	for i := range r.config.Repositories {
+		selected[i] = true
+	}
+
+	return selected
+}
+
+// writeVersions rewrite the version of each Repository in the Giltfile.
+func (r *Repositories) writeVersions(versions map[int]string) error {
+	info, err := r.appFs.Stat(r.config.GiltFile)
+	if err != nil {
+		return err
+	}
+	data, err := r.appFs.ReadFile(r.config.GiltFile)
+	if err != nil {
+		return err
+	}
+
+	out, err := giltfile.SetVersions(data, versions)
+	if err != nil {
+		return fmt.Errorf("unable to update %s: %s", r.config.GiltFile, err)
+	}
+
+	r.logger.Info("writing giltfile", slog.String("giltFile", r.config.GiltFile))
+	return r.appFs.WriteFile(r.config.GiltFile, out, info.Mode())
 }
 
-// populateCloneCache ensure that all named repos exist and are up-to-date
-func (r *Repositories) populateCloneCache(parallel bool) error {
+// populateCloneCache ensure that the clones of the `selected` repos exist and
+// are up-to-date
+func (r *Repositories) populateCloneCache(
+	ctx context.Context,
+	parallel bool,
+	selected map[int]bool,
+) error {
 	cacheDir, err := r.getCacheDir()
 	if err != nil {
 		r.logger.Error(
@@ -122,6 +1318,10 @@ func (r *Repositories) populateCloneCache(parallel bool) error {
 		)
 		return err
 	}
+	archiveDir, err := r.getArchiveDir()
+	if err != nil {
+		return err
+	}
 
 	// Run all the clones concurrently (1 coroutine per CPU), up to 8 workers
 	slots := 1
@@ -133,14 +1333,21 @@ func (r *Repositories) populateCloneCache(parallel bool) error {
 	errChan := make(chan error, len(r.config.Repositories)) // Channel to collect errors
 	semaphore := make(chan struct{}, slots)                 // Semaphore to limit concurrency
 
-	for _, repo := range r.config.Repositories {
+	for i, repo := range r.config.Repositories {
+		if !selected[i] {
+			continue
+		}
 		wg.Add(1)
 		go func(c config.Repository) {
 			defer wg.Done()
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			if err := r.runPopulate(c, cacheDir, &mu); err != nil {
+			dir := cacheDir
+			if c.Archive != "" {
+				dir = archiveDir
+			}
+			if err := r.runPopulate(ctx, c, dir, &mu); err != nil {
 				errChan <- err
 			}
 		}(repo)
@@ -152,26 +1359,43 @@ func (r *Repositories) populateCloneCache(parallel bool) error {
 	return r.anyErrors(errChan)
 }
 
-func (r *Repositories) runPopulate(c config.Repository, cacheDir string, mu *sync.Mutex) error {
+func (r *Repositories) runPopulate(
+	ctx context.Context,
+	c config.Repository, cacheDir string, mu *sync.Mutex,
+) error {
 	mu.Lock()
-	if _, exists := r.cloneCache[c.Git]; exists {
+	if _, exists := r.cloneCache[cacheKey(c)]; exists {
 		mu.Unlock()
+		r.notify(c, event.Event{Type: event.FetchSkipped})
 		return nil
 	}
 	// Set a "stub" value to claim territory
 	// This worker is now responsible for populating the "full" value
-	r.cloneCache[c.Git] = ""
+	r.cloneCache[cacheKey(c)] = ""
 	mu.Unlock()
 
 	// Initialize and/or update the clone (long-running operation outside the lock)
-	targetDir, err := r.repoManager.Clone(c, cacheDir)
+	r.notify(c, event.Event{Type: event.CloneStarted})
+	start := time.Now()
+	targetDir, state, err := r.repoManager.Clone(ctx, c, cacheDir)
+	if err == nil && (state == report.CloneCached || state == report.CloneLocal) {
+		r.notify(c, event.Event{Type: event.FetchSkipped, Dir: targetDir, Clone: state})
+	}
+	r.notify(c, event.Event{
+		Type:     event.CloneFinished,
+		Dir:      targetDir,
+		Clone:    state,
+		Duration: time.Since(start),
+		Err:      err,
+	})
 	if err != nil {
 		return err
 	}
 
 	// Rewrite with the "full" value
 	mu.Lock()
-	r.cloneCache[c.Git] = targetDir
+	r.cloneCache[cacheKey(c)] = targetDir
+	r.cloneStates[cacheKey(c)] = state
 	mu.Unlock()
 
 	return nil
@@ -186,56 +1410,447 @@ func (r *Repositories) anyErrors(errChan <-chan error) error {
 	return nil
 }
 
-func (r *Repositories) overlayTree(c config.Repository, targetDir string) error {
+// overlayTree extract the worktree directly into DstDir, and return the
+// content hash of what was extracted, and the paths it installed.  What it
+// replaced, and ran, is recorded in `result`.
+func (r *Repositories) overlayTree(
+	ctx context.Context,
+	j *journal.Journal,
+	c config.Repository,
+	result *report.Overlay,
+	targetDir string,
+	owned map[string]bool,
+) (string, manifest.Repository, error) {
 	if c.DstDir == "" {
-		return nil
+		return "", manifest.Repository{}, nil
 	}
-	// delete DstDir since `git worktree add` will not replace existing directories
-	if info, err := r.appFs.Stat(c.DstDir); err == nil && info.IsDir() {
-		if err := r.appFs.RemoveAll(c.DstDir); err != nil {
-			return err
-		}
+	// A worktree cannot be added to an existing directory, and build commands
+	// must not run in the destination; copy it in
+	if c.Mode == config.ModeMerge || c.Mode == config.ModeSync || len(c.BuildCommands) > 0 {
+		return r.overlayCopy(ctx, j, c, result, targetDir, owned)
 	}
-	if err := r.repoManager.Worktree(c, targetDir, c.DstDir); err != nil {
-		return err
+	targets, err := r.repoManager.Targets(c, c.DstDir)
+	if err != nil {
+		return "", manifest.Repository{}, err
 	}
-	return nil
+	created := r.missingParents(targets, owned)
+	if err := r.journalTargets(j, nil, created); err != nil {
+		return "", manifest.Repository{}, err
+	}
+	// set DstDir aside since `git worktree add` will not replace existing
+	// directories
+	existing := r.existingDirs([]string{c.DstDir})
+	if err := j.Replace(c.DstDir); err != nil {
+		return "", manifest.Repository{}, err
+	}
+	r.notifyRemoved(c, existing)
+	if err := r.worktree(ctx, c, targetDir, c.DstDir); err != nil {
+		return "", manifest.Repository{}, err
+	}
+	result.Replaced = append(result.Replaced, c.DstDir)
+	hash, err := r.repoManager.Hash(c, c.DstDir)
+	if err != nil {
+		return "", manifest.Repository{}, err
+	}
+	installed, err := r.installed(c, targets, created)
+	if err != nil {
+		return "", manifest.Repository{}, err
+	}
+	// The worktree wrote the files itself, rather than copying them one by one
+	for _, file := range installed.Files {
+		r.notify(c, event.Event{Type: event.FileCopied, File: file})
+	}
+	return hash, installed, nil
 }
 
-func (r *Repositories) overlaySubtrees(c config.Repository, targetDir string) error {
+// overlaySubtrees extract the worktree into a temporary directory, copy the
+// Repository's sources out of it, and return the content hash of what was
+// copied, and the paths it installed.  What it replaced, and ran, is recorded
+// in `result`.
+func (r *Repositories) overlaySubtrees(
+	ctx context.Context,
+	j *journal.Journal,
+	c config.Repository,
+	result *report.Overlay,
+	targetDir string,
+	owned map[string]bool,
+) (string, manifest.Repository, error) {
 	if len(c.Sources) == 0 {
-		return nil
+		return "", manifest.Repository{}, nil
 	}
+	return r.overlayCopy(ctx, j, c, result, targetDir, owned)
+}
+
+// overlayCopy extract the worktree into a temporary directory, run the
+// Repository's build commands in it, copy its targets out of it, and return
+// the content hash of what was built, and the paths it installed.  What it
+// replaced, and ran, is recorded in `result`.
+func (r *Repositories) overlayCopy(
+	ctx context.Context,
+	j *journal.Journal,
+	c config.Repository,
+	result *report.Overlay,
+	targetDir string,
+	owned map[string]bool,
+) (string, manifest.Repository, error) {
 	giltDir, err := r.getGiltDir()
 	if err != nil {
-		return err
+		return "", manifest.Repository{}, err
 	}
+	var hash string
+	var installed manifest.Repository
 	err = r.execManager.RunInTempDir(giltDir, "tmp", func(tmpDir string) error {
 		tmpClone := r.appFs.Join(tmpDir, r.appFs.Base(targetDir))
-		if err := r.repoManager.Worktree(c, targetDir, tmpClone); err != nil {
+		if err := r.worktree(ctx, c, targetDir, tmpClone); err != nil {
+			return err
+		}
+		runs, err := r.runCommands(
+			ctx,
+			c,
+			result.Version,
+			report.StageBuild,
+			c.BuildCommands,
+			tmpClone,
+		)
+		result.Commands = append(result.Commands, runs...)
+		if err != nil {
+			return err
+		}
+		if hash, err = r.repoManager.Hash(c, tmpClone); err != nil {
 			return err
 		}
-		return r.repoManager.CopySources(c, tmpClone)
+		targets, err := r.repoManager.Targets(c, tmpClone)
+		if err != nil {
+			return err
+		}
+		created := r.missingParents(targets, owned)
+		var replaced []string
+		for _, t := range targets {
+			if t.Dir && !t.Merges() {
+				replaced = append(replaced, t.Dst)
+			}
+		}
+		existing := r.existingDirs(replaced)
+		if err := r.journalTargets(j, targets, created); err != nil {
+			return err
+		}
+		r.notifyRemoved(c, existing)
+		err = r.repoManager.CopySources(c, tmpClone, func(dst string) {
+			r.notify(c, event.Event{Type: event.FileCopied, File: dst})
+		})
+		if err != nil {
+			return err
+		}
+		result.Replaced = append(result.Replaced, replaced...)
+		installed, err = r.installed(c, targets, created)
+		return err
 	})
 	if err != nil {
-		return err
+		return "", manifest.Repository{}, err
+	}
+	return hash, installed, nil
+}
+
+// existingDirs returns those of `dirs` which exist.
+func (r *Repositories) existingDirs(dirs []string) []string {
+	var existing []string
+	for _, dir := range dirs {
+		if info, err := r.appFs.Stat(dir); err == nil && info.IsDir() {
+			existing = append(existing, dir)
+		}
 	}
+
+	return existing
+}
+
+// notifyRemoved notify the observer of each of the Repository's `dirs` which
+// were removed.
+func (r *Repositories) notifyRemoved(c config.Repository, dirs []string) {
+	for _, dir := range dirs {
+		r.notify(c, event.Event{Type: event.DirRemoved, Dir: dir})
+	}
+}
+
+// journalTargets record in `j` the parent directories overlaying `targets`
+// `created`, and set aside each target's destination; copied when it is
+// merged into, and moved otherwise.
+func (r *Repositories) journalTargets(
+	j *journal.Journal,
+	targets []internal.Target,
+	created []string,
+) error {
+	for _, dir := range created {
+		if err := j.Create(dir); err != nil {
+			return err
+		}
+	}
+	for _, t := range targets {
+		save := j.Replace
+		if t.Merges() {
+			save = j.Modify
+		}
+		if err := save(t.Dst); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func (r *Repositories) runCommands(c config.Repository) error {
-	if len(c.Commands) == 0 {
+// missingParents returns the parent directories of each target's destination
+// which do not exist yet, and so will be created by overlaying it.  Parents an
+// earlier overlay created, and recorded in `owned`, are included too.
+func (r *Repositories) missingParents(targets []internal.Target, owned map[string]bool) []string {
+	var missing []string
+	for _, t := range targets {
+		for dir := r.appFs.Dir(t.Dst); dir != "." && dir != r.appFs.Dir(dir); dir = r.appFs.Dir(dir) {
+			if _, err := r.appFs.Stat(dir); err == nil && !owned[dir] {
+				break
+			}
+			missing = append(missing, dir)
+		}
+	}
+
+	return missing
+}
+
+// installed lists the files and directories overlaying `targets` put in
+// place, along with the parent directories it `created`.
+func (r *Repositories) installed(
+	c config.Repository,
+	targets []internal.Target,
+	created []string,
+) (manifest.Repository, error) {
+	files := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, dir := range created {
+		dirs[dir] = true
+	}
+
+	for _, t := range targets {
+		if !t.Dir {
+			files[t.Dst] = true
+			continue
+		}
+		if t.Merges() {
+			// Dst holds foreign files too; record only what was copied
+			if err := r.merged(t, files, dirs); err != nil {
+				return manifest.Repository{}, err
+			}
+			continue
+		}
+		err := r.appFs.WalkDir(t.Dst, func(path string, d fs.DirEntry, err error) error {
+			switch {
+			case err != nil:
+				return err
+			case d.IsDir():
+				dirs[path] = true
+			default:
+				files[path] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return manifest.Repository{}, err
+		}
+	}
+
+	return manifest.Repository{
+		Name:    c.Name,
+		Git:     c.Git,
+		Archive: c.Archive,
+		Path:    c.Path,
+		Files:   sortedKeys(files),
+		Dirs:    sortedKeys(dirs),
+	}, nil
+}
+
+// merged adds to `files` and `dirs` the paths merging the directory target `t`
+// copied into its destination.
+func (r *Repositories) merged(t internal.Target, files, dirs map[string]bool) error {
+	return r.appFs.WalkDir(t.Src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := r.appFs.Rel(t.Src, path)
+		if err != nil {
+			return err
+		}
+		dst := r.appFs.Join(t.Dst, rel)
+		switch {
+		case d.IsDir() && glob.Match(t.Exclude, r.appFs.ToSlash(rel)) && rel != ".":
+			return fs.SkipDir
+		case d.IsDir():
+			// Left out when nothing below it was selected
+			if _, err := r.appFs.Stat(dst); err == nil {
+				dirs[dst] = true
+			}
+		case glob.Selected(t.Include, t.Exclude, r.appFs.ToSlash(rel)):
+			files[dst] = true
+		}
 		return nil
+	})
+}
+
+// sortedKeys returns the keys of `set` in order.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// runCommands run the `stage` `commands` for the Repository, each in its own
+// working directory relative to `dir`, or to the invocation directory when
+// `dir` is empty, and with the Repository described in GILT_* variables.  `c`
+// is pinned to its resolved commit, and `version` is the version configured.
+// Returns a record of each command run, including the one which failed.
+func (r *Repositories) runCommands(
+	ctx context.Context,
+	c config.Repository,
+	version string,
+	stage string,
+	commands []config.Command,
+	dir string,
+) ([]report.Command, error) {
+	if len(commands) == 0 {
+		return nil, nil
+	}
+	// Absolute, as commands may run in another directory
+	dstDir := c.DstDir
+	if dstDir != "" {
+		abs, err := r.appFs.Abs(dstDir)
+		if err != nil {
+			return nil, err
+		}
+		dstDir = abs
 	}
-	for _, command := range c.Commands {
+	giltEnv := []string{
+		"GILT_REPO_URL=" + source(c),
+		"GILT_VERSION=" + version,
+		"GILT_RESOLVED_SHA=" + c.Version,
+		"GILT_DST_DIR=" + dstDir,
+	}
+	runs := make([]report.Command, 0, len(commands))
+	for _, command := range commands {
+		cwd := command.Dir
+		if dir != "" && !r.appFs.IsAbs(cwd) {
+			cwd = r.appFs.Join(dir, cwd)
+		}
 		r.logger.Info(
 			"executing command",
+			slog.String("stage", stage),
 			slog.String("cmd", command.Cmd),
 			slog.String("args", strings.Join(command.Args, " ")),
+			slog.String("dir", cwd),
 		)
-		if _, err := r.execManager.RunCmd(command.Cmd, command.Args); err != nil {
-			return err
+		r.notify(c, event.Event{
+			Type:  event.CommandStarted,
+			Dir:   cwd,
+			Stage: stage,
+			Cmd:   commandText(command),
+		})
+		start := time.Now()
+		err := r.runCommand(ctx, command, cwd, slices.Concat(giltEnv, command.Env))
+		run := report.Command{
+			Stage:    stage,
+			Cmd:      commandText(command),
+			Dir:      cwd,
+			ExitCode: exitCode(err),
+			Duration: time.Since(start),
+		}
+		r.notify(c, event.Event{
+			Type:     event.CommandFinished,
+			Dir:      run.Dir,
+			Stage:    run.Stage,
+			Cmd:      run.Cmd,
+			ExitCode: run.ExitCode,
+			Duration: run.Duration,
+			Err:      err,
+		})
+		if err != nil {
+			run.Error = err.Error()
+			return append(runs, run), err
 		}
+		runs = append(runs, run)
 	}
-	return nil
+	return runs, nil
+}
+
+// exitCode the exit status of a command which returned `err`; -1 when it did
+// not exit on its own, or could not be started.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// skipped add `stage` to the `skipped` stages, when it has any `commands`.
+func skipped(skipped []string, stage string, commands []config.Command) []string {
+	if len(commands) == 0 {
+		return skipped
+	}
+	return append(skipped, stage)
+}
+
+// runCommand run `command` in `dir`, with `env` added to its environment,
+// killing it once its timeout elapses.
+func (r *Repositories) runCommand(
+	ctx context.Context,
+	command config.Command,
+	dir string,
+	env []string,
+) error {
+	cmdCtx := ctx
+	if command.Timeout > 0 {
+		var cancel context.CancelFunc
+		cmdCtx, cancel = context.WithTimeout(ctx, command.Timeout)
+		defer cancel()
+	}
+
+	name, args := commandLine(command)
+	_, err := r.execManager.RunCmdWithEnv(cmdCtx, name, args, dir, env)
+	switch {
+	case err == nil:
+		return nil
+	// Stopped from outside, e.g. by an interrupt, rather than by its timeout
+	case ctx.Err() != nil:
+		return fmt.Errorf("command %s: %s", command.Cmd, ctx.Err())
+	case command.Timeout > 0 && errors.Is(cmdCtx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("command %s timed out after %s", command.Cmd, command.Timeout)
+	}
+	return err
+}
+
+// describeCommand the command line of `command`, and the directory it runs
+// in, as a dry run lists it.
+func describeCommand(command config.Command) string {
+	line := commandText(command)
+	if command.Dir != "" {
+		line = fmt.Sprintf("%s (in %s)", line, command.Dir)
+	}
+	return line
+}
+
+// commandText the command line of `command`.
+func commandText(command config.Command) string {
+	return strings.TrimSpace(command.Cmd + " " + strings.Join(command.Args, " "))
+}
+
+// commandLine the program, and arguments, running `command` executes.  A shell
+// command is a script run by `sh`, with its args as positional parameters.
+func commandLine(command config.Command) (string, []string) {
+	if !command.Shell {
+		return command.Cmd, command.Args
+	}
+
+	return "sh", append([]string{"-c", command.Cmd, "sh"}, command.Args...)
 }
@@ -21,10 +21,14 @@
 package repositories_test
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/avfs/avfs"
 	"github.com/avfs/avfs/vfs/memfs"
@@ -34,10 +38,14 @@ import (
 	"go.uber.org/mock/gomock"
 
 	"github.com/retr0h/gilt/v2/internal"
+	"github.com/retr0h/gilt/v2/internal/lockfile"
+	"github.com/retr0h/gilt/v2/internal/manifest"
 	"github.com/retr0h/gilt/v2/internal/mocks/exec"
 	"github.com/retr0h/gilt/v2/internal/mocks/repository"
 	"github.com/retr0h/gilt/v2/internal/repositories"
 	"github.com/retr0h/gilt/v2/pkg/config"
+	"github.com/retr0h/gilt/v2/pkg/event"
+	"github.com/retr0h/gilt/v2/pkg/report"
 )
 
 type RepositoriesPublicTestSuite struct {
@@ -52,9 +60,32 @@ type RepositoriesPublicTestSuite struct {
 	giltDir          string
 	gitURL           string
 	gitVersion       string
+	gitHash          string
 	repoConfigDstDir []config.Repository
 	SkipCommands     bool
+	Locked           bool
+	Prune            bool
+	Replace          string
+	Only             []string
+	Skip             []string
+	Label            string
+	GiltFile         string
 	logger           *slog.Logger
+
+	mu     sync.Mutex
+	events []event.Type
+	copied []event.Event
+}
+
+// observe record the type of `e`, and each file copied; clones are fetched
+// in parallel.
+func (suite *RepositoriesPublicTestSuite) observe(e event.Event) {
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+	suite.events = append(suite.events, e.Type)
+	if e.Type == event.FileCopied {
+		suite.copied = append(suite.copied, e)
+	}
 }
 
 func (suite *RepositoriesPublicTestSuite) NewTestRepositoriesManager(
@@ -64,7 +95,13 @@ func (suite *RepositoriesPublicTestSuite) NewTestRepositoriesManager(
 		Debug:        false,
 		Parallel:     true,
 		SkipCommands: suite.SkipCommands,
-		GiltFile:     "Giltfile.yaml",
+		Locked:       suite.Locked,
+		Prune:        suite.Prune,
+		Replace:      suite.Replace,
+		Only:         suite.Only,
+		Skip:         suite.Skip,
+		Label:        suite.Label,
+		GiltFile:     suite.GiltFile,
 		GiltDir:      suite.giltDir,
 		Repositories: repoConfig,
 	}
@@ -74,6 +111,7 @@ func (suite *RepositoriesPublicTestSuite) NewTestRepositoriesManager(
 		reposConfig,
 		suite.mockRepo,
 		suite.mockExec,
+		event.ObserverFunc(suite.observe),
 		suite.logger,
 	)
 }
@@ -88,6 +126,7 @@ func (suite *RepositoriesPublicTestSuite) SetupTest() {
 	suite.giltDir = "/giltDir"
 	suite.gitURL = "https://example.com/user/repo.git"
 	suite.gitVersion = "abc1234"
+	suite.gitHash = "sha256:0123abcd"
 	suite.repoConfigDstDir = []config.Repository{
 		{
 			Git:     suite.gitURL,
@@ -96,6 +135,15 @@ func (suite *RepositoriesPublicTestSuite) SetupTest() {
 		},
 	}
 	suite.SkipCommands = false
+	suite.Locked = false
+	suite.Prune = false
+	suite.Replace = ""
+	suite.Only = nil
+	suite.Skip = nil
+	suite.Label = ""
+	suite.GiltFile = "Giltfile.yaml"
+	suite.events = nil
+	suite.copied = nil
 	suite.logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
 }
 
@@ -108,13 +156,20 @@ func (suite *RepositoriesPublicTestSuite) TestOverlayOkWhenDstDir() {
 	expected := suite.appFs.Join(suite.giltDir, "cache")
 
 	suite.mockRepo.EXPECT().
-		Clone(suite.repoConfigDstDir[0], expected).
-		Return(expected, nil)
+		Clone(gomock.Any(), suite.repoConfigDstDir[0], expected).
+		Return(expected, "", nil)
 	suite.mockRepo.EXPECT().
-		Worktree(suite.repoConfigDstDir[0], expected, suite.dstDir).
+		Resolve(gomock.Any(), suite.repoConfigDstDir[0], expected).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), suite.repoConfigDstDir[0], expected, suite.dstDir).
 		Return(nil)
+	suite.mockRepo.EXPECT().
+		Hash(suite.repoConfigDstDir[0], suite.dstDir).
+		Return(suite.gitHash, nil)
 
-	err := repos.Overlay()
+	_, err := repos.Overlay(context.Background())
 	assert.NoError(suite.T(), err)
 }
 
@@ -124,13 +179,17 @@ func (suite *RepositoriesPublicTestSuite) TestOverlayErrorWhenDstDir() {
 	errors := errors.New("tests error")
 
 	suite.mockRepo.EXPECT().
-		Clone(suite.repoConfigDstDir[0], expected).
-		Return(expected, nil)
+		Clone(gomock.Any(), suite.repoConfigDstDir[0], expected).
+		Return(expected, "", nil)
 	suite.mockRepo.EXPECT().
-		Worktree(suite.repoConfigDstDir[0], expected, suite.dstDir).
+		Resolve(gomock.Any(), suite.repoConfigDstDir[0], expected).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), suite.repoConfigDstDir[0], expected, suite.dstDir).
 		Return(errors)
 
-	err := repos.Overlay()
+	_, err := repos.Overlay(context.Background())
 	assert.Error(suite.T(), err)
 }
 
@@ -138,29 +197,40 @@ func (suite *RepositoriesPublicTestSuite) TestOverlayCacheDirCreateError() {
 	// Replace the test FS with a read-only copy
 	suite.appFs = rofs.New(suite.appFs)
 	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
-	err := repos.Overlay()
+	_, err := repos.Overlay(context.Background())
 	assert.Error(suite.T(), err)
 }
 
 func (suite *RepositoriesPublicTestSuite) TestOverlayDstDirExists() {
 	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
 
-	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any()).Return("", nil)
-	suite.mockRepo.EXPECT().Worktree(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
 
 	_ = suite.appFs.MkdirAll(suite.dstDir, 0o755)
-	err := repos.Overlay()
+	_, err := repos.Overlay(context.Background())
 	assert.NoError(suite.T(), err)
 }
 
 func (suite *RepositoriesPublicTestSuite) TestOverlayErrorRemovingDstDir() {
-	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any()).Return("", nil)
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
 	_ = suite.appFs.MkdirAll(suite.appFs.Join(suite.giltDir, "cache"), 0o700)
 	_ = suite.appFs.MkdirAll(suite.dstDir, 0o755)
 	// Replace the test FS with a read-only copy
 	suite.appFs = rofs.New(suite.appFs)
 	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
-	err := repos.Overlay()
+	_, err := repos.Overlay(context.Background())
 	assert.Error(suite.T(), err)
 }
 
@@ -172,9 +242,9 @@ func (suite *RepositoriesPublicTestSuite) TestOverlayReturnsErrorWhenCloneErrors
 	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
 
 	errors := errors.New("tests error")
-	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any()).Return("", errors)
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", errors)
 
-	err := repos.Overlay()
+	_, err := repos.Overlay(context.Background())
 	assert.Error(suite.T(), err)
 }
 
@@ -193,7 +263,10 @@ func (suite *RepositoriesPublicTestSuite) TestOverlayOkWhenCopySources() {
 	}
 	repos := suite.NewTestRepositoriesManager(repoConfig)
 
-	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any()).Return("", nil)
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
 	suite.mockExec.EXPECT().
 		RunInTempDir(gomock.Any(), gomock.Any(), gomock.Any()).
 		DoAndReturn(func(_ string, _ string, fn func(string) error) error {
@@ -202,11 +275,233 @@ func (suite *RepositoriesPublicTestSuite) TestOverlayOkWhenCopySources() {
 			}
 			return nil
 		})
-	suite.mockRepo.EXPECT().Worktree(repoConfig[0], gomock.Any(), gomock.Any()).Return(nil)
-	suite.mockRepo.EXPECT().CopySources(repoConfig[0], gomock.Any()).Return(nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), repoConfig[0], gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+	suite.mockRepo.EXPECT().CopySources(repoConfig[0], gomock.Any(), gomock.Any()).Return(nil)
+
+	_, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayNotifiesObserver() {
+	repoConfig := []config.Repository{
+		{
+			Git:     suite.gitURL,
+			Version: suite.gitVersion,
+			DstDir:  suite.dstDir,
+			Commands: []config.Command{
+				{
+					Cmd: "make",
+				},
+			},
+		},
+	}
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+	suite.mockExec.EXPECT().
+		RunCmdWithEnv(gomock.Any(), "make", gomock.Any(), gomock.Any(), gomock.Any()).
+		Return("", nil)
+
+	_ = suite.appFs.MkdirAll(suite.dstDir, 0o755)
+	_, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []event.Type{
+		event.CloneStarted,
+		event.CloneFinished,
+		event.DirRemoved,
+		event.WorktreeExtracted,
+		event.CommandStarted,
+		event.CommandFinished,
+	}, suite.events)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayNotifiesObserverOfCopiedFiles() {
+	repoConfig := []config.Repository{
+		{
+			Name:    "foo",
+			Git:     suite.gitURL,
+			Version: suite.gitVersion,
+			Sources: []config.Source{
+				{
+					Src:     "foo",
+					DstFile: "/library/foo",
+				},
+			},
+		},
+		{
+			Name:    "bar",
+			Git:     suite.gitURL,
+			Version: suite.gitVersion,
+			Sources: []config.Source{
+				{
+					Src:     "bar",
+					DstFile: "/library/bar",
+				},
+			},
+		},
+	}
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil).
+		Times(2)
+	suite.expectTempDir()
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil).
+		Times(2)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil).Times(2)
+	gomock.InOrder(
+		suite.mockRepo.EXPECT().
+			Targets(repoConfig[0], gomock.Any()).
+			Return([]internal.Target{{Src: "stub/foo", Dst: "/library/foo"}}, nil),
+		suite.mockRepo.EXPECT().
+			Targets(repoConfig[1], gomock.Any()).
+			Return([]internal.Target{{Src: "stub/bar", Dst: "/library/bar"}}, nil),
+	)
+	suite.mockRepo.EXPECT().
+		CopySources(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(c config.Repository, _ string, copied func(string)) error {
+			copied(c.Sources[0].DstFile)
+			return nil
+		}).
+		Times(2)
+
+	_, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+	// The second Repository shares the first's clone, which is fetched in
+	// parallel
+	assert.ElementsMatch(suite.T(), []event.Type{
+		event.CloneStarted,
+		event.CloneFinished,
+		event.FetchSkipped,
+	}, suite.events[:3])
+	assert.Equal(suite.T(), []event.Type{
+		event.WorktreeExtracted,
+		event.FileCopied,
+		event.WorktreeExtracted,
+		event.FileCopied,
+	}, suite.events[3:])
+	assert.Equal(suite.T(), []string{"foo", "bar"}, []string{
+		suite.copied[0].Name,
+		suite.copied[1].Name,
+	})
+	assert.Equal(suite.T(), []string{"/library/foo", "/library/bar"}, []string{
+		suite.copied[0].File,
+		suite.copied[1].File,
+	})
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayNotifiesObserverOfCopiedFilesInCopyOrder() {
+	repoConfig := []config.Repository{
+		{
+			Name:    "etcd",
+			Git:     suite.gitURL,
+			Version: suite.gitVersion,
+			DstDir:  suite.dstDir,
+			Mode:    config.ModeMerge,
+		},
+	}
+	_ = suite.appFs.MkdirAll("/work", 0o755)
+	_ = suite.appFs.WriteFile("/work/a.py", nil, 0o644)
+	_ = suite.appFs.WriteFile("/work/z.py", nil, 0o644)
+	_ = suite.appFs.MkdirAll(suite.dstDir, 0o755)
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return([]internal.Target{
+		{Src: "/work", Dst: suite.dstDir, Dir: true, Mode: config.ModeMerge},
+	}, nil)
+	// Copied in an order other than the sorted one
+	suite.mockRepo.EXPECT().
+		CopySources(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ config.Repository, _ string, copied func(string)) error {
+			for _, path := range []string{"/dstDir/z.py", "/dstDir/a.py"} {
+				if err := suite.appFs.WriteFile(path, nil, 0o644); err != nil {
+					return err
+				}
+				copied(path)
+			}
+			return nil
+		})
+
+	_, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+
+	var files []string
+	for _, e := range suite.copied {
+		assert.Equal(suite.T(), "etcd", e.Name)
+		files = append(files, e.File)
+	}
+	assert.Equal(suite.T(), []string{"/dstDir/z.py", "/dstDir/a.py"}, files)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayNotifiesObserverOfExtractedFiles() {
+	repoConfig := []config.Repository{
+		{
+			Name:    "etcd",
+			Git:     suite.gitURL,
+			Version: suite.gitVersion,
+			DstDir:  suite.dstDir,
+		},
+	}
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return([]internal.Target{
+		{Src: "/work", Dst: suite.dstDir, Dir: true},
+	}, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), suite.dstDir).
+		DoAndReturn(func(_ context.Context, _ config.Repository, _ string, dir string) error {
+			_ = suite.appFs.MkdirAll(suite.appFs.Join(dir, "roles"), 0o755)
+			_ = suite.appFs.WriteFile(suite.appFs.Join(dir, "roles", "main.yml"), nil, 0o644)
+			return suite.appFs.WriteFile(suite.appFs.Join(dir, "README.md"), nil, 0o644)
+		})
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
 
-	err := repos.Overlay()
+	_, err := repos.Overlay(context.Background())
 	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), []event.Type{
+		event.CloneStarted,
+		event.CloneFinished,
+		event.WorktreeExtracted,
+		event.FileCopied,
+		event.FileCopied,
+	}, suite.events)
+	var files []string
+	for _, e := range suite.copied {
+		assert.Equal(suite.T(), "etcd", e.Name)
+		files = append(files, e.File)
+	}
+	assert.Equal(suite.T(), []string{"/dstDir/README.md", "/dstDir/roles/main.yml"}, files)
 }
 
 func (suite *RepositoriesPublicTestSuite) TestOverlayReturnsErrorWhenCopySourcesErrors() {
@@ -225,7 +520,10 @@ func (suite *RepositoriesPublicTestSuite) TestOverlayReturnsErrorWhenCopySources
 	repos := suite.NewTestRepositoriesManager(repoConfig)
 	errors := errors.New("tests error")
 
-	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any()).Return("", nil)
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
 	suite.mockExec.EXPECT().
 		RunInTempDir(gomock.Any(), gomock.Any(), gomock.Any()).
 		DoAndReturn(func(_ string, _ string, fn func(string) error) error {
@@ -234,10 +532,14 @@ func (suite *RepositoriesPublicTestSuite) TestOverlayReturnsErrorWhenCopySources
 			}
 			return nil
 		})
-	suite.mockRepo.EXPECT().Worktree(repoConfig[0], gomock.Any(), gomock.Any()).Return(nil)
-	suite.mockRepo.EXPECT().CopySources(gomock.Any(), gomock.Any()).Return(errors)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), repoConfig[0], gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+	suite.mockRepo.EXPECT().CopySources(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors)
 
-	err := repos.Overlay()
+	_, err := repos.Overlay(context.Background())
 	assert.Error(suite.T(), err)
 }
 
@@ -257,7 +559,10 @@ func (suite *RepositoriesPublicTestSuite) TestOverlayErrorCreatingCopySourcesWor
 	repos := suite.NewTestRepositoriesManager(repoConfig)
 	errors := errors.New("tests error")
 
-	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any()).Return("", nil)
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
 	suite.mockExec.EXPECT().
 		RunInTempDir(gomock.Any(), gomock.Any(), gomock.Any()).
 		DoAndReturn(func(_ string, _ string, fn func(string) error) error {
@@ -266,9 +571,11 @@ func (suite *RepositoriesPublicTestSuite) TestOverlayErrorCreatingCopySourcesWor
 			}
 			return nil
 		})
-	suite.mockRepo.EXPECT().Worktree(repoConfig[0], gomock.Any(), gomock.Any()).Return(errors)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), repoConfig[0], gomock.Any(), gomock.Any()).
+		Return(errors)
 
-	err := repos.Overlay()
+	_, err := repos.Overlay(context.Background())
 	assert.Error(suite.T(), err)
 }
 
@@ -289,11 +596,87 @@ func (suite *RepositoriesPublicTestSuite) TestOverlayOkWhenCommands() {
 
 	repos := suite.NewTestRepositoriesManager(repoConfig)
 
-	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any()).Return("", nil)
-	suite.mockRepo.EXPECT().Worktree(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
-	suite.mockExec.EXPECT().RunCmd("touch", []string{"/tmp/foo"}).Return("", nil)
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return("", report.CloneUpdated, nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+	suite.mockExec.EXPECT().
+		RunCmdWithEnv(gomock.Any(), "touch", []string{"/tmp/foo"}, "", []string{
+			"GILT_REPO_URL=" + suite.gitURL,
+			"GILT_VERSION=" + suite.gitVersion,
+			"GILT_RESOLVED_SHA=" + suite.gitVersion,
+			"GILT_DST_DIR=" + suite.dstDir,
+		}).
+		Return("", nil)
+
+	got, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), got, 1)
+	assert.Equal(suite.T(), suite.gitURL, got[0].Git)
+	assert.Equal(suite.T(), suite.gitVersion, got[0].Commit)
+	assert.Equal(suite.T(), report.CloneUpdated, got[0].Clone)
+	assert.Equal(suite.T(), []string{suite.dstDir}, got[0].Replaced)
+	assert.Len(suite.T(), got[0].Commands, 1)
+	assert.Equal(suite.T(), report.StagePost, got[0].Commands[0].Stage)
+	assert.Equal(suite.T(), "touch /tmp/foo", got[0].Commands[0].Cmd)
+	assert.Equal(suite.T(), 0, got[0].Commands[0].ExitCode)
+	assert.Empty(suite.T(), got[0].Commands[0].Error)
+	assert.Empty(suite.T(), got[0].Skipped)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayRunsShellCommandsInDirWithEnv() {
+	repoConfig := []config.Repository{
+		{
+			Git:     suite.gitURL,
+			Version: "^1.0",
+			DstDir:  suite.dstDir,
+			Commands: []config.Command{
+				{
+					Cmd:   `ansible-galaxy install -r "$1"`,
+					Args:  []string{"requirements.yml"},
+					Dir:   suite.dstDir,
+					Env:   []string{"ZONE=b", "ANSIBLE_NOCOLOR=1"},
+					Shell: true,
+				},
+			},
+		},
+	}
+
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitHash, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+	suite.mockExec.EXPECT().
+		RunCmdWithEnv(
+			gomock.Any(),
+			"sh",
+			[]string{"-c", `ansible-galaxy install -r "$1"`, "sh", "requirements.yml"},
+			suite.dstDir,
+			[]string{
+				"GILT_REPO_URL=" + suite.gitURL,
+				"GILT_VERSION=^1.0",
+				"GILT_RESOLVED_SHA=" + suite.gitHash,
+				"GILT_DST_DIR=" + suite.dstDir,
+				"ZONE=b",
+				"ANSIBLE_NOCOLOR=1",
+			},
+		).
+		Return("", nil)
 
-	err := repos.Overlay()
+	_, err := repos.Overlay(context.Background())
 	assert.NoError(suite.T(), err)
 }
 
@@ -314,17 +697,29 @@ func (suite *RepositoriesPublicTestSuite) TestOverlayReturnsErrorWhenCommandErro
 
 	repos := suite.NewTestRepositoriesManager(repoConfig)
 
-	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any()).Return("", nil)
-	suite.mockRepo.EXPECT().Worktree(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
 	errors := errors.New("tests error")
-	suite.mockExec.EXPECT().RunCmd(gomock.Any(), gomock.Any()).Return("", errors)
+	suite.mockExec.EXPECT().
+		RunCmdWithEnv(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return("", errors)
 
-	err := repos.Overlay()
+	got, err := repos.Overlay(context.Background())
 	assert.Error(suite.T(), err)
+	assert.Len(suite.T(), got, 1)
+	assert.Len(suite.T(), got[0].Commands, 1)
+	assert.Equal(suite.T(), -1, got[0].Commands[0].ExitCode)
+	assert.Equal(suite.T(), "tests error", got[0].Commands[0].Error)
 }
 
-func (suite *RepositoriesPublicTestSuite) TestOverlaySkipsCommands() {
-	suite.SkipCommands = true
+func (suite *RepositoriesPublicTestSuite) TestOverlayReturnsErrorWhenCommandTimesOut() {
 	repoConfig := []config.Repository{
 		{
 			Git:     suite.gitURL,
@@ -332,8 +727,9 @@ func (suite *RepositoriesPublicTestSuite) TestOverlaySkipsCommands() {
 			DstDir:  suite.dstDir,
 			Commands: []config.Command{
 				{
-					Cmd:  "touch",
-					Args: []string{"/tmp/foo"},
+					Cmd:     "sleep",
+					Args:    []string{"60"},
+					Timeout: 10 * time.Millisecond,
 				},
 			},
 		},
@@ -341,13 +737,2095 @@ func (suite *RepositoriesPublicTestSuite) TestOverlaySkipsCommands() {
 
 	repos := suite.NewTestRepositoriesManager(repoConfig)
 
-	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any()).Return("", nil)
-	suite.mockRepo.EXPECT().Worktree(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
-	// Explicitly check that RunCmd is never called
-	suite.mockExec.EXPECT().RunCmd(gomock.Any(), gomock.Any()).Times(0)
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+	suite.mockExec.EXPECT().
+		RunCmdWithEnv(gomock.Any(), "sleep", []string{"60"}, "", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ string, _ []string, _ string, _ []string) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		})
+
+	_, err := repos.Overlay(context.Background())
+	assert.EqualError(suite.T(), err, "command sleep timed out after 10ms")
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayReturnsErrorWhenCommandOutlivesDeadline() {
+	repoConfig := []config.Repository{
+		{
+			Git:      suite.gitURL,
+			Version:  suite.gitVersion,
+			DstDir:   suite.dstDir,
+			Commands: []config.Command{{Cmd: "sleep", Args: []string{"60"}}},
+		},
+	}
+
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+	suite.mockExec.EXPECT().
+		RunCmdWithEnv(gomock.Any(), "sleep", []string{"60"}, "", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ string, _ []string, _ string, _ []string) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := repos.Overlay(ctx)
+	assert.EqualError(suite.T(), err, "command sleep: context deadline exceeded")
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayReturnsErrorWhenCancelled() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(context.Context, config.Repository, string) (string, string, error) {
+			cancel()
+			return suite.gitVersion, "", nil
+		})
+	// Nothing is extracted once cancelled
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	_, err := repos.Overlay(ctx)
+	assert.ErrorIs(suite.T(), err, context.Canceled)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayRunsPreCommandsFirst() {
+	repoConfig := []config.Repository{
+		{
+			Git:         suite.gitURL,
+			Version:     suite.gitVersion,
+			DstDir:      suite.dstDir,
+			PreCommands: []config.Command{{Cmd: "make", Args: []string{"check"}}},
+		},
+	}
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitHash, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	gomock.InOrder(
+		suite.mockExec.EXPECT().
+			RunCmdWithEnv(gomock.Any(), "make", []string{"check"}, "", []string{
+				"GILT_REPO_URL=" + suite.gitURL,
+				"GILT_VERSION=" + suite.gitVersion,
+				"GILT_RESOLVED_SHA=" + suite.gitHash,
+				"GILT_DST_DIR=" + suite.dstDir,
+			}).
+			Return("", nil),
+		suite.mockRepo.EXPECT().
+			Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil),
+	)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+
+	_, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayReturnsErrorWhenPreCommandErrors() {
+	repoConfig := []config.Repository{
+		{
+			Git:         suite.gitURL,
+			Version:     suite.gitVersion,
+			DstDir:      suite.dstDir,
+			PreCommands: []config.Command{{Cmd: "make", Args: []string{"check"}}},
+		},
+	}
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+	_ = suite.appFs.MkdirAll(suite.dstDir, 0o755)
+	_ = suite.appFs.WriteFile("/dstDir/1.txt", []byte("old"), 0o644)
+	errors := errors.New("tests error")
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitHash, "", nil)
+	suite.mockExec.EXPECT().
+		RunCmdWithEnv(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return("", errors)
+	// No destination is touched
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	_, err := repos.Overlay(context.Background())
+	assert.Error(suite.T(), err)
+
+	data, err := suite.appFs.ReadFile("/dstDir/1.txt")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "old", string(data))
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayRunsBuildCommandsInWorktree() {
+	repoConfig := []config.Repository{
+		{
+			Git:     suite.gitURL,
+			Version: suite.gitVersion,
+			DstDir:  suite.dstDir,
+			BuildCommands: []config.Command{
+				{Cmd: "make", Args: []string{"docs"}},
+				{Cmd: "helm", Args: []string{"dependency", "build"}, Dir: "chart"},
+			},
+		},
+	}
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitHash, "", nil)
+	suite.expectTempDir()
+	// The worktree is extracted, and built, in a temporary directory, and only
+	// then copied to DstDir
+	gomock.InOrder(
+		suite.mockRepo.EXPECT().
+			Worktree(gomock.Any(), gomock.Any(), gomock.Any(), "stub").
+			Return(nil),
+		suite.mockExec.EXPECT().
+			RunCmdWithEnv(gomock.Any(), "make", []string{"docs"}, "stub", gomock.Any()).
+			Return("", nil),
+		suite.mockExec.EXPECT().
+			RunCmdWithEnv(
+				gomock.Any(),
+				"helm",
+				[]string{"dependency", "build"},
+				"stub/chart",
+				gomock.Any(),
+			).
+			Return("", nil),
+		suite.mockRepo.EXPECT().Hash(gomock.Any(), "stub").Return(suite.gitHash, nil),
+		suite.mockRepo.EXPECT().Targets(gomock.Any(), "stub").Return(nil, nil),
+		suite.mockRepo.EXPECT().CopySources(gomock.Any(), "stub", gomock.Any()).Return(nil),
+	)
+
+	_, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayReturnsErrorWhenBuildCommandErrors() {
+	repoConfig := []config.Repository{
+		{
+			Git:           suite.gitURL,
+			Version:       suite.gitVersion,
+			Sources:       []config.Source{{Src: "docs/_build", DstDir: suite.dstDir}},
+			BuildCommands: []config.Command{{Cmd: "make", Args: []string{"docs"}}},
+		},
+	}
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+	errors := errors.New("tests error")
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitHash, "", nil)
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockExec.EXPECT().
+		RunCmdWithEnv(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return("", errors)
+	// Nothing is copied from a failed build
+	suite.mockRepo.EXPECT().CopySources(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := repos.Overlay(context.Background())
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayRollsBackWhenCommandErrors() {
+	repoConfig := []config.Repository{
+		{
+			Git:      suite.gitURL,
+			Version:  suite.gitVersion,
+			DstDir:   suite.dstDir,
+			Commands: []config.Command{{Cmd: "false"}},
+		},
+	}
+	_ = suite.appFs.MkdirAll(suite.dstDir, 0o755)
+	_ = suite.appFs.WriteFile("/dstDir/1.txt", []byte("old"), 0o644)
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), suite.dstDir).
+		DoAndReturn(func(_ context.Context, _ config.Repository, _ string, dstDir string) error {
+			_ = suite.appFs.MkdirAll(dstDir, 0o755)
+			return suite.appFs.WriteFile(suite.appFs.Join(dstDir, "1.txt"), []byte("new"), 0o644)
+		})
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+	errors := errors.New("tests error")
+	suite.mockExec.EXPECT().
+		RunCmdWithEnv(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return("", errors)
+
+	_, err := repos.Overlay(context.Background())
+	assert.Error(suite.T(), err)
 
-	err := repos.Overlay()
+	data, err := suite.appFs.ReadFile("/dstDir/1.txt")
 	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "old", string(data))
+	for _, path := range []string{"Giltfile.lock", ".gilt"} {
+		_, err := suite.appFs.Stat(path)
+		assert.Error(suite.T(), err, path)
+	}
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayRollsBackEarlierRepositories() {
+	repoConfig := []config.Repository{
+		{
+			Git:     suite.gitURL,
+			Version: suite.gitVersion,
+			DstDir:  suite.dstDir,
+		},
+		{
+			Git:     suite.gitURL,
+			Version: suite.gitVersion,
+			DstDir:  "/roles/etcd",
+		},
+	}
+	_ = suite.appFs.MkdirAll(suite.dstDir, 0o755)
+	_ = suite.appFs.WriteFile("/dstDir/1.txt", []byte("old"), 0o644)
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil).
+		Times(2)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), suite.dstDir).Return([]internal.Target{
+		{Src: suite.dstDir, Dst: suite.dstDir, Dir: true},
+	}, nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), "/roles/etcd").Return([]internal.Target{
+		{Src: "/roles/etcd", Dst: "/roles/etcd", Dir: true},
+	}, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), suite.dstDir).
+		DoAndReturn(func(_ context.Context, _ config.Repository, _ string, dstDir string) error {
+			_ = suite.appFs.MkdirAll(dstDir, 0o755)
+			return suite.appFs.WriteFile(suite.appFs.Join(dstDir, "2.txt"), nil, 0o644)
+		})
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+	errors := errors.New("tests error")
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), "/roles/etcd").
+		DoAndReturn(func(_ context.Context, _ config.Repository, _ string, dstDir string) error {
+			_ = suite.appFs.MkdirAll(dstDir, 0o755)
+			return errors
+		})
+
+	_, err := repos.Overlay(context.Background())
+	assert.Error(suite.T(), err)
+
+	data, err := suite.appFs.ReadFile("/dstDir/1.txt")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "old", string(data))
+	for _, path := range []string{"/dstDir/2.txt", "/roles"} {
+		_, err := suite.appFs.Stat(path)
+		assert.Error(suite.T(), err, path)
+	}
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlaySkipsCommands() {
+	suite.SkipCommands = true
+	repoConfig := []config.Repository{
+		{
+			Git:     suite.gitURL,
+			Version: suite.gitVersion,
+			DstDir:  suite.dstDir,
+			PreCommands: []config.Command{
+				{
+					Cmd: "make",
+				},
+			},
+			Commands: []config.Command{
+				{
+					Cmd:  "touch",
+					Args: []string{"/tmp/foo"},
+				},
+			},
+		},
+	}
+
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+	// Explicitly check that RunCmd is never called
+	suite.mockExec.EXPECT().
+		RunCmdWithEnv(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	got, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), got, 1)
+	assert.Empty(suite.T(), got[0].Commands)
+	assert.Equal(suite.T(), []string{report.StagePre, report.StagePost}, got[0].Skipped)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayReturnsErrorWhenResolveErrors() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+	errors := errors.New("tests error")
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().Resolve(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", errors)
+	// Nothing is extracted when a version cannot be resolved
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	_, err := repos.Overlay(context.Background())
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayWritesLockFile() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+	commit := "0123456789abcdef0123456789abcdef01234567"
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(commit, "", nil)
+	// The worktree is pinned to the resolved commit
+	pinned := suite.repoConfigDstDir[0]
+	pinned.Version = commit
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().Worktree(gomock.Any(), pinned, gomock.Any(), suite.dstDir).Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+
+	_, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+
+	got, err := lockfile.Load(suite.appFs, "Giltfile.lock")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []lockfile.Repository{
+		{
+			Git:     suite.gitURL,
+			Version: suite.gitVersion,
+			Commit:  commit,
+			Hash:    suite.gitHash,
+		},
+	}, got.Repositories)
+}
+
+func (suite *RepositoriesPublicTestSuite) repoConfigNamed() []config.Repository {
+	return []config.Repository{
+		{
+			Name:    "etcd",
+			Git:     suite.gitURL,
+			Version: suite.gitVersion,
+			DstDir:  suite.dstDir,
+		},
+		{
+			Name:    "consul",
+			Git:     "https://example.com/user/consul.git",
+			Version: "v1.0.0",
+			DstDir:  "/consulDir",
+		},
+	}
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayOnlySelectedRepositories() {
+	suite.Only = []string{"consul"}
+	repoConfig := suite.repoConfigNamed()
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+	suite.writeLockFile(suite.gitVersion, suite.gitHash)
+	commit := "0123456789abcdef0123456789abcdef01234567"
+
+	// Only the selected Repository is fetched, and overlaid
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), repoConfig[1], gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), repoConfig[1], gomock.Any()).
+		Return(commit, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), "/consulDir").
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+
+	got, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), got, 1)
+	assert.Equal(suite.T(), repoConfig[1].Git, got[0].Git)
+	assert.Equal(suite.T(), "consul", got[0].Name)
+
+	// The entry left out stays locked as it was
+	lock, err := lockfile.Load(suite.appFs, "Giltfile.lock")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []lockfile.Repository{
+		{
+			Git:     suite.gitURL,
+			Version: suite.gitVersion,
+			Commit:  suite.gitVersion,
+			Hash:    suite.gitHash,
+		},
+		{
+			Name:    "consul",
+			Git:     repoConfig[1].Git,
+			Version: "v1.0.0",
+			Commit:  commit,
+			Hash:    suite.gitHash,
+		},
+	}, lock.Repositories)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlaySkipsSelectedRepositories() {
+	suite.Skip = []string{"consul"}
+	repoConfig := suite.repoConfigNamed()
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), repoConfig[0], gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), repoConfig[0], gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), suite.dstDir).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+
+	got, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), got, 1)
+
+	// The lock file cannot leave out the entry which was never locked
+	_, err = suite.appFs.Stat("Giltfile.lock")
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayReportEncodesEmptyListsAsArrays() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), suite.dstDir).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+
+	got, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), got, 1)
+
+	data, err := json.Marshal(got[0])
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), string(data), `"commands":[]`)
+	assert.Contains(suite.T(), string(data), `"skipped":[]`)
+	assert.NotContains(suite.T(), string(data), "null")
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlaySelectsByNameBeforeURL() {
+	// Both entries are vendored from ".../repo.git"
+	suite.Only = []string{"repo"}
+	repoConfig := []config.Repository{
+		{
+			Name:    "repo",
+			Git:     suite.gitURL,
+			Version: suite.gitVersion,
+			DstDir:  suite.dstDir,
+		},
+		{
+			Name:    "library",
+			Git:     suite.gitURL,
+			Version: suite.gitVersion,
+			DstDir:  "/library",
+		},
+	}
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), repoConfig[0], gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), repoConfig[0], gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), suite.dstDir).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+
+	got, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), got, 1)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayReturnsErrorWhenSelectedRepositoryNotFound() {
+	suite.Only = []string{"vault"}
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigNamed())
+
+	_, err := repos.Overlay(context.Background())
+	assert.EqualError(suite.T(), err, "repository vault not found in Giltfile.yaml")
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlaySelectsByLabel() {
+	suite.Label = "helm && !experimental"
+	repoConfig := suite.repoConfigNamed()
+	repoConfig[0].Labels = []string{"helm", "experimental"}
+	repoConfig[1].Labels = []string{"helm"}
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+	commit := "0123456789abcdef0123456789abcdef01234567"
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), repoConfig[1], gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), repoConfig[1], gomock.Any()).
+		Return(commit, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), "/consulDir").
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+
+	got, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), got, 1)
+	assert.Equal(suite.T(), repoConfig[1].Git, got[0].Git)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayReturnsErrorWhenNoRepositoryMatchesLabel() {
+	suite.Label = "docs"
+	repoConfig := suite.repoConfigNamed()
+	repoConfig[0].Labels = []string{"helm"}
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	_, err := repos.Overlay(context.Background())
+	assert.EqualError(suite.T(), err, "no repositories match label docs in Giltfile.yaml")
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayReturnsErrorWhenPruningSelectedRepositories() {
+	suite.Prune = true
+	suite.Skip = []string{"etcd"}
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigNamed())
+
+	_, err := repos.Overlay(context.Background())
+	assert.EqualError(suite.T(), err, "unable to prune when overlaying only some repositories")
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayRecordsResolvedTag() {
+	c := config.Repository{
+		Git:     suite.gitURL,
+		Version: "^1.1",
+		DstDir:  suite.dstDir,
+	}
+	repos := suite.NewTestRepositoriesManager([]config.Repository{c})
+	commit := "0123456789abcdef0123456789abcdef01234567"
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(commit, "v1.1.0", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), suite.dstDir).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+
+	got, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), got, 1)
+	assert.Equal(suite.T(), "^1.1", got[0].Version)
+	assert.Equal(suite.T(), "v1.1.0", got[0].Tag)
+
+	lock, err := lockfile.Load(suite.appFs, "Giltfile.lock")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []lockfile.Repository{
+		{
+			Git:     suite.gitURL,
+			Version: "^1.1",
+			Tag:     "v1.1.0",
+			Commit:  commit,
+			Hash:    suite.gitHash,
+		},
+	}, lock.Repositories)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayArchiveWritesLockFile() {
+	c := config.Repository{
+		Archive: "https://example.com/user/repo-1.1.tar.gz",
+		SHA256:  "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		DstDir:  suite.dstDir,
+	}
+	repos := suite.NewTestRepositoriesManager([]config.Repository{c})
+	archiveDir := suite.appFs.Join(suite.giltDir, "archives")
+	unpacked := suite.appFs.Join(archiveDir, c.SHA256)
+	commit := "sha256:" + c.SHA256
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), c, archiveDir).Return(unpacked, "", nil)
+	suite.mockRepo.EXPECT().Resolve(gomock.Any(), c, unpacked).Return(commit, "", nil)
+	pinned := c
+	pinned.Version = commit
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().Worktree(gomock.Any(), pinned, unpacked, suite.dstDir).Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+
+	_, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+
+	got, err := lockfile.Load(suite.appFs, "Giltfile.lock")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []lockfile.Repository{
+		{
+			Archive: c.Archive,
+			Commit:  commit,
+			Hash:    suite.gitHash,
+		},
+	}, got.Repositories)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayPathWritesLockFile() {
+	c := config.Repository{
+		Path:   "/src/fork",
+		DstDir: suite.dstDir,
+	}
+	repos := suite.NewTestRepositoriesManager([]config.Repository{c})
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), c, gomock.Any()).Return(c.Path, "", nil)
+	suite.mockRepo.EXPECT().Resolve(gomock.Any(), c, c.Path).Return("", "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().Worktree(gomock.Any(), c, c.Path, suite.dstDir).Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+
+	_, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+
+	got, err := lockfile.Load(suite.appFs, "Giltfile.lock")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []lockfile.Repository{
+		{
+			Path: c.Path,
+			Hash: suite.gitHash,
+		},
+	}, got.Repositories)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayReplacesRepositories() {
+	suite.Replace = "/gilt.replace"
+	_ = suite.appFs.WriteFile(suite.Replace, []byte(suite.gitURL+" => /src/fork\n"), 0o644)
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+	replaced := config.Repository{
+		Path:   "/src/fork",
+		DstDir: suite.dstDir,
+	}
+
+	suite.mockRepo.EXPECT().
+		Clone(gomock.Any(), replaced, gomock.Any()).
+		Return(replaced.Path, "", nil)
+	suite.mockRepo.EXPECT().Resolve(gomock.Any(), replaced, replaced.Path).Return("", "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), replaced, replaced.Path, suite.dstDir).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+
+	_, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+
+	// Replacements must not leak into the lock file
+	_, err = suite.appFs.Stat("Giltfile.lock")
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayLockedReturnsErrorWhenReplaced() {
+	suite.Locked = true
+	suite.Replace = "/gilt.replace"
+	_ = suite.appFs.WriteFile(suite.Replace, []byte(suite.gitURL+" => /src/fork\n"), 0o644)
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := repos.Overlay(context.Background())
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayReturnsErrorOnGarbageReplaceFile() {
+	suite.Replace = "/gilt.replace"
+	_ = suite.appFs.WriteFile(suite.Replace, []byte("garbage\n"), 0o644)
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	_, err := repos.Overlay(context.Background())
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayFetchesEachArchiveChecksum() {
+	archive := "https://example.com/user/repo-1.1.tar.gz"
+	repoConfig := []config.Repository{
+		{
+			Archive: archive,
+			SHA256:  "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			DstDir:  suite.dstDir,
+		},
+		{
+			Archive: archive,
+			SHA256:  "60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752",
+			DstDir:  suite.dstDir,
+		},
+	}
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+	errors := errors.New("tests error")
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), repoConfig[0], gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), repoConfig[1], gomock.Any()).Return("", "", errors)
+
+	_, err := repos.Overlay(context.Background())
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayWritesManifest() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return([]internal.Target{
+		{Src: suite.dstDir, Dst: suite.dstDir, Dir: true},
+	}, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), suite.dstDir).
+		DoAndReturn(func(_ context.Context, _ config.Repository, _ string, dstDir string) error {
+			_ = suite.appFs.MkdirAll(suite.appFs.Join(dstDir, "subDir"), 0o755)
+			_ = suite.appFs.WriteFile(suite.appFs.Join(dstDir, "subDir", "1.txt"), nil, 0o644)
+			return nil
+		})
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+
+	_, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+
+	got, err := manifest.Load(suite.appFs, ".gilt/Giltfile/manifest.json")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []manifest.Repository{
+		{
+			Git:   suite.gitURL,
+			Files: []string{"/dstDir/subDir/1.txt"},
+			Dirs:  []string{"/dstDir", "/dstDir/subDir"},
+		},
+	}, got.Repositories)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayManifestKeepsOrphans() {
+	previous := &manifest.Manifest{
+		Repositories: []manifest.Repository{
+			{Git: "https://example.com/user/gone.git", Files: []string{"/library/gone.txt"}},
+		},
+	}
+	_ = suite.appFs.MkdirAll("/library", 0o755)
+	_ = suite.appFs.WriteFile("/library/gone.txt", nil, 0o644)
+	_ = previous.Save(suite.appFs, ".gilt/Giltfile/manifest.json")
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+
+	_, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+
+	got, err := manifest.Load(suite.appFs, ".gilt/Giltfile/manifest.json")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []manifest.Repository{
+		{Git: suite.gitURL},
+		{Git: "https://example.com/user/gone.git", Files: []string{"/library/gone.txt"}},
+	}, got.Repositories)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayPrunesOrphans() {
+	suite.Prune = true
+	previous := &manifest.Manifest{
+		Repositories: []manifest.Repository{
+			{
+				Git:   "https://example.com/user/gone.git",
+				Files: []string{"/library/gone.txt", "/library/renamed_manage"},
+				Dirs:  []string{"/library"},
+			},
+		},
+	}
+	_ = suite.appFs.MkdirAll("/library", 0o755)
+	_ = suite.appFs.WriteFile("/library/gone.txt", nil, 0o644)
+	_ = suite.appFs.WriteFile("/library/renamed_manage", nil, 0o644)
+	_ = suite.appFs.WriteFile("/library/mine.txt", nil, 0o644)
+	_ = previous.Save(suite.appFs, ".gilt/Giltfile/manifest.json")
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+
+	_, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+
+	for _, path := range []string{"/library/gone.txt", "/library/renamed_manage"} {
+		_, err := suite.appFs.Stat(path)
+		assert.Error(suite.T(), err, path)
+	}
+	_, err = suite.appFs.Stat("/library/mine.txt")
+	assert.NoError(suite.T(), err)
+
+	// The directory still holds other files, so it is still tracked
+	got, err := manifest.Load(suite.appFs, ".gilt/Giltfile/manifest.json")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []manifest.Repository{
+		{Git: suite.gitURL},
+		{Git: "https://example.com/user/gone.git", Dirs: []string{"/library"}},
+	}, got.Repositories)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayMergeKeepsForeignFiles() {
+	suite.repoConfigDstDir[0].Mode = config.ModeMerge
+	_ = suite.appFs.MkdirAll("/work", 0o755)
+	_ = suite.appFs.WriteFile("/work/etcd.py", []byte("etcd"), 0o644)
+	_ = suite.appFs.MkdirAll(suite.dstDir, 0o755)
+	_ = suite.appFs.WriteFile("/dstDir/consul.py", []byte("consul"), 0o644)
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.expectTempDir()
+	// The worktree is extracted aside, not into DstDir
+	suite.mockRepo.EXPECT().Worktree(gomock.Any(), gomock.Any(), gomock.Any(), "stub").Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), "stub").Return(suite.gitHash, nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return([]internal.Target{
+		{Src: "/work", Dst: suite.dstDir, Dir: true, Mode: config.ModeMerge},
+	}, nil)
+	suite.mockRepo.EXPECT().
+		CopySources(gomock.Any(), "stub", gomock.Any()).
+		DoAndReturn(func(_ config.Repository, _ string, _ func(string)) error {
+			return suite.appFs.WriteFile("/dstDir/etcd.py", []byte("etcd"), 0o644)
+		})
+
+	_, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.appFs.Stat("/dstDir/consul.py")
+	assert.NoError(suite.T(), err)
+
+	// Only what was copied is recorded, so it alone is ever cleaned up
+	got, err := manifest.Load(suite.appFs, ".gilt/Giltfile/manifest.json")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []manifest.Repository{
+		{
+			Git:   suite.gitURL,
+			Files: []string{"/dstDir/etcd.py"},
+			Dirs:  []string{"/dstDir"},
+		},
+	}, got.Repositories)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayPruneLeavesWhatOtherGiltfilesInstalled() {
+	suite.Prune = true
+	suite.GiltFile = "G3.yaml"
+	other := &manifest.Manifest{
+		Repositories: []manifest.Repository{
+			{
+				Git:   "https://example.com/user/other.git",
+				Files: []string{"/lib/other.txt"},
+				Dirs:  []string{"/lib"},
+			},
+		},
+	}
+	_ = suite.appFs.MkdirAll("/lib", 0o755)
+	_ = suite.appFs.WriteFile("/lib/other.txt", nil, 0o644)
+	_ = other.Save(suite.appFs, manifest.Path("Giltfile.yaml"))
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+
+	_, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.appFs.Stat("/lib/other.txt")
+	assert.NoError(suite.T(), err)
+	got, err := manifest.Load(suite.appFs, manifest.Path("Giltfile.yaml"))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), other, got)
+	// The overlay is recorded by its own Giltfile only
+	got, err = manifest.Load(suite.appFs, manifest.Path("G3.yaml"))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []manifest.Repository{{Git: suite.gitURL}}, got.Repositories)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlaySyncRemovesStaleFiles() {
+	suite.repoConfigDstDir[0].Mode = config.ModeSync
+	previous := &manifest.Manifest{
+		Repositories: []manifest.Repository{
+			{
+				Git:   suite.gitURL,
+				Files: []string{"/dstDir/etcd.py", "/dstDir/old.py"},
+				Dirs:  []string{"/dstDir"},
+			},
+		},
+	}
+	_ = previous.Save(suite.appFs, ".gilt/Giltfile/manifest.json")
+	_ = suite.appFs.MkdirAll("/work", 0o755)
+	_ = suite.appFs.WriteFile("/work/etcd.py", []byte("etcd"), 0o644)
+	_ = suite.appFs.MkdirAll(suite.dstDir, 0o755)
+	_ = suite.appFs.WriteFile("/dstDir/old.py", nil, 0o644)
+	_ = suite.appFs.WriteFile("/dstDir/consul.py", nil, 0o644)
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return([]internal.Target{
+		{Src: "/work", Dst: suite.dstDir, Dir: true, Mode: config.ModeSync},
+	}, nil)
+	suite.mockRepo.EXPECT().
+		CopySources(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ config.Repository, _ string, _ func(string)) error {
+			return suite.appFs.WriteFile("/dstDir/etcd.py", []byte("etcd"), 0o644)
+		})
+
+	_, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+
+	// Upstream no longer has it
+	_, err = suite.appFs.Stat("/dstDir/old.py")
+	assert.Error(suite.T(), err)
+	// gilt never wrote it
+	_, err = suite.appFs.Stat("/dstDir/consul.py")
+	assert.NoError(suite.T(), err)
+	_, err = suite.appFs.Stat("/dstDir/etcd.py")
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlaySyncLeavesOtherRepositoriesFiles() {
+	suite.repoConfigDstDir[0].Mode = config.ModeSync
+	previous := &manifest.Manifest{
+		Repositories: []manifest.Repository{
+			{Git: "https://example.com/user/other.git", Files: []string{"/dstDir/other.py"}},
+		},
+	}
+	_ = previous.Save(suite.appFs, ".gilt/Giltfile/manifest.json")
+	_ = suite.appFs.MkdirAll("/work", 0o755)
+	_ = suite.appFs.MkdirAll(suite.dstDir, 0o755)
+	_ = suite.appFs.WriteFile("/dstDir/other.py", nil, 0o644)
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return([]internal.Target{
+		{Src: "/work", Dst: suite.dstDir, Dir: true, Mode: config.ModeSync},
+	}, nil)
+	suite.mockRepo.EXPECT().CopySources(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	_, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.appFs.Stat("/dstDir/other.py")
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlaySyncLeavesFilesOfOtherEntriesSharingURL() {
+	suite.repoConfigDstDir[0].Name = "etcd"
+	suite.repoConfigDstDir[0].Mode = config.ModeSync
+	previous := &manifest.Manifest{
+		Repositories: []manifest.Repository{
+			{Name: "etcd-docs", Git: suite.gitURL, Files: []string{"/dstDir/README.md"}},
+		},
+	}
+	_ = previous.Save(suite.appFs, manifest.Path("Giltfile.yaml"))
+	_ = suite.appFs.MkdirAll("/work", 0o755)
+	_ = suite.appFs.MkdirAll(suite.dstDir, 0o755)
+	_ = suite.appFs.WriteFile("/dstDir/README.md", nil, 0o644)
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return([]internal.Target{
+		{Src: "/work", Dst: suite.dstDir, Dir: true, Mode: config.ModeSync},
+	}, nil)
+	suite.mockRepo.EXPECT().CopySources(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+	results, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "etcd", results[0].Name)
+
+	// Installed by another entry cloning the same repository
+	_, err = suite.appFs.Stat("/dstDir/README.md")
+	assert.NoError(suite.T(), err)
+	got, err := manifest.Load(suite.appFs, manifest.Path("Giltfile.yaml"))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "etcd", got.Repositories[0].Name)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestCleanOk() {
+	installed := &manifest.Manifest{
+		Repositories: []manifest.Repository{
+			{
+				Git:   suite.gitURL,
+				Files: []string{"/dstDir/subDir/1.txt", "/library/2.txt", "/missing.txt"},
+				Dirs:  []string{"/dstDir", "/dstDir/subDir", "/library"},
+			},
+		},
+	}
+	_ = suite.appFs.MkdirAll("/dstDir/subDir", 0o755)
+	_ = suite.appFs.WriteFile("/dstDir/subDir/1.txt", nil, 0o644)
+	_ = suite.appFs.MkdirAll("/library", 0o755)
+	_ = suite.appFs.WriteFile("/library/2.txt", nil, 0o644)
+	_ = suite.appFs.WriteFile("/library/mine.txt", nil, 0o644)
+	_ = installed.Save(suite.appFs, ".gilt/Giltfile/manifest.json")
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	results, err := repos.Clean()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []report.Clean{
+		{
+			Git: suite.gitURL,
+			Removed: []string{
+				"/dstDir/subDir/1.txt",
+				"/library/2.txt",
+				"/dstDir/subDir",
+				"/dstDir",
+			},
+			Left: []string{"/library"},
+		},
+	}, results)
+
+	for _, path := range []string{"/dstDir", "/library/2.txt", ".gilt/Giltfile/manifest.json", ".gilt"} {
+		_, err := suite.appFs.Stat(path)
+		assert.Error(suite.T(), err, path)
+	}
+	// Files gilt did not install are left alone, along with their directory
+	_, err = suite.appFs.Stat("/library/mine.txt")
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestCleanOkWhenManifestMissing() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	results, err := repos.Clean()
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), results)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestCleanLeavesWhatOtherGiltfilesInstalled() {
+	suite.GiltFile = "b.yaml"
+	_ = suite.appFs.MkdirAll("/library", 0o755)
+	for giltFile, path := range map[string]string{"a.yaml": "/library/a.txt", "b.yaml": "/library/b.txt"} {
+		_ = suite.appFs.WriteFile(path, nil, 0o644)
+		installed := &manifest.Manifest{
+			Repositories: []manifest.Repository{{Git: suite.gitURL, Files: []string{path}}},
+		}
+		_ = installed.Save(suite.appFs, manifest.Path(giltFile))
+	}
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	_, err := repos.Clean()
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.appFs.Stat("/library/b.txt")
+	assert.Error(suite.T(), err)
+	_, err = suite.appFs.Stat("/library/a.txt")
+	assert.NoError(suite.T(), err)
+	got, err := manifest.Load(suite.appFs, manifest.Path("a.yaml"))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"/library/a.txt"}, got.Repositories[0].Files)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestCleanReturnsErrorOnGarbageManifest() {
+	_ = suite.appFs.MkdirAll(".gilt/Giltfile", 0o755)
+	_ = suite.appFs.WriteFile(".gilt/Giltfile/manifest.json", []byte("{"), 0o644)
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	_, err := repos.Clean()
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) writeLockFile(commit string, hash string) {
+	l := &lockfile.Lockfile{
+		Repositories: []lockfile.Repository{
+			{
+				Git:     suite.gitURL,
+				Version: suite.gitVersion,
+				Commit:  commit,
+				Hash:    hash,
+			},
+		},
+	}
+	err := l.Save(suite.appFs, "Giltfile.lock")
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayLockedOk() {
+	suite.Locked = true
+	suite.writeLockFile(suite.gitVersion, suite.gitHash)
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+
+	_, err := repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayLockedReturnsErrorWhenCommitDiffers() {
+	suite.Locked = true
+	suite.writeLockFile("fedcba9", suite.gitHash)
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	// Nothing is extracted when the lock does not match
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	_, err := repos.Overlay(context.Background())
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "but the lock file has fedcba9")
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayLockedReturnsErrorWhenLockFileHasExtraEntries() {
+	suite.Locked = true
+	l := &lockfile.Lockfile{
+		Repositories: []lockfile.Repository{
+			{Git: suite.gitURL, Version: suite.gitVersion, Commit: suite.gitVersion},
+			{Git: "https://example.com/user/gone.git", Version: "v1.0", Commit: "fedcba9"},
+		},
+	}
+	_ = l.Save(suite.appFs, "Giltfile.lock")
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	_, err := repos.Overlay(context.Background())
+	assert.EqualError(
+		suite.T(),
+		err,
+		"lock file is out of date: it has 2 entries, but the Giltfile has 1",
+	)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayLockedReturnsErrorWhenLockFileMissing() {
+	suite.Locked = true
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+
+	_, err := repos.Overlay(context.Background())
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "missing from the lock file")
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOverlayLockedReturnsErrorWhenHashDiffers() {
+	suite.Locked = true
+	suite.writeLockFile(suite.gitVersion, "sha256:fedcba98")
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+
+	_, err := repos.Overlay(context.Background())
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "does not match the lock file")
+}
+
+func (suite *RepositoriesPublicTestSuite) expectTempDir() {
+	suite.mockExec.EXPECT().
+		RunInTempDir(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ string, _ string, fn func(string) error) error {
+			return fn("stub")
+		})
+}
+
+func (suite *RepositoriesPublicTestSuite) TestPlanOk() {
+	repoConfig := []config.Repository{
+		{
+			Git:     suite.gitURL,
+			Version: suite.gitVersion,
+			DstDir:  suite.dstDir,
+			PreCommands: []config.Command{
+				{Cmd: "make", Args: []string{"check"}},
+			},
+			BuildCommands: []config.Command{
+				{Cmd: "make", Args: []string{"docs"}, Dir: "docs"},
+			},
+			Commands: []config.Command{
+				{Cmd: "touch", Args: []string{"/tmp/foo"}},
+			},
+		},
+	}
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+	_ = suite.appFs.MkdirAll("/work/subDir", 0o755)
+	_ = suite.appFs.WriteFile("/work/1.txt", []byte("one"), 0o644)
+	_ = suite.appFs.WriteFile("/work/subDir/2.txt", []byte("two"), 0o644)
+	_ = suite.appFs.MkdirAll(suite.dstDir, 0o755)
+	_ = suite.appFs.WriteFile("/dstDir/1.txt", []byte("local"), 0o644)
+	_ = suite.appFs.MkdirAll("/library", 0o755)
+	_ = suite.appFs.WriteFile("/library/1.txt", []byte("local"), 0o644)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitHash, "", nil)
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return([]internal.Target{
+		{Src: "/work", Dst: suite.dstDir, Dir: true},
+		{Src: "/work/1.txt", Dst: "/library/1.txt"},
+	}, nil)
+	// Only the build commands are run, as what they produce is overlaid
+	suite.mockExec.EXPECT().
+		RunCmdWithEnv(gomock.Any(), "make", []string{"docs"}, "stub/docs", gomock.Any()).
+		Return("", nil)
+
+	got, err := repos.Plan(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []report.Plan{
+		{
+			Git:           suite.gitURL,
+			Version:       suite.gitVersion,
+			Commit:        suite.gitHash,
+			Delete:        []string{suite.dstDir},
+			Create:        []string{"/dstDir/1.txt", "/dstDir/subDir/2.txt"},
+			Overwrite:     []string{"/library/1.txt"},
+			PreCommands:   []string{"make check"},
+			BuildCommands: []string{"make docs (in docs)"},
+			Commands:      []string{"touch /tmp/foo"},
+		},
+	}, got)
+
+	// Nothing is written
+	data, err := suite.appFs.ReadFile("/dstDir/1.txt")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "local", string(data))
+	_, err = suite.appFs.Stat("Giltfile.lock")
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestPlanCreatesFilesUnderDeletedDirectory() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+	_ = suite.appFs.MkdirAll("/work", 0o755)
+	_ = suite.appFs.WriteFile("/work/1.txt", []byte("one"), 0o644)
+	_ = suite.appFs.MkdirAll("/dstDir/sub", 0o755)
+	_ = suite.appFs.WriteFile("/dstDir/1.txt", []byte("local"), 0o644)
+	_ = suite.appFs.WriteFile("/dstDir/sub/1.txt", []byte("local"), 0o644)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitHash, "", nil)
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return([]internal.Target{
+		{Src: "/work", Dst: suite.dstDir, Dir: true},
+		{Src: "/work/1.txt", Dst: "/dstDir/sub/1.txt"},
+	}, nil)
+
+	got, err := repos.Plan(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), got, 1)
+	// The files exist now, but the directory holding them is deleted first
+	assert.Equal(suite.T(), []string{suite.dstDir}, got[0].Delete)
+	assert.Equal(suite.T(), []string{"/dstDir/1.txt", "/dstDir/sub/1.txt"}, got[0].Create)
+	assert.Empty(suite.T(), got[0].Overwrite)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestPlanRecordsResolvedTag() {
+	repoConfig := []config.Repository{
+		{
+			Git:     suite.gitURL,
+			Version: "^1.1",
+			DstDir:  suite.dstDir,
+		},
+	}
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitHash, "v1.1.0", nil)
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	got, err := repos.Plan(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), got, 1)
+	assert.Equal(suite.T(), "v1.1.0", got[0].Tag)
+	assert.Equal(suite.T(), suite.gitHash, got[0].Commit)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestPlanSyncDeletesOnlyStaleFiles() {
+	suite.repoConfigDstDir[0].Mode = config.ModeSync
+	previous := &manifest.Manifest{
+		Repositories: []manifest.Repository{
+			{Git: suite.gitURL, Files: []string{"/dstDir/1.txt", "/dstDir/old.txt"}},
+		},
+	}
+	_ = previous.Save(suite.appFs, ".gilt/Giltfile/manifest.json")
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+	_ = suite.appFs.MkdirAll("/work", 0o755)
+	_ = suite.appFs.WriteFile("/work/1.txt", []byte("one"), 0o644)
+	_ = suite.appFs.MkdirAll(suite.dstDir, 0o755)
+	_ = suite.appFs.WriteFile("/dstDir/1.txt", []byte("one"), 0o644)
+	_ = suite.appFs.WriteFile("/dstDir/old.txt", nil, 0o644)
+	_ = suite.appFs.WriteFile("/dstDir/mine.txt", nil, 0o644)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitHash, "", nil)
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return([]internal.Target{
+		{Src: "/work", Dst: suite.dstDir, Dir: true, Mode: config.ModeSync},
+	}, nil)
+
+	got, err := repos.Plan(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []report.Plan{
+		{
+			Git:           suite.gitURL,
+			Version:       suite.gitVersion,
+			Commit:        suite.gitHash,
+			Delete:        []string{"/dstDir/old.txt"},
+			Create:        []string{},
+			Overwrite:     []string{"/dstDir/1.txt"},
+			PreCommands:   []string{},
+			BuildCommands: []string{},
+			Commands:      []string{},
+		},
+	}, got)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestPlanSkipsCommands() {
+	suite.SkipCommands = true
+	repoConfig := []config.Repository{
+		{
+			Git:      suite.gitURL,
+			Version:  suite.gitVersion,
+			DstDir:   suite.dstDir,
+			Commands: []config.Command{{Cmd: "touch"}},
+		},
+	}
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitHash, "", nil)
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	got, err := repos.Plan(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), got[0].Commands)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestPlanOnlySelectedRepositories() {
+	suite.Only = []string{"consul"}
+	repoConfig := suite.repoConfigNamed()
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), repoConfig[1], gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), repoConfig[1], gomock.Any()).
+		Return(suite.gitHash, "", nil)
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	got, err := repos.Plan(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), got, 1)
+	assert.Equal(suite.T(), repoConfig[1].Git, got[0].Git)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestPlanPruneListsOrphans() {
+	suite.Prune = true
+	previous := &manifest.Manifest{
+		Repositories: []manifest.Repository{
+			{
+				Git:   suite.gitURL,
+				Files: []string{"/old/stale.txt"},
+				Dirs:  []string{"/old"},
+			},
+			{
+				Git:   "https://example.com/user/gone.git",
+				Files: []string{"/library/gone.txt", "/library/renamed_manage"},
+				Dirs:  []string{"/library"},
+			},
+		},
+	}
+	_ = suite.appFs.MkdirAll("/old", 0o755)
+	_ = suite.appFs.WriteFile("/old/stale.txt", nil, 0o644)
+	_ = suite.appFs.MkdirAll("/library", 0o755)
+	_ = suite.appFs.WriteFile("/library/gone.txt", nil, 0o644)
+	_ = suite.appFs.WriteFile("/library/renamed_manage", nil, 0o644)
+	_ = suite.appFs.WriteFile("/library/mine.txt", nil, 0o644)
+	_ = previous.Save(suite.appFs, ".gilt/Giltfile/manifest.json")
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitHash, "", nil)
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	got, err := repos.Plan(context.Background())
+	assert.NoError(suite.T(), err)
+	// Directories which still hold other files are left in place
+	assert.Equal(suite.T(), []report.Plan{
+		{
+			Git:           suite.gitURL,
+			Version:       suite.gitVersion,
+			Commit:        suite.gitHash,
+			Delete:        []string{"/old/stale.txt", "/old"},
+			Create:        []string{},
+			Overwrite:     []string{},
+			PreCommands:   []string{},
+			BuildCommands: []string{},
+			Commands:      []string{},
+		},
+		{
+			Git:           "https://example.com/user/gone.git",
+			Delete:        []string{"/library/gone.txt", "/library/renamed_manage"},
+			Create:        []string{},
+			Overwrite:     []string{},
+			PreCommands:   []string{},
+			BuildCommands: []string{},
+			Commands:      []string{},
+		},
+	}, got)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestPlanPruneListsWhatOverlayDeletes() {
+	suite.Prune = true
+	previous := &manifest.Manifest{
+		Repositories: []manifest.Repository{
+			{
+				Git:   "https://example.com/user/gone.git",
+				Files: []string{"/gone/a.txt", "/gone/sub/b.txt", "/library/c.txt"},
+				Dirs:  []string{"/gone", "/gone/sub", "/library"},
+			},
+		},
+	}
+	paths := []string{"/gone/a.txt", "/gone/sub/b.txt", "/library/c.txt", "/library/mine.txt"}
+	for _, path := range paths {
+		_ = suite.appFs.MkdirAll(suite.appFs.Dir(path), 0o755)
+		_ = suite.appFs.WriteFile(path, nil, 0o644)
+	}
+	_ = previous.Save(suite.appFs, ".gilt/Giltfile/manifest.json")
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	suite.mockRepo.EXPECT().
+		Clone(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return("", "", nil).
+		Times(2)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitVersion, "", nil).
+		Times(2)
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil).
+		Times(2)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+	suite.mockRepo.EXPECT().Hash(gomock.Any(), gomock.Any()).Return(suite.gitHash, nil)
+
+	plans, err := repos.Plan(context.Background())
+	assert.NoError(suite.T(), err)
+	var planned []string
+	for _, plan := range plans {
+		planned = append(planned, plan.Delete...)
+	}
+
+	// Nothing is deleted by the dry run
+	for _, path := range append(paths, "/gone/sub", "/gone") {
+		_, err := suite.appFs.Stat(path)
+		assert.NoError(suite.T(), err, path)
+	}
+
+	repos = suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+	_, err = repos.Overlay(context.Background())
+	assert.NoError(suite.T(), err)
+
+	// Exactly what the dry run listed is deleted by the overlay
+	var deleted []string
+	for _, path := range append(paths, "/gone/sub", "/gone", "/library") {
+		if _, err := suite.appFs.Stat(path); err != nil {
+			deleted = append(deleted, path)
+		}
+	}
+	assert.ElementsMatch(suite.T(), deleted, planned)
+	assert.ElementsMatch(
+		suite.T(),
+		[]string{"/gone/a.txt", "/gone/sub/b.txt", "/library/c.txt", "/gone/sub", "/gone"},
+		planned,
+	)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestPlanReturnsErrorWhenPruningSelectedRepositories() {
+	suite.Prune = true
+	suite.Skip = []string{"etcd"}
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigNamed())
+
+	_, err := repos.Plan(context.Background())
+	assert.EqualError(suite.T(), err, "unable to prune when overlaying only some repositories")
+}
+
+func (suite *RepositoriesPublicTestSuite) TestPlanReturnsErrorWhenResolveErrors() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+	errors := errors.New("tests error")
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().Resolve(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", errors)
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	_, err := repos.Plan(context.Background())
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestPlanReturnsErrorWhenTargetsErrors() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+	errors := errors.New("tests error")
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitHash, "", nil)
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return(nil, errors)
+
+	_, err := repos.Plan(context.Background())
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestStatusOk() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+	_ = suite.appFs.MkdirAll("/work/subDir", 0o755)
+	_ = suite.appFs.WriteFile("/work/1.txt", []byte("one"), 0o644)
+	_ = suite.appFs.WriteFile("/work/2.txt", []byte("two"), 0o644)
+	_ = suite.appFs.WriteFile("/work/subDir/3.txt", []byte("three"), 0o644)
+	_ = suite.appFs.MkdirAll("/dstDir/subDir", 0o755)
+	_ = suite.appFs.WriteFile("/dstDir/1.txt", []byte("one"), 0o644)
+	_ = suite.appFs.WriteFile("/dstDir/2.txt", []byte("local"), 0o644)
+	_ = suite.appFs.WriteFile("/dstDir/4.txt", []byte("four"), 0o644)
+	_ = suite.appFs.MkdirAll("/library", 0o755)
+	_ = suite.appFs.WriteFile("/library/other.txt", []byte("other"), 0o644)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitHash, "", nil)
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return([]internal.Target{
+		{Src: "/work", Dst: suite.dstDir, Dir: true},
+		{Src: "/work/1.txt", Dst: "/library/1.txt"},
+	}, nil)
+
+	got, err := repos.Status(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []report.Status{
+		{
+			Git:      suite.gitURL,
+			Version:  suite.gitVersion,
+			Commit:   suite.gitHash,
+			Modified: []string{"/dstDir/2.txt"},
+			Deleted:  []string{"/dstDir/subDir/3.txt", "/library/1.txt"},
+			// Files in a destination file's directory are not gilt's
+			Added: []string{"/dstDir/4.txt"},
+		},
+	}, got)
+	assert.True(suite.T(), got[0].Drifted())
+}
+
+func (suite *RepositoriesPublicTestSuite) TestStatusOkWhenNoDrift() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+	_ = suite.appFs.MkdirAll("/work", 0o755)
+	_ = suite.appFs.WriteFile("/work/1.txt", []byte("one"), 0o644)
+	_ = suite.appFs.MkdirAll(suite.dstDir, 0o755)
+	_ = suite.appFs.WriteFile("/dstDir/1.txt", []byte("one"), 0o644)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitHash, "", nil)
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return([]internal.Target{
+		{Src: "/work", Dst: suite.dstDir, Dir: true},
+	}, nil)
+
+	got, err := repos.Status(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), got[0].Drifted())
+
+	data, err := json.Marshal(got[0])
+	assert.NoError(suite.T(), err)
+	assert.NotContains(suite.T(), string(data), "null")
+}
+
+func (suite *RepositoriesPublicTestSuite) TestStatusIgnoresExcludedFiles() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+	_ = suite.appFs.MkdirAll("/work/tests", 0o755)
+	_ = suite.appFs.WriteFile("/work/1.txt", []byte("one"), 0o644)
+	_ = suite.appFs.WriteFile("/work/tests/2.txt", []byte("two"), 0o644)
+	_ = suite.appFs.MkdirAll(suite.dstDir, 0o755)
+	_ = suite.appFs.WriteFile("/dstDir/1.txt", []byte("one"), 0o644)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitHash, "", nil)
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return([]internal.Target{
+		{Src: "/work", Dst: suite.dstDir, Dir: true, Exclude: []string{"tests"}},
+	}, nil)
+
+	got, err := repos.Status(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), got[0].Drifted())
+}
+
+func (suite *RepositoriesPublicTestSuite) TestStatusLaterDirReplacesEarlierTargets() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+	_ = suite.appFs.MkdirAll("/work/a", 0o755)
+	_ = suite.appFs.MkdirAll("/work/b", 0o755)
+	_ = suite.appFs.WriteFile("/work/a/1.txt", []byte("one"), 0o644)
+	_ = suite.appFs.WriteFile("/work/b/2.txt", []byte("two"), 0o644)
+	_ = suite.appFs.MkdirAll(suite.dstDir, 0o755)
+	_ = suite.appFs.WriteFile("/dstDir/2.txt", []byte("two"), 0o644)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitHash, "", nil)
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return([]internal.Target{
+		{Src: "/work/a", Dst: suite.dstDir, Dir: true},
+		{Src: "/work/b", Dst: suite.dstDir, Dir: true},
+	}, nil)
+
+	got, err := repos.Status(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), got[0].Drifted())
+}
+
+func (suite *RepositoriesPublicTestSuite) TestStatusMergeIgnoresForeignFiles() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+	_ = suite.appFs.MkdirAll("/work", 0o755)
+	_ = suite.appFs.WriteFile("/work/1.txt", []byte("one"), 0o644)
+	_ = suite.appFs.MkdirAll(suite.dstDir, 0o755)
+	_ = suite.appFs.WriteFile("/dstDir/1.txt", []byte("one"), 0o644)
+	_ = suite.appFs.WriteFile("/dstDir/mine.txt", []byte("mine"), 0o644)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitHash, "", nil)
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	suite.mockRepo.EXPECT().Targets(gomock.Any(), gomock.Any()).Return([]internal.Target{
+		{Src: "/work", Dst: suite.dstDir, Dir: true, Mode: config.ModeMerge},
+	}, nil)
+
+	got, err := repos.Status(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), got[0].Drifted())
+}
+
+func (suite *RepositoriesPublicTestSuite) TestStatusReturnsErrorWhenWorktreeErrors() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+	errors := errors.New("tests error")
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Resolve(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.gitHash, "", nil)
+	suite.expectTempDir()
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors)
+
+	_, err := repos.Status(context.Background())
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOutdatedOk() {
+	repoConfig := []config.Repository{
+		{Git: suite.gitURL, Version: "v1.1.0", DstDir: suite.dstDir},
+		{Git: suite.gitURL, Version: suite.gitVersion, DstDir: suite.dstDir},
+	}
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+	tags := []string{"v1.1.0", "v1.2.0", "v2.0.0"}
+
+	suite.mockRepo.EXPECT().
+		Clone(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.giltDir, "", nil)
+	suite.mockRepo.EXPECT().
+		Tags(gomock.Any(), gomock.Any(), suite.giltDir).
+		Return(tags, nil).
+		Times(2)
+	// Nothing is overlaid
+	suite.mockRepo.EXPECT().
+		Worktree(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+
+	got, err := repos.Outdated(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []report.Outdated{
+		{Git: suite.gitURL, Version: "v1.1.0", Latest: "v2.0.0", LatestInMajor: "v1.2.0"},
+		{Git: suite.gitURL, Version: suite.gitVersion, Latest: "v2.0.0"},
+	}, got)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOutdatedSkipsArchives() {
+	repoConfig := []config.Repository{
+		{
+			Archive: "https://example.com/user/repo-1.1.tar.gz",
+			SHA256:  "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			DstDir:  suite.dstDir,
+		},
+	}
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().
+		Clone(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.giltDir, "", nil)
+	suite.mockRepo.EXPECT().Tags(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	got, err := repos.Outdated(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), got)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOutdatedSkipsPaths() {
+	repoConfig := []config.Repository{
+		{
+			Path:   "/src/fork",
+			DstDir: suite.dstDir,
+		},
+	}
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().
+		Clone(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return("/src/fork", "", nil)
+	suite.mockRepo.EXPECT().Tags(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	got, err := repos.Outdated(context.Background())
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), got)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOutdatedReturnsErrorWhenCloneErrors() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+	errors := errors.New("tests error")
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", errors)
+
+	_, err := repos.Outdated(context.Background())
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestOutdatedReturnsErrorWhenTagsErrors() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+	errors := errors.New("tests error")
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().Tags(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors)
+
+	_, err := repos.Outdated(context.Background())
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) writeGiltFile(data string) {
+	err := suite.appFs.WriteFile("Giltfile.yaml", []byte(data), 0o644)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestUpdateOk() {
+	otherURL := "https://example.com/user/other.git"
+	repoConfig := []config.Repository{
+		{Git: suite.gitURL, Version: "v1.1.0", DstDir: suite.dstDir},
+		{Git: otherURL, Version: "^1.0", DstDir: suite.dstDir},
+	}
+	suite.writeGiltFile(`---
+repositories:
+  # Keep me.
+  - git: https://example.com/user/repo.git
+    version: v1.1.0
+    dstDir: /dstDir
+  - git: https://example.com/user/other.git
+    version: "^1.0"
+    dstDir: /dstDir
+`)
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().
+		Clone(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.giltDir, "", nil).
+		Times(2)
+	suite.mockRepo.EXPECT().
+		Tags(gomock.Any(), repoConfig[0], suite.giltDir).
+		Return([]string{"v1.1.0", "v1.2.0", "v2.0.0"}, nil)
+
+	got, err := repos.Update(context.Background(), "", nil)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []report.Update{
+		{Git: suite.gitURL, From: "v1.1.0", To: "v2.0.0"},
+	}, got)
+
+	data, err := suite.appFs.ReadFile("Giltfile.yaml")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), `---
+repositories:
+  # Keep me.
+  - git: https://example.com/user/repo.git
+    version: v2.0.0
+    dstDir: /dstDir
+  - git: https://example.com/user/other.git
+    version: "^1.0"
+    dstDir: /dstDir
+`, string(data))
+}
+
+func (suite *RepositoriesPublicTestSuite) TestUpdateOkWithConstraint() {
+	repoConfig := []config.Repository{
+		{Git: suite.gitURL, Version: "v1.1.0", DstDir: suite.dstDir},
+	}
+	suite.writeGiltFile("repositories:\n  - git: x\n    version: v1.1.0\n")
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().
+		Clone(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.giltDir, "", nil)
+	suite.mockRepo.EXPECT().
+		Tags(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return([]string{"v1.1.0", "v1.2.0", "v2.0.0"}, nil)
+
+	got, err := repos.Update(context.Background(), "^1.0", nil)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []report.Update{
+		{Git: suite.gitURL, From: "v1.1.0", To: "v1.2.0"},
+	}, got)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestUpdateOnlySelectedRepositories() {
+	otherURL := "https://example.com/user/other.git"
+	repoConfig := []config.Repository{
+		{Git: suite.gitURL, Version: "v1.1.0", DstDir: suite.dstDir},
+		{Git: otherURL, Version: "v1.1.0", DstDir: suite.dstDir},
+	}
+	suite.writeGiltFile(`repositories:
+  - git: https://example.com/user/repo.git
+    version: v1.1.0
+  - git: https://example.com/user/other.git
+    version: v1.1.0
+`)
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	// Only the selected Repository is fetched
+	suite.mockRepo.EXPECT().
+		Clone(gomock.Any(), repoConfig[1], gomock.Any()).
+		Return(suite.giltDir, "", nil)
+	suite.mockRepo.EXPECT().
+		Tags(gomock.Any(), repoConfig[1], gomock.Any()).
+		Return([]string{"v1.1.0", "v1.2.0"}, nil)
+
+	got, err := repos.Update(context.Background(), "", []string{"other"})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []report.Update{
+		{Git: otherURL, From: "v1.1.0", To: "v1.2.0"},
+	}, got)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestUpdateSkipsWhenNotSemver() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+
+	suite.mockRepo.EXPECT().
+		Clone(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.giltDir, "", nil)
+	suite.mockRepo.EXPECT().
+		Tags(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return([]string{"v1.2.0"}, nil)
+
+	// The Giltfile is not rewritten, so it need not exist
+	got, err := repos.Update(context.Background(), "", nil)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), got)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestUpdateReturnsErrorWhenRepositoryNotFound() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := repos.Update(context.Background(), "", []string{"missing"})
+	assert.EqualError(suite.T(), err, "repository missing not found in Giltfile.yaml")
+}
+
+func (suite *RepositoriesPublicTestSuite) TestUpdateReturnsErrorOnInvalidConstraint() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := repos.Update(context.Background(), "^foo", nil)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestUpdateReturnsErrorWhenTagsErrors() {
+	repos := suite.NewTestRepositoriesManager(suite.repoConfigDstDir)
+	errors := errors.New("tests error")
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().Tags(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors)
+
+	_, err := repos.Update(context.Background(), "", nil)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoriesPublicTestSuite) TestUpdateReturnsErrorWhenGiltFileMissing() {
+	repoConfig := []config.Repository{
+		{Git: suite.gitURL, Version: "v1.1.0", DstDir: suite.dstDir},
+	}
+	repos := suite.NewTestRepositoriesManager(repoConfig)
+
+	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return("", "", nil)
+	suite.mockRepo.EXPECT().
+		Tags(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return([]string{"v1.2.0"}, nil)
+
+	_, err := repos.Update(context.Background(), "", nil)
+	assert.Error(suite.T(), err)
 }
 
 // In order for `go test` to run this suite, we need to create
@@ -21,6 +21,7 @@
 package repositories
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
@@ -34,10 +35,12 @@ import (
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/mock/gomock"
 
+	"github.com/retr0h/gilt/v2/internal/journal"
 	"github.com/retr0h/gilt/v2/internal/mocks/exec"
 	"github.com/retr0h/gilt/v2/internal/mocks/repository"
 	"github.com/retr0h/gilt/v2/internal/path"
 	"github.com/retr0h/gilt/v2/pkg/config"
+	"github.com/retr0h/gilt/v2/pkg/report"
 )
 
 type RepositoriesTestSuite struct {
@@ -68,6 +71,7 @@ func (suite *RepositoriesTestSuite) NewTestRepositories(
 		reposConfig,
 		suite.mockRepo,
 		suite.mockExec,
+		nil,
 		suite.logger,
 	)
 }
@@ -132,8 +136,11 @@ func (suite *RepositoriesTestSuite) TestPopulateCloneCacheDedupesCloneCalls() {
 		{Git: suite.gitURL, Version: "v2"},
 	}
 	// .Times(1) is the default behavior, but let's be explicit
-	suite.mockRepo.EXPECT().Clone(gomock.Any(), gomock.Any()).Return(suite.giltDir, nil).Times(1)
-	err := repos.populateCloneCache(false)
+	suite.mockRepo.EXPECT().
+		Clone(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(suite.giltDir, "", nil).
+		Times(1)
+	err := repos.populateCloneCache(context.Background(), false, repos.allRepositories())
 	assert.NoError(suite.T(), err)
 }
 
@@ -151,7 +158,15 @@ func (suite *RepositoriesTestSuite) TestOverlaySubtreesGiltDirLookupError() {
 			Sources: []config.Source{{Src: "srcDir", DstDir: "dstDir"}},
 		},
 	}
-	err := repos.overlaySubtrees(repos.config.Repositories[0], suite.giltDir)
+	j := journal.New(repos.appFs, ".gilt", repos.logger)
+	_, _, err := repos.overlaySubtrees(
+		context.Background(),
+		j,
+		repos.config.Repositories[0],
+		&report.Overlay{},
+		suite.giltDir,
+		nil,
+	)
 	assert.Error(suite.T(), err)
 }
 
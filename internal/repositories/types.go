@@ -27,6 +27,7 @@ import (
 
 	"github.com/retr0h/gilt/v2/internal"
 	"github.com/retr0h/gilt/v2/pkg/config"
+	"github.com/retr0h/gilt/v2/pkg/event"
 )
 
 // Repositories perform repository operations.
@@ -37,6 +38,18 @@ type Repositories struct {
 
 	repoManager internal.RepositoryManager
 	execManager internal.ExecManager
+	observer    event.Observer
 
 	cloneCache map[string]string
+	// cloneStates how each clone in cloneCache was obtained
+	cloneStates map[string]string
+}
+
+// resolved the version of a Repository, as resolved in its clone.
+type resolved struct {
+	// commit the commit SHA the version resolved to, or the archive's
+	// checksum.
+	commit string
+	// tag the tag a semantic version constraint resolved to.
+	tag string
 }
@@ -21,12 +21,52 @@
 package internal
 
 import (
+	"context"
+
 	"github.com/retr0h/gilt/v2/pkg/config"
 )
 
 // RepositoryManager manager responsible for Repository operations.
 type RepositoryManager interface {
-	Clone(config config.Repository, cloneDir string) (string, error)
-	Worktree(config config.Repository, cloneDir string, targetDir string) error
-	CopySources(config config.Repository, cloneDir string) error
+	Clone(
+		ctx context.Context,
+		config config.Repository,
+		cloneDir string,
+	) (dir string, state string, err error)
+	Worktree(ctx context.Context, config config.Repository, cloneDir string, targetDir string) error
+	CopySources(config config.Repository, cloneDir string, copied func(dst string)) error
+	Resolve(
+		ctx context.Context,
+		config config.Repository,
+		cloneDir string,
+	) (commit string, tag string, err error)
+	Hash(config config.Repository, worktreeDir string) (string, error)
+	Tags(ctx context.Context, config config.Repository, cloneDir string) ([]string, error)
+	Targets(config config.Repository, worktreeDir string) ([]Target, error)
+}
+
+// Target maps a file, or directory, in a worktree to the destination it is
+// overlaid at.
+type Target struct {
+	// Src path of the file, or directory, in the worktree.
+	Src string
+	// Dst path the file, or directory, is overlaid at.
+	Dst string
+	// Dir whether Src is a directory, which replaces Dst wholesale unless
+	// Mode merges it.
+	Dir bool
+	// Include patterns selecting the files below a directory Src which are
+	// overlaid; all of them when empty.
+	Include []string
+	// Exclude patterns selecting the files below a directory Src which are
+	// not overlaid.
+	Exclude []string
+	// Mode how a directory Src updates Dst; config.ModeReplace when empty.
+	Mode string
+}
+
+// Merges reports whether the directory Src is copied into Dst, keeping what
+// else Dst holds, rather than replacing it.
+func (t Target) Merges() bool {
+	return t.Dir && (t.Mode == config.ModeMerge || t.Mode == config.ModeSync)
 }
@@ -25,8 +25,11 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"path"
 
 	"github.com/avfs/avfs"
+
+	"github.com/retr0h/gilt/v2/internal/glob"
 )
 
 // NewCopy factory to create a new copy instance.
@@ -112,6 +115,53 @@ func (r *Copy) CopyFile(
 func (r *Copy) CopyDir(
 	src string,
 	dst string,
+) (err error) {
+	return r.copyDir(src, dst, ".", copyOptions{})
+}
+
+// CopyDirMatching copies a directory tree like CopyDir, but only the files
+// whose path below `src` is selected by the `include` and `exclude` patterns.
+// Directories left without any files are not created.  `copied`, when not
+// nil, is called with the destination of each file as soon as it is copied.
+func (r *Copy) CopyDirMatching(
+	src string,
+	dst string,
+	include []string,
+	exclude []string,
+	copied func(dst string),
+) (err error) {
+	return r.copyDir(src, dst, ".", copyOptions{
+		include: include,
+		exclude: exclude,
+		copied:  copied,
+	})
+}
+
+// MergeDir copies a directory tree like CopyDirMatching, but into `dst` even
+// when it exists.  Files in `dst` are overwritten by the files copied over
+// them, and everything else in it is left alone.
+func (r *Copy) MergeDir(
+	src string,
+	dst string,
+	include []string,
+	exclude []string,
+	copied func(dst string),
+) (err error) {
+	return r.copyDir(src, dst, ".", copyOptions{
+		include: include,
+		exclude: exclude,
+		merge:   true,
+		copied:  copied,
+	})
+}
+
+// copyDir copies the directory `src`, which is `rel` below the directory
+// being copied, to `dst`.
+func (r *Copy) copyDir(
+	src string,
+	dst string,
+	rel string,
+	opts copyOptions,
 ) (err error) {
 	src = r.appFs.Clean(src)
 	dst = r.appFs.Clean(dst)
@@ -133,13 +183,19 @@ func (r *Copy) CopyDir(
 
 	d, err := r.appFs.Open(dst)
 	_ = d.Close()
-	if err == nil {
-		return fmt.Errorf("destination already exists")
-	}
-
-	err = r.appFs.MkdirAll(dst, si.Mode())
-	if err != nil {
-		return err
+	existed := err == nil
+	if existed {
+		if !opts.merge {
+			return fmt.Errorf("destination already exists")
+		}
+		if di, err := r.appFs.Stat(dst); err != nil || !di.IsDir() {
+			return fmt.Errorf("destination %s is not a directory", dst)
+		}
+	} else {
+		err = r.appFs.MkdirAll(dst, si.Mode())
+		if err != nil {
+			return err
+		}
 	}
 
 	entries, err := r.appFs.ReadDir(src)
@@ -147,9 +203,11 @@ func (r *Copy) CopyDir(
 		return err
 	}
 
+	filtered := len(opts.include)+len(opts.exclude) > 0
 	for _, entry := range entries {
 		srcPath := r.appFs.Join(src, entry.Name())
 		dstPath := r.appFs.Join(dst, entry.Name())
+		entryRel := path.Join(rel, entry.Name())
 
 		// Dereference any symlinks and copy their contents instead
 		target, err := r.appFs.Stat(srcPath)
@@ -158,15 +216,31 @@ func (r *Copy) CopyDir(
 		}
 
 		if target.IsDir() {
-			err = r.CopyDir(srcPath, dstPath)
+			if glob.Match(opts.exclude, entryRel) {
+				continue
+			}
+			err = r.copyDir(srcPath, dstPath, entryRel, opts)
 			if err != nil {
 				return err
 			}
 		} else {
+			if !glob.Selected(opts.include, opts.exclude, entryRel) {
+				continue
+			}
 			err = r.CopyFile(srcPath, dstPath)
 			if err != nil {
 				return err
 			}
+			if opts.copied != nil {
+				opts.copied(dstPath)
+			}
+		}
+	}
+
+	// Drop directories nothing was selected from
+	if filtered && !existed && rel != "." {
+		if entries, err := r.appFs.ReadDir(dst); err == nil && len(entries) == 0 {
+			return r.appFs.Remove(dst)
 		}
 	}
 
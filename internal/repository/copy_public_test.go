@@ -284,6 +284,193 @@ func (suite *CopyPublicTestSuite) TestCopyDirNestedOk() {
 	assert.True(suite.T(), got)
 }
 
+func (suite *CopyPublicTestSuite) TestCopyDirMatchingSkipsExcluded() {
+	cm := suite.NewTestCopyManager()
+
+	srcDir := suite.appFs.Join(suite.cloneDir, "chart")
+	specs := []FileSpec{
+		{
+			appFs:   suite.appFs,
+			srcDir:  suite.appFs.Join(srcDir, "templates"),
+			srcFile: suite.appFs.Join(srcDir, "templates", "deployment.yaml"),
+		},
+		{
+			appFs:   suite.appFs,
+			srcDir:  suite.appFs.Join(srcDir, "tests"),
+			srcFile: suite.appFs.Join(srcDir, "tests", "deployment_test.yaml"),
+		},
+		{
+			appFs:   suite.appFs,
+			srcDir:  suite.appFs.Join(srcDir, "ci"),
+			srcFile: suite.appFs.Join(srcDir, "ci", "values.yaml"),
+		},
+	}
+	createFileSpecs(specs)
+
+	err := cm.CopyDirMatching(srcDir, suite.dstDir, nil, []string{"tests", "ci"}, nil)
+	assert.NoError(suite.T(), err)
+
+	got, _ := avfs.Exists(
+		suite.appFs,
+		suite.appFs.Join(suite.dstDir, "templates", "deployment.yaml"),
+	)
+	assert.True(suite.T(), got)
+	got, _ = avfs.Exists(suite.appFs, suite.appFs.Join(suite.dstDir, "tests"))
+	assert.False(suite.T(), got)
+	got, _ = avfs.Exists(suite.appFs, suite.appFs.Join(suite.dstDir, "ci"))
+	assert.False(suite.T(), got)
+}
+
+func (suite *CopyPublicTestSuite) TestCopyDirMatchingOnlyIncluded() {
+	cm := suite.NewTestCopyManager()
+
+	srcDir := suite.appFs.Join(suite.cloneDir, "chart")
+	specs := []FileSpec{
+		{
+			appFs:  suite.appFs,
+			srcDir: suite.appFs.Join(srcDir, "templates"),
+			srcFiles: []string{
+				suite.appFs.Join(srcDir, "templates", "deployment.yaml"),
+				suite.appFs.Join(srcDir, "templates", "NOTES.txt"),
+			},
+		},
+		{
+			appFs:   suite.appFs,
+			srcDir:  suite.appFs.Join(srcDir, "docs"),
+			srcFile: suite.appFs.Join(srcDir, "docs", "README.md"),
+		},
+	}
+	createFileSpecs(specs)
+
+	err := cm.CopyDirMatching(srcDir, suite.dstDir, []string{"**/*.yaml"}, nil, nil)
+	assert.NoError(suite.T(), err)
+
+	got, _ := avfs.Exists(
+		suite.appFs,
+		suite.appFs.Join(suite.dstDir, "templates", "deployment.yaml"),
+	)
+	assert.True(suite.T(), got)
+	got, _ = avfs.Exists(suite.appFs, suite.appFs.Join(suite.dstDir, "templates", "NOTES.txt"))
+	assert.False(suite.T(), got)
+	// Directories nothing was copied into are not created
+	got, _ = avfs.Exists(suite.appFs, suite.appFs.Join(suite.dstDir, "docs"))
+	assert.False(suite.T(), got)
+}
+
+func (suite *CopyPublicTestSuite) TestCopyDirMatchingReportsEachFileAsCopied() {
+	cm := suite.NewTestCopyManager()
+
+	srcDir := suite.appFs.Join(suite.cloneDir, "chart")
+	specs := []FileSpec{
+		{
+			appFs:  suite.appFs,
+			srcDir: suite.appFs.Join(srcDir, "templates"),
+			srcFiles: []string{
+				suite.appFs.Join(srcDir, "templates", "deployment.yaml"),
+				suite.appFs.Join(srcDir, "templates", "service.yaml"),
+			},
+		},
+		{
+			appFs:   suite.appFs,
+			srcDir:  srcDir,
+			srcFile: suite.appFs.Join(srcDir, "Chart.yaml"),
+		},
+	}
+	createFileSpecs(specs)
+
+	var copied []string
+	err := cm.CopyDirMatching(srcDir, suite.dstDir, nil, nil, func(dst string) {
+		// Reported once the file is in place
+		got, _ := avfs.Exists(suite.appFs, dst)
+		assert.True(suite.T(), got, dst)
+		copied = append(copied, dst)
+	})
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), []string{
+		suite.appFs.Join(suite.dstDir, "Chart.yaml"),
+		suite.appFs.Join(suite.dstDir, "templates", "deployment.yaml"),
+		suite.appFs.Join(suite.dstDir, "templates", "service.yaml"),
+	}, copied)
+}
+
+func (suite *CopyPublicTestSuite) TestMergeDirKeepsForeignFiles() {
+	cm := suite.NewTestCopyManager()
+
+	srcDir := suite.appFs.Join(suite.cloneDir, "library")
+	specs := []FileSpec{
+		{
+			appFs:   suite.appFs,
+			srcDir:  srcDir,
+			srcFile: suite.appFs.Join(srcDir, "etcd.py"),
+		},
+		{
+			appFs:  suite.appFs,
+			srcDir: suite.dstDir,
+			srcFiles: []string{
+				suite.appFs.Join(suite.dstDir, "etcd.py"),
+				suite.appFs.Join(suite.dstDir, "consul.py"),
+			},
+		},
+	}
+	createFileSpecs(specs)
+	err := suite.appFs.WriteFile(suite.appFs.Join(srcDir, "etcd.py"), []byte("new"), 0o644)
+	assert.NoError(suite.T(), err)
+
+	err = cm.MergeDir(srcDir, suite.dstDir, nil, nil, nil)
+	assert.NoError(suite.T(), err)
+
+	got, _ := suite.appFs.ReadFile(suite.appFs.Join(suite.dstDir, "etcd.py"))
+	assert.Equal(suite.T(), "new", string(got))
+	exists, _ := avfs.Exists(suite.appFs, suite.appFs.Join(suite.dstDir, "consul.py"))
+	assert.True(suite.T(), exists)
+}
+
+func (suite *CopyPublicTestSuite) TestMergeDirKeepsExistingEmptyDirs() {
+	cm := suite.NewTestCopyManager()
+
+	srcDir := suite.appFs.Join(suite.cloneDir, "library")
+	specs := []FileSpec{
+		{
+			appFs:   suite.appFs,
+			srcDir:  suite.appFs.Join(srcDir, "docs"),
+			srcFile: suite.appFs.Join(srcDir, "docs", "README.md"),
+		},
+	}
+	createFileSpecs(specs)
+	err := suite.appFs.MkdirAll(suite.appFs.Join(suite.dstDir, "docs"), 0o755)
+	assert.NoError(suite.T(), err)
+
+	err = cm.MergeDir(srcDir, suite.dstDir, []string{"*.py"}, nil, nil)
+	assert.NoError(suite.T(), err)
+
+	got, _ := avfs.Exists(suite.appFs, suite.appFs.Join(suite.dstDir, "docs"))
+	assert.True(suite.T(), got)
+}
+
+func (suite *CopyPublicTestSuite) TestMergeDirReturnsErrorWhenDstIsNotDir() {
+	cm := suite.NewTestCopyManager()
+
+	srcDir := suite.appFs.Join(suite.cloneDir, "library")
+	specs := []FileSpec{
+		{
+			appFs:   suite.appFs,
+			srcDir:  srcDir,
+			srcFile: suite.appFs.Join(srcDir, "etcd.py"),
+		},
+		{
+			appFs:   suite.appFs,
+			srcDir:  suite.dstDir,
+			srcFile: suite.appFs.Join(suite.dstDir, "library"),
+		},
+	}
+	createFileSpecs(specs)
+
+	err := cm.MergeDir(srcDir, suite.appFs.Join(suite.dstDir, "library"), nil, nil, nil)
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "is not a directory")
+}
+
 func (suite *CopyPublicTestSuite) TestCopyDirSymlinksOk() {
 	cm := suite.NewTestCopyManager()
 
@@ -21,20 +21,35 @@
 package repository
 
 import (
+	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/avfs/avfs"
 
 	"github.com/retr0h/gilt/v2/internal"
+	"github.com/retr0h/gilt/v2/internal/glob"
+	"github.com/retr0h/gilt/v2/internal/version"
 	"github.com/retr0h/gilt/v2/pkg/config"
+	"github.com/retr0h/gilt/v2/pkg/report"
 )
 
 // ORIGIN is the name used for the git remote added by gilt.
 const ORIGIN = "gilt"
 
+// LASTUSED is the file gilt touches in a clone each time it is used, so that
+// clones which are no longer used can be pruned from the cache.  An unpacked
+// archive, all of which is copied, is marked by `<checksum>.gilt-last-used`
+// beside it instead.
+const LASTUSED = "gilt-last-used"
+
 // We'll use this to normalize Git URLs as "safe" filenames
 var replacer = strings.NewReplacer("/", "-", ":", "-")
 
@@ -43,23 +58,39 @@ func New(
 	appFs avfs.VFS,
 	copyManager CopyManager,
 	gitManager internal.GitManager,
+	archiveManager internal.ArchiveManager,
+	patchManager internal.PatchManager,
 	logger *slog.Logger,
 ) *Repository {
 	return &Repository{
-		appFs:       appFs,
-		copyManager: copyManager,
-		gitManager:  gitManager,
-		logger:      logger,
+		appFs:          appFs,
+		copyManager:    copyManager,
+		gitManager:     gitManager,
+		archiveManager: archiveManager,
+		patchManager:   patchManager,
+		logger:         logger,
 	}
 }
 
-// Clone Repository.Git under Repository.getCloneDir
+// Clone Repository.Git under Repository.getCloneDir.  A Repository.Archive is
+// instead unpacked under `cloneDir`, in a directory named by its checksum,
+// and a Repository.Path is used as it is.  Returns the directory, and how it
+// was obtained, as one of the report.Clone constants.
 func (r *Repository) Clone(
+	ctx context.Context,
 	c config.Repository,
 	cloneDir string,
-) (string, error) {
+) (string, string, error) {
+	switch {
+	case c.Archive != "":
+		return r.fetchArchive(ctx, c, cloneDir)
+	case c.Path != "":
+		dir, err := r.localPath(c)
+		return dir, report.CloneLocal, err
+	}
+
 	targetDir := r.appFs.Join(cloneDir, replacer.Replace(c.Git))
-	remote, err := r.gitManager.Remote(targetDir)
+	remote, err := r.gitManager.Remote(ctx, targetDir)
 	if err == nil && !strings.Contains(remote, ORIGIN) {
 		r.logger.Info(
 			"remote does not exist in clone, invalidating cache",
@@ -69,72 +100,460 @@ func (r *Repository) Clone(
 		_ = r.appFs.RemoveAll(targetDir)
 		err = errors.New("cache does not exist")
 	}
+	state := report.CloneUpdated
 	if err != nil {
+		state = report.CloneFresh
 		r.logger.Info("cloning", slog.String("repository", c.Git), slog.String("dstDir", targetDir))
-		if err := r.gitManager.Clone(c.Git, ORIGIN, targetDir); err != nil {
-			return targetDir, err
+		if err := r.gitManager.Clone(ctx, c.Git, ORIGIN, targetDir); err != nil {
+			return targetDir, state, err
 		}
 	} else {
 		r.logger.Info("clone already exists", slog.String("dstDir", targetDir))
-		if err := r.gitManager.Update(ORIGIN, targetDir); err != nil {
-			return targetDir, err
+		if err := r.gitManager.Update(ctx, ORIGIN, targetDir); err != nil {
+			return targetDir, state, err
 		}
 	}
-	return targetDir, nil
+	// Best effort; a missing marker only makes the clone look unused
+	_ = r.appFs.WriteFile(r.appFs.Join(targetDir, LASTUSED), nil, 0o644)
+	return targetDir, state, nil
+}
+
+// fetchArchive download and unpack Repository.Archive under `archiveDir`,
+// unless an archive with the same checksum was unpacked before.
+func (r *Repository) fetchArchive(
+	ctx context.Context,
+	c config.Repository,
+	archiveDir string,
+) (string, string, error) {
+	targetDir := r.appFs.Join(archiveDir, c.SHA256)
+	state := report.CloneCached
+	if info, err := r.appFs.Stat(targetDir); err == nil && info.IsDir() {
+		r.logger.Info("archive already exists", slog.String("dstDir", targetDir))
+	} else {
+		state = report.CloneFresh
+		r.logger.Info(
+			"fetching archive",
+			slog.String("archive", c.Archive),
+			slog.String("dstDir", targetDir),
+		)
+		if err := r.archiveManager.Fetch(ctx, c.Archive, c.SHA256, targetDir); err != nil {
+			return targetDir, state, err
+		}
+	}
+	// Best effort; a missing marker only makes the archive look unused
+	_ = r.appFs.WriteFile(targetDir+"."+LASTUSED, nil, 0o644)
+	return targetDir, state, nil
+}
+
+// localPath returns the absolute path of Repository.Path, which must be a
+// directory.
+func (r *Repository) localPath(c config.Repository) (string, error) {
+	dir, err := r.appFs.Abs(c.Path)
+	if err != nil {
+		return "", err
+	}
+	info, err := r.appFs.Stat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", c.Path)
+	}
+
+	r.logger.Info("using local path", slog.String("path", dir))
+	return dir, nil
 }
 
 // Worktree create a git workingtree at the given version in Repository.DstDir.
+// An archive's unpacked tree, or a local path, is copied instead.  Each of
+// Repository.Patches is then applied to it, in order.  Files Repository.Include
+// and Repository.Exclude leave out are removed, unless build commands have yet
+// to run in it; copying its targets filters them instead.
 func (r *Repository) Worktree(
+	ctx context.Context,
 	c config.Repository,
 	cloneDir string,
 	targetDir string,
 ) error {
-	return r.gitManager.Worktree(cloneDir, c.Version, targetDir)
+	var err error
+	switch {
+	case c.Archive != "":
+		err = r.copyManager.CopyDir(cloneDir, targetDir)
+	case c.Path != "":
+		err = r.copyPath(cloneDir, targetDir)
+	default:
+		err = r.gitManager.Worktree(ctx, cloneDir, c.Version, targetDir)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, patchFile := range c.Patches {
+		if err := r.patchManager.Apply(patchFile, targetDir); err != nil {
+			return err
+		}
+	}
+
+	if len(c.Include)+len(c.Exclude) > 0 && len(c.BuildCommands) == 0 {
+		return r.prune(targetDir, c.Include, c.Exclude)
+	}
+
+	return nil
+}
+
+// prune remove the files below `dir` which the `include` and `exclude`
+// patterns do not select, and the directories left empty.
+func (r *Repository) prune(dir string, include []string, exclude []string) error {
+	var dirs []string
+	err := r.appFs.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		rel, err := r.appFs.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = r.appFs.ToSlash(rel)
+
+		switch {
+		case d.IsDir() && glob.Match(exclude, rel):
+			if err := r.appFs.RemoveAll(path); err != nil {
+				return err
+			}
+			return fs.SkipDir
+		case d.IsDir():
+			dirs = append(dirs, path)
+		case !glob.Selected(include, exclude, rel):
+			return r.appFs.Remove(path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Deepest first, so a parent is only checked once its children are gone
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if entries, err := r.appFs.ReadDir(dirs[i]); err == nil && len(entries) == 0 {
+			if err := r.appFs.Remove(dirs[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
 }
 
-// CopySources copy Repository.Src to Repository.DstFile or Repository.DstDir.
+// copyPath copy the contents of the local path `src` to `dst`, including
+// uncommitted changes, but leaving out any `.git` directory.
+func (r *Repository) copyPath(src string, dst string) error {
+	entries, err := r.appFs.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	if err := r.appFs.MkdirAll(dst, 0o755); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.Name() == ".git" {
+			continue
+		}
+		srcPath := r.appFs.Join(src, entry.Name())
+		dstPath := r.appFs.Join(dst, entry.Name())
+		copyFn := r.copyManager.CopyFile
+		if info, err := r.appFs.Stat(srcPath); err == nil && info.IsDir() {
+			copyFn = r.copyManager.CopyDir
+		}
+		if err := copyFn(srcPath, dstPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CopySources copy Repository.Src to Repository.DstFile or Repository.DstDir,
+// or, in `DstDir` mode, the whole worktree to Repository.DstDir.  A directory
+// replaces its destination, unless its mode merges it.  `copied`, when not
+// nil, is called with the destination of each file as soon as it is copied.
 func (r *Repository) CopySources(
 	c config.Repository,
 	cloneDir string,
+	copied func(dst string),
 ) error {
 	r.logger.Debug("copy", slog.String("origin", cloneDir))
+	targets, err := r.Targets(c, cloneDir)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range targets {
+		// The source is a directory
+		if t.Dir {
+			if t.Merges() {
+				err := r.copyManager.MergeDir(t.Src, t.Dst, t.Include, t.Exclude, copied)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			// ... and dst dir exists
+			if info, err := r.appFs.Stat(t.Dst); err == nil && info.IsDir() {
+				if err := r.appFs.RemoveAll(t.Dst); err != nil {
+					return err
+				}
+			}
+			err := r.copyManager.CopyDirMatching(t.Src, t.Dst, t.Include, t.Exclude, copied)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := r.appFs.MkdirAll(r.appFs.Dir(t.Dst), 0o755); err != nil {
+			return fmt.Errorf("unable to create dest dir: %s", err)
+		}
+		if err := r.copyManager.CopyFile(t.Src, t.Dst); err != nil {
+			return err
+		}
+		if copied != nil {
+			copied(t.Dst)
+		}
+	}
+
+	return nil
+}
+
+// Targets maps what the Repository overlays from the worktree in
+// `worktreeDir` to its destinations.  In `DstDir` mode this is the files of the
+// whole worktree selected by Repository.Include and Repository.Exclude,
+// otherwise every path matched by the Repository's sources.
+func (r *Repository) Targets(
+	c config.Repository,
+	worktreeDir string,
+) ([]internal.Target, error) {
+	if c.DstDir != "" {
+		return []internal.Target{{
+			Src:     worktreeDir,
+			Dst:     c.DstDir,
+			Dir:     true,
+			Include: c.Include,
+			Exclude: c.Exclude,
+			Mode:    c.Mode,
+		}}, nil
+	}
+
+	return r.sourceTargets(c, worktreeDir)
+}
+
+// sourceTargets expand the globs of each of the Repository's sources against
+// `worktreeDir`.  A matched directory is copied to Source.DstDir, filtered by
+// the Source's include and exclude patterns, and a matched file to
+// Source.DstFile, or into Source.DstDir, unless the patterns leave out its
+// name.  With a Source.Base, matches keep their path relative to it below
+// Source.DstDir instead.
+func (r *Repository) sourceTargets(
+	c config.Repository,
+	worktreeDir string,
+) ([]internal.Target, error) {
+	var targets []internal.Target
 	for _, source := range c.Sources {
-		cloneDirWithSrcPath := r.appFs.Join(cloneDir, source.Src) // join clone dir with head
-		globbedSrc, err := r.appFs.Glob(cloneDirWithSrcPath)
+		globbedSrc, err := glob.Glob(r.appFs, worktreeDir, source.Src)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
+		// Without a base, deep matches may share a name
+		copiedFrom := make(map[string]string, len(globbedSrc))
 		for _, src := range globbedSrc {
-			// The source is a directory
-			if info, err := r.appFs.Stat(src); err == nil && info.IsDir() {
-				// ... and dst dir exists
-				if info, err := r.appFs.Stat(source.DstDir); err == nil && info.IsDir() {
-					if err := r.appFs.RemoveAll(source.DstDir); err != nil {
-						return err
-					}
+			dstDir := source.DstDir
+			if source.Base != "" {
+				if dstDir, err = r.baseDst(worktreeDir, source, src); err != nil {
+					return nil, err
 				}
-				if err := r.copyManager.CopyDir(src, source.DstDir); err != nil {
+			}
+
+			if info, err := r.appFs.Stat(src); err == nil && info.IsDir() {
+				targets = append(targets, internal.Target{
+					Src:     src,
+					Dst:     dstDir,
+					Dir:     true,
+					Include: source.Include,
+					Exclude: source.Exclude,
+					Mode:    source.Mode,
+				})
+				continue
+			}
+			if !glob.Selected(source.Include, source.Exclude, r.appFs.Base(src)) {
+				continue
+			}
+
+			var dst string
+			switch {
+			case source.DstFile != "":
+				dst = source.DstFile
+			case source.Base != "":
+				dst = dstDir
+			default:
+				dst = r.appFs.Join(dstDir, r.appFs.Base(src))
+			}
+			if other, ok := copiedFrom[dst]; ok && source.DstFile == "" {
+				return nil, fmt.Errorf(
+					"%s and %s would both be copied to %s; set base to keep their paths",
+					other, src, dst,
+				)
+			}
+			copiedFrom[dst] = src
+			targets = append(targets, internal.Target{Src: src, Dst: dst})
+		}
+	}
+
+	return targets, nil
+}
+
+// baseDst the destination of `src` below Source.DstDir, keeping its path
+// relative to Source.Base.
+func (r *Repository) baseDst(
+	worktreeDir string,
+	source config.Source,
+	src string,
+) (string, error) {
+	rel, err := r.appFs.Rel(r.appFs.Join(worktreeDir, source.Base), src)
+	if err != nil {
+		return "", err
+	}
+	if rel != "." && !filepath.IsLocal(rel) {
+		matched, _ := r.appFs.Rel(worktreeDir, src)
+		return "", fmt.Errorf("%s is not below base %s", matched, source.Base)
+	}
+
+	return r.appFs.Join(source.DstDir, rel), nil
+}
+
+// Resolve the configured version to the immutable commit SHA it points to
+// in the clone at `cloneDir`.  A semantic version constraint is first resolved
+// to the newest tag in the clone which satisfies it, which is returned as
+// `tag`; it is empty for any other version.  An archive is pinned by its
+// checksum instead, and a local path is not pinned at all.
+func (r *Repository) Resolve(
+	ctx context.Context,
+	c config.Repository,
+	cloneDir string,
+) (commit string, tag string, err error) {
+	switch {
+	case c.Archive != "":
+		return "sha256:" + c.SHA256, "", nil
+	case c.Path != "":
+		return "", "", nil
+	}
+
+	rev := c.Version
+	if version.IsConstraint(c.Version) {
+		tags, err := r.gitManager.Tags(ctx, cloneDir)
+		if err != nil {
+			return "", "", err
+		}
+		if tag, err = version.Match(tags, c.Version); err != nil {
+			return "", "", fmt.Errorf("%s: %s", c.Git, err)
+		}
+		r.logger.Info(
+			"resolved version constraint",
+			slog.String("repository", c.Git),
+			slog.String("constraint", c.Version),
+			slog.String("tag", tag),
+		)
+		rev = tag
+	}
+
+	commit, err = r.gitManager.RevParse(ctx, cloneDir, rev)
+	if err != nil {
+		return "", "", err
+	}
+
+	r.logger.Info(
+		"resolved version",
+		slog.String("repository", c.Git),
+		slog.String("version", rev),
+		slog.String("commit", commit),
+	)
+
+	return commit, tag, nil
+}
+
+// Hash computes a content hash of what the Repository overlays from the
+// worktree in `worktreeDir`.  In `DstDir` mode this is the selected files of
+// the whole worktree, otherwise it covers every path selected by the
+// Repository's sources.  The hash is the SHA-256 of a sorted
+// "<file sha256>  <relative path>" listing, so it is independent of where
+// the files end up.
+func (r *Repository) Hash(
+	c config.Repository,
+	worktreeDir string,
+) (string, error) {
+	targets := []internal.Target{
+		{Src: worktreeDir, Dir: true, Include: c.Include, Exclude: c.Exclude},
+	}
+	if len(c.Sources) > 0 {
+		var err error
+		if targets, err = r.sourceTargets(c, worktreeDir); err != nil {
+			return "", err
+		}
+	}
+
+	lines := make([]string, 0, len(targets))
+	for _, t := range targets {
+		err := r.appFs.WalkDir(t.Src, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			if t.Dir {
+				rel, err := r.appFs.Rel(t.Src, path)
+				if err != nil {
 					return err
 				}
-			} else {
-				if source.DstFile != "" {
-					if err := r.copyManager.CopyFile(src, source.DstFile); err != nil {
-						return err
-					}
-				} else if source.DstDir != "" {
-					if err := r.appFs.MkdirAll(source.DstDir, 0o755); err != nil {
-						return fmt.Errorf("unable to create dest dir: %s", err)
-					}
-					srcBaseFile := r.appFs.Base(src)
-					newDst := r.appFs.Join(source.DstDir, srcBaseFile)
-					if err := r.copyManager.CopyFile(src, newDst); err != nil {
-						return err
-					}
+				if !glob.Selected(t.Include, t.Exclude, r.appFs.ToSlash(rel)) {
+					return nil
 				}
 			}
+			data, err := r.appFs.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			rel, err := r.appFs.Rel(worktreeDir, path)
+			if err != nil {
+				return err
+			}
+			lines = append(
+				lines,
+				fmt.Sprintf("%x  %s\n", sha256.Sum256(data), r.appFs.ToSlash(rel)),
+			)
+			return nil
+		})
+		if err != nil {
+			return "", err
 		}
 	}
+	sort.Strings(lines)
 
-	return nil
+	h := sha256.New()
+	for _, line := range lines {
+		_, _ = io.WriteString(h, line)
+	}
+
+	return fmt.Sprintf("sha256:%x", h.Sum(nil)), nil
+}
+
+// Tags lists the tags available in the clone of the Repository at `cloneDir`.
+// Archives and local paths have none.
+func (r *Repository) Tags(
+	ctx context.Context,
+	c config.Repository,
+	cloneDir string,
+) ([]string, error) {
+	if c.Git == "" {
+		return nil, nil
+	}
+	r.logger.Debug("listing tags", slog.String("repository", c.Git))
+	return r.gitManager.Tags(ctx, cloneDir)
 }
@@ -21,7 +21,9 @@
 package repository_test
 
 import (
+	"context"
 	"errors"
+	"io/fs"
 	"log/slog"
 	"os"
 	"testing"
@@ -33,11 +35,15 @@ import (
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/mock/gomock"
 
+	"github.com/retr0h/gilt/v2/internal"
 	"github.com/retr0h/gilt/v2/internal/mocks"
+	"github.com/retr0h/gilt/v2/internal/mocks/archive"
 	"github.com/retr0h/gilt/v2/internal/mocks/git"
+	"github.com/retr0h/gilt/v2/internal/mocks/patch"
 	mock_repo "github.com/retr0h/gilt/v2/internal/mocks/repository"
 	"github.com/retr0h/gilt/v2/internal/repository"
 	"github.com/retr0h/gilt/v2/pkg/config"
+	"github.com/retr0h/gilt/v2/pkg/report"
 )
 
 type RepositoryPublicTestSuite struct {
@@ -45,6 +51,8 @@ type RepositoryPublicTestSuite struct {
 
 	ctrl            *gomock.Controller
 	mockGit         *git.MockGitManager
+	mockArchive     *archive.MockArchiveManager
+	mockPatch       *patch.MockPatchManager
 	mockCopyManager *mock_repo.MockCopyManager
 
 	appFs    avfs.VFS
@@ -54,6 +62,8 @@ type RepositoryPublicTestSuite struct {
 	cacheDir string
 	gitSHA   string
 	gitTag   string
+	archive  string
+	checksum string
 	logger   *slog.Logger
 }
 
@@ -62,6 +72,8 @@ func (suite *RepositoryPublicTestSuite) NewRepositoryManager() mocks.RepositoryM
 		suite.appFs,
 		suite.mockCopyManager,
 		suite.mockGit,
+		suite.mockArchive,
+		suite.mockPatch,
 		suite.logger,
 	)
 }
@@ -69,6 +81,8 @@ func (suite *RepositoryPublicTestSuite) NewRepositoryManager() mocks.RepositoryM
 func (suite *RepositoryPublicTestSuite) SetupTest() {
 	suite.ctrl = gomock.NewController(suite.T())
 	suite.mockGit = git.NewMockGitManager(suite.ctrl)
+	suite.mockArchive = archive.NewMockArchiveManager(suite.ctrl)
+	suite.mockPatch = patch.NewMockPatchManager(suite.ctrl)
 	suite.mockCopyManager = mock_repo.NewMockCopyManager(suite.ctrl)
 
 	suite.appFs = memfs.New()
@@ -78,6 +92,8 @@ func (suite *RepositoryPublicTestSuite) SetupTest() {
 	suite.cacheDir = "https---example.com-user-repo.git"
 	suite.gitSHA = "abc123"
 	suite.gitTag = "v1.1"
+	suite.archive = "https://example.com/user/repo-1.1.tar.gz"
+	suite.checksum = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
 	suite.logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
 }
 
@@ -96,11 +112,34 @@ func (suite *RepositoryPublicTestSuite) TestCloneOk() {
 
 	errors := errors.New("tests error")
 	gomock.InOrder(
-		suite.mockGit.EXPECT().Remote(targetDir).Return("", errors),
-		suite.mockGit.EXPECT().Clone(suite.gitURL, repository.ORIGIN, targetDir).Return(nil),
+		suite.mockGit.EXPECT().Remote(gomock.Any(), targetDir).Return("", errors),
+		suite.mockGit.EXPECT().
+			Clone(gomock.Any(), suite.gitURL, repository.ORIGIN, targetDir).
+			Return(nil),
 	)
 
-	_, err := repo.Clone(c, suite.cloneDir)
+	_, state, err := repo.Clone(context.Background(), c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), report.CloneFresh, state)
+}
+
+func (suite *RepositoryPublicTestSuite) TestCloneMarksCloneUsed() {
+	repo := suite.NewRepositoryManager()
+
+	c := config.Repository{
+		Git:     suite.gitURL,
+		Version: suite.gitSHA,
+	}
+	targetDir := suite.appFs.Join(suite.cloneDir, suite.cacheDir)
+	_ = suite.appFs.MkdirAll(targetDir, 0o755)
+
+	suite.mockGit.EXPECT().Remote(gomock.Any(), targetDir).Return(repository.ORIGIN, nil)
+	suite.mockGit.EXPECT().Update(gomock.Any(), repository.ORIGIN, targetDir).Return(nil)
+
+	_, _, err := repo.Clone(context.Background(), c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+
+	_, err = suite.appFs.Stat(suite.appFs.Join(targetDir, repository.LASTUSED))
 	assert.NoError(suite.T(), err)
 }
 
@@ -114,11 +153,13 @@ func (suite *RepositoryPublicTestSuite) TestCloneReturnsErrorWhenCloneErrors() {
 
 	errors := errors.New("tests error")
 	gomock.InOrder(
-		suite.mockGit.EXPECT().Remote(gomock.Any()).Return("", errors),
-		suite.mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors),
+		suite.mockGit.EXPECT().Remote(gomock.Any(), gomock.Any()).Return("", errors),
+		suite.mockGit.EXPECT().
+			Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(errors),
 	)
 
-	_, err := repo.Clone(c, suite.cloneDir)
+	_, _, err := repo.Clone(context.Background(), c, suite.cloneDir)
 	assert.Error(suite.T(), err)
 }
 
@@ -131,11 +172,12 @@ func (suite *RepositoryPublicTestSuite) TestCloneDoesNotCloneWhenCloneDirExists(
 	}
 	targetDir := suite.appFs.Join(suite.cloneDir, suite.cacheDir)
 
-	suite.mockGit.EXPECT().Remote(targetDir).Return(repository.ORIGIN, nil)
-	suite.mockGit.EXPECT().Update(repository.ORIGIN, targetDir).Return(nil)
+	suite.mockGit.EXPECT().Remote(gomock.Any(), targetDir).Return(repository.ORIGIN, nil)
+	suite.mockGit.EXPECT().Update(gomock.Any(), repository.ORIGIN, targetDir).Return(nil)
 
-	_, err := repo.Clone(c, suite.cloneDir)
+	_, state, err := repo.Clone(context.Background(), c, suite.cloneDir)
 	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), report.CloneUpdated, state)
 }
 
 func (suite *RepositoryPublicTestSuite) TestCloneInvalidatesCaches() {
@@ -148,11 +190,13 @@ func (suite *RepositoryPublicTestSuite) TestCloneInvalidatesCaches() {
 	targetDir := suite.appFs.Join(suite.cloneDir, suite.cacheDir)
 
 	gomock.InOrder(
-		suite.mockGit.EXPECT().Remote(targetDir).Return("invalid", nil),
-		suite.mockGit.EXPECT().Clone(suite.gitURL, repository.ORIGIN, targetDir).Return(nil),
+		suite.mockGit.EXPECT().Remote(gomock.Any(), targetDir).Return("invalid", nil),
+		suite.mockGit.EXPECT().
+			Clone(gomock.Any(), suite.gitURL, repository.ORIGIN, targetDir).
+			Return(nil),
 	)
 
-	_, err := repo.Clone(c, suite.cloneDir)
+	_, _, err := repo.Clone(context.Background(), c, suite.cloneDir)
 	assert.NoError(suite.T(), err)
 }
 
@@ -166,10 +210,78 @@ func (suite *RepositoryPublicTestSuite) TestCloneUpdateCloneDirThrowsError() {
 	targetDir := suite.appFs.Join(suite.cloneDir, suite.cacheDir)
 
 	errors := errors.New("tests error")
-	suite.mockGit.EXPECT().Remote(targetDir).Return(repository.ORIGIN, nil)
-	suite.mockGit.EXPECT().Update(repository.ORIGIN, targetDir).Return(errors)
+	suite.mockGit.EXPECT().Remote(gomock.Any(), targetDir).Return(repository.ORIGIN, nil)
+	suite.mockGit.EXPECT().Update(gomock.Any(), repository.ORIGIN, targetDir).Return(errors)
+
+	_, _, err := repo.Clone(context.Background(), c, suite.cloneDir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoryPublicTestSuite) TestCloneFetchesArchive() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{Archive: suite.archive, SHA256: suite.checksum}
+	targetDir := suite.appFs.Join(suite.cloneDir, suite.checksum)
+	suite.mockArchive.EXPECT().
+		Fetch(gomock.Any(), suite.archive, suite.checksum, targetDir).
+		Return(nil)
+
+	got, state, err := repo.Clone(context.Background(), c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), targetDir, got)
+	assert.Equal(suite.T(), report.CloneFresh, state)
+}
+
+func (suite *RepositoryPublicTestSuite) TestCloneDoesNotFetchArchiveWhenUnpacked() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{Archive: suite.archive, SHA256: suite.checksum}
+	targetDir := suite.appFs.Join(suite.cloneDir, suite.checksum)
+	_ = suite.appFs.MkdirAll(targetDir, 0o755)
+
+	got, state, err := repo.Clone(context.Background(), c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), targetDir, got)
+	assert.Equal(suite.T(), report.CloneCached, state)
+	_, err = suite.appFs.Stat(targetDir + "." + repository.LASTUSED)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *RepositoryPublicTestSuite) TestCloneReturnsErrorWhenFetchErrors() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{Archive: suite.archive, SHA256: suite.checksum}
+	errors := errors.New("tests error")
+	suite.mockArchive.EXPECT().
+		Fetch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors)
 
-	_, err := repo.Clone(c, suite.cloneDir)
+	_, _, err := repo.Clone(context.Background(), c, suite.cloneDir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoryPublicTestSuite) TestCloneUsesLocalPath() {
+	repo := suite.NewRepositoryManager()
+	_ = suite.appFs.MkdirAll("/src/fork", 0o755)
+	c := config.Repository{Path: "/src/fork"}
+
+	got, state, err := repo.Clone(context.Background(), c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "/src/fork", got)
+	assert.Equal(suite.T(), report.CloneLocal, state)
+}
+
+func (suite *RepositoryPublicTestSuite) TestCloneReturnsErrorWhenLocalPathIsNotDir() {
+	repo := suite.NewRepositoryManager()
+	_ = suite.appFs.WriteFile("/fork", []byte("fork"), 0o644)
+	c := config.Repository{Path: "/fork"}
+
+	_, _, err := repo.Clone(context.Background(), c, suite.cloneDir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoryPublicTestSuite) TestCloneReturnsErrorWhenLocalPathIsMissing() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{Path: "/missing"}
+
+	_, _, err := repo.Clone(context.Background(), c, suite.cloneDir)
 	assert.Error(suite.T(), err)
 }
 
@@ -196,13 +308,97 @@ func (suite *RepositoryPublicTestSuite) TestCopySourcesOkWhenSourceIsDirAndDstDi
 	}
 
 	suite.mockCopyManager.EXPECT().
-		CopyDir(suite.appFs.Join(suite.cloneDir, c.Sources[0].Src), c.Sources[0].DstDir).
+		CopyDirMatching(
+			suite.appFs.Join(suite.cloneDir, c.Sources[0].Src),
+			c.Sources[0].DstDir,
+			[]string(nil),
+			[]string(nil),
+			gomock.Any(),
+		).
 		Return(nil)
 
-	err := repo.CopySources(c, suite.cloneDir)
+	err := repo.CopySources(c, suite.cloneDir, nil)
 	assert.NoError(suite.T(), err)
 }
 
+func (suite *RepositoryPublicTestSuite) TestCopySourcesFiltersDir() {
+	repo := suite.NewRepositoryManager()
+	suite.writeFiles(suite.cloneDir, map[string]string{"chart/Chart.yaml": "chart"})
+	c := config.Repository{
+		Git:     suite.gitURL,
+		Version: suite.gitSHA,
+		Sources: []config.Source{
+			{
+				Src:     "chart",
+				DstDir:  suite.dstDir,
+				Exclude: []string{"tests", "ci"},
+			},
+		},
+	}
+
+	suite.mockCopyManager.EXPECT().
+		CopyDirMatching(
+			suite.appFs.Join(suite.cloneDir, "chart"),
+			suite.dstDir,
+			[]string(nil),
+			[]string{"tests", "ci"},
+			gomock.Any(),
+		).
+		Return(nil)
+	err := repo.CopySources(c, suite.cloneDir, nil)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *RepositoryPublicTestSuite) TestCopySourcesMergesDirIntoExistingDstDir() {
+	repo := suite.NewRepositoryManager()
+	suite.writeFiles(suite.cloneDir, map[string]string{"library/etcd.py": "etcd"})
+	suite.writeFiles(suite.dstDir, map[string]string{"consul.py": "consul"})
+	c := config.Repository{
+		Git:     suite.gitURL,
+		Version: suite.gitSHA,
+		Sources: []config.Source{
+			{
+				Src:    "library",
+				DstDir: suite.dstDir,
+				Mode:   config.ModeMerge,
+			},
+		},
+	}
+
+	suite.mockCopyManager.EXPECT().
+		MergeDir(
+			suite.appFs.Join(suite.cloneDir, "library"),
+			suite.dstDir,
+			[]string(nil),
+			[]string(nil),
+			gomock.Any(),
+		).
+		Return(nil)
+	err := repo.CopySources(c, suite.cloneDir, nil)
+	assert.NoError(suite.T(), err)
+
+	got, _ := avfs.Exists(suite.appFs, suite.appFs.Join(suite.dstDir, "consul.py"))
+	assert.True(suite.T(), got)
+}
+
+func (suite *RepositoryPublicTestSuite) TestCopySourcesReturnsErrorWhenMergeDirErrors() {
+	repo := suite.NewRepositoryManager()
+	suite.writeFiles(suite.cloneDir, map[string]string{"library/etcd.py": "etcd"})
+	c := config.Repository{
+		Git:     suite.gitURL,
+		Version: suite.gitSHA,
+		DstDir:  suite.dstDir,
+		Mode:    config.ModeSync,
+	}
+
+	errors := errors.New("tests error")
+	suite.mockCopyManager.EXPECT().
+		MergeDir(suite.cloneDir, suite.dstDir, []string(nil), []string(nil), gomock.Any()).
+		Return(errors)
+	err := repo.CopySources(c, suite.cloneDir, nil)
+	assert.Error(suite.T(), err)
+}
+
 func (suite *RepositoryPublicTestSuite) TestCopySourcesReturnsErrorWhenSourceIsDirAndDstDirDoesNotExistAndCopyDirErrors() {
 	repo := suite.NewRepositoryManager()
 	specs := []FileSpec{
@@ -226,9 +422,11 @@ func (suite *RepositoryPublicTestSuite) TestCopySourcesReturnsErrorWhenSourceIsD
 	}
 
 	errors := errors.New("tests error")
-	suite.mockCopyManager.EXPECT().CopyDir(gomock.Any(), gomock.Any()).Return(errors)
+	suite.mockCopyManager.EXPECT().
+		CopyDirMatching(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors)
 
-	err := repo.CopySources(c, suite.cloneDir)
+	err := repo.CopySources(c, suite.cloneDir, nil)
 	assert.Error(suite.T(), err)
 }
 
@@ -255,12 +453,18 @@ func (suite *RepositoryPublicTestSuite) TestCopySourcesOkWhenSourceIsDirAndDstDi
 	}
 
 	suite.mockCopyManager.EXPECT().
-		CopyDir(suite.appFs.Join(suite.cloneDir, c.Sources[0].Src), c.Sources[0].DstDir).
+		CopyDirMatching(
+			suite.appFs.Join(suite.cloneDir, c.Sources[0].Src),
+			c.Sources[0].DstDir,
+			[]string(nil),
+			[]string(nil),
+			gomock.Any(),
+		).
 		Return(nil)
 
 	// create dstDir
 	_ = suite.appFs.MkdirAll(suite.dstDir, 0o755)
-	err := repo.CopySources(c, suite.cloneDir)
+	err := repo.CopySources(c, suite.cloneDir, nil)
 	assert.NoError(suite.T(), err)
 }
 
@@ -291,12 +495,10 @@ func (suite *RepositoryPublicTestSuite) TestCopySourcesErrorWhenSourceIsDirAndDs
 	}
 
 	// We should throw an EPERM and never even make it to the CopyFile step
-	suite.mockCopyManager.EXPECT().CopyDir(gomock.Any(), gomock.Any()).Do(
-		func(_ interface{}, _ interface{}) {
-			suite.T().Fatal("CopyDir was not expected to be called")
-		},
-	).AnyTimes()
-	err := repo.CopySources(c, suite.cloneDir)
+	suite.mockCopyManager.EXPECT().
+		CopyDirMatching(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(0)
+	err := repo.CopySources(c, suite.cloneDir, nil)
 	assert.Error(suite.T(), err)
 }
 
@@ -361,8 +563,57 @@ func (suite *RepositoryPublicTestSuite) TestCopySourcesOkWhenSourceIsFilesAndDst
 		CopyFile(suite.appFs.Join(suite.cloneDir, "nova_manage"), suite.appFs.Join(suite.dstDir, "nova_manage")).
 		Return(nil)
 
-	err := repo.CopySources(c, suite.cloneDir)
+	err := repo.CopySources(c, suite.cloneDir, nil)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *RepositoryPublicTestSuite) TestCopySourcesReportsEachFileAsCopied() {
+	repo := suite.NewRepositoryManager()
+	suite.writeFiles(suite.cloneDir, map[string]string{
+		"nova_manage":   "nova",
+		"cinder_manage": "cinder",
+		"chart/a.yaml":  "a",
+	})
+	c := config.Repository{
+		Git:     suite.gitURL,
+		Version: suite.gitSHA,
+		Sources: []config.Source{
+			{
+				Src:    "*_manage",
+				DstDir: suite.dstDir,
+			},
+			{
+				Src:    "chart",
+				DstDir: "/chart",
+			},
+		},
+	}
+
+	var copied []string
+	gomock.InOrder(
+		suite.mockCopyManager.EXPECT().
+			CopyFile(gomock.Any(), suite.appFs.Join(suite.dstDir, "cinder_manage")).
+			Return(nil),
+		suite.mockCopyManager.EXPECT().
+			CopyFile(gomock.Any(), suite.appFs.Join(suite.dstDir, "nova_manage")).
+			Return(nil),
+		suite.mockCopyManager.EXPECT().
+			CopyDirMatching(gomock.Any(), "/chart", gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_, _ string, _, _ []string, copied func(string)) error {
+				copied("/chart/a.yaml")
+				return nil
+			}),
+	)
+	err := repo.CopySources(c, suite.cloneDir, func(dst string) {
+		copied = append(copied, dst)
+	})
 	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), []string{
+		suite.appFs.Join(suite.dstDir, "cinder_manage"),
+		suite.appFs.Join(suite.dstDir, "nova_manage"),
+		"/chart/a.yaml",
+	}, copied)
 }
 
 func (suite *RepositoryPublicTestSuite) TestCopySourcesReturnsErrorWhenSourceIsFilesAndDstDir() {
@@ -397,7 +648,7 @@ func (suite *RepositoryPublicTestSuite) TestCopySourcesReturnsErrorWhenSourceIsF
 			suite.T().Fatal("CopyFile was not expected to be called")
 		},
 	).AnyTimes()
-	err := repo.CopySources(c, suite.cloneDir)
+	err := repo.CopySources(c, suite.cloneDir, nil)
 	assert.Error(suite.T(), err)
 }
 
@@ -428,7 +679,7 @@ func (suite *RepositoryPublicTestSuite) TestCopySourcesReturnsErrorWhenSourceIsF
 	errors := errors.New("tests error")
 	suite.mockCopyManager.EXPECT().CopyFile(gomock.Any(), gomock.Any()).Return(errors)
 
-	err := repo.CopySources(c, suite.cloneDir)
+	err := repo.CopySources(c, suite.cloneDir, nil)
 	assert.Error(suite.T(), err)
 }
 
@@ -458,7 +709,7 @@ func (suite *RepositoryPublicTestSuite) TestCopySourcesOkWhenSourceIsFileAndDstF
 		CopyFile(suite.appFs.Join(suite.cloneDir, "1.txt"), suite.appFs.Join(suite.dstDir, "1.txt")).
 		Return(nil)
 
-	err := repo.CopySources(c, suite.cloneDir)
+	err := repo.CopySources(c, suite.cloneDir, nil)
 	assert.NoError(suite.T(), err)
 }
 
@@ -487,7 +738,7 @@ func (suite *RepositoryPublicTestSuite) TestCopySourcesReturnsErrorWhenSourceIsF
 	errors := errors.New("tests error")
 	suite.mockCopyManager.EXPECT().CopyFile(gomock.Any(), gomock.Any()).Return(errors)
 
-	err := repo.CopySources(c, suite.cloneDir)
+	err := repo.CopySources(c, suite.cloneDir, nil)
 	assert.Error(suite.T(), err)
 }
 
@@ -521,7 +772,7 @@ func (suite *RepositoryPublicTestSuite) TestCopySourcesReturnsErrorOnGarbagePatt
 			suite.T().Fatal("CopyFile was not expected to be called")
 		},
 	).AnyTimes()
-	err := repo.CopySources(c, suite.cloneDir)
+	err := repo.CopySources(c, suite.cloneDir, nil)
 	assert.Error(suite.T(), err)
 }
 
@@ -530,9 +781,11 @@ func (suite *RepositoryPublicTestSuite) TestWorktreeTagOk() {
 	c := config.Repository{
 		Version: suite.gitTag,
 	}
-	suite.mockGit.EXPECT().Worktree(suite.cloneDir, c.Version, suite.dstDir).Return(nil)
+	suite.mockGit.EXPECT().
+		Worktree(gomock.Any(), suite.cloneDir, c.Version, suite.dstDir).
+		Return(nil)
 
-	err := repo.Worktree(c, suite.cloneDir, suite.dstDir)
+	err := repo.Worktree(context.Background(), c, suite.cloneDir, suite.dstDir)
 	assert.NoError(suite.T(), err)
 }
 
@@ -542,10 +795,525 @@ func (suite *RepositoryPublicTestSuite) TestWorktreeSHAOk() {
 	c := config.Repository{
 		Version: suite.gitSHA,
 	}
-	suite.mockGit.EXPECT().Worktree(suite.cloneDir, c.Version, suite.dstDir).Return(nil)
+	suite.mockGit.EXPECT().
+		Worktree(gomock.Any(), suite.cloneDir, c.Version, suite.dstDir).
+		Return(nil)
+
+	err := repo.Worktree(context.Background(), c, suite.cloneDir, suite.dstDir)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *RepositoryPublicTestSuite) TestWorktreeAppliesPatchesInOrder() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{
+		Git:     suite.gitURL,
+		Version: suite.gitSHA,
+		Patches: []string{"first.patch", "second.patch"},
+	}
+	gomock.InOrder(
+		suite.mockGit.EXPECT().
+			Worktree(gomock.Any(), suite.cloneDir, suite.gitSHA, suite.dstDir).
+			Return(nil),
+		suite.mockPatch.EXPECT().Apply("first.patch", suite.dstDir).Return(nil),
+		suite.mockPatch.EXPECT().Apply("second.patch", suite.dstDir).Return(nil),
+	)
+
+	err := repo.Worktree(context.Background(), c, suite.cloneDir, suite.dstDir)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *RepositoryPublicTestSuite) TestWorktreeReturnsErrorWhenPatchErrors() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{
+		Git:     suite.gitURL,
+		Version: suite.gitSHA,
+		Patches: []string{"first.patch", "second.patch"},
+	}
+	errors := errors.New("tests error")
+	suite.mockGit.EXPECT().
+		Worktree(gomock.Any(), suite.cloneDir, suite.gitSHA, suite.dstDir).
+		Return(nil)
+	suite.mockPatch.EXPECT().Apply("first.patch", suite.dstDir).Return(errors)
+
+	err := repo.Worktree(context.Background(), c, suite.cloneDir, suite.dstDir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoryPublicTestSuite) TestWorktreeDoesNotPatchWhenWorktreeErrors() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{
+		Git:     suite.gitURL,
+		Version: suite.gitSHA,
+		Patches: []string{"first.patch"},
+	}
+	errors := errors.New("tests error")
+	suite.mockGit.EXPECT().
+		Worktree(gomock.Any(), suite.cloneDir, suite.gitSHA, suite.dstDir).
+		Return(errors)
+	suite.mockPatch.EXPECT().Apply(gomock.Any(), gomock.Any()).Times(0)
+
+	err := repo.Worktree(context.Background(), c, suite.cloneDir, suite.dstDir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoryPublicTestSuite) TestWorktreePrunesExcluded() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{
+		Git:     suite.gitURL,
+		Version: suite.gitSHA,
+		DstDir:  suite.dstDir,
+		Include: []string{"roles/**", "README.md"},
+		Exclude: []string{".github", "**/*_test.yml"},
+	}
+	suite.mockGit.EXPECT().
+		Worktree(gomock.Any(), suite.cloneDir, suite.gitSHA, suite.dstDir).
+		DoAndReturn(func(_ context.Context, _, _, dst string) error {
+			suite.writeFiles(dst, map[string]string{
+				".github/workflows/ci.yml": "ci",
+				"README.md":                "readme",
+				"roles/x/main.yml":         "main",
+				"roles/x/main_test.yml":    "test",
+				"docs/index.md":            "docs",
+			})
+			return nil
+		})
+
+	err := repo.Worktree(context.Background(), c, suite.cloneDir, suite.dstDir)
+	assert.NoError(suite.T(), err)
+
+	var got []string
+	_ = suite.appFs.WalkDir(suite.dstDir, func(path string, _ fs.DirEntry, _ error) error {
+		got = append(got, path)
+		return nil
+	})
+	assert.Equal(suite.T(), []string{
+		"/dstDir",
+		"/dstDir/README.md",
+		"/dstDir/roles",
+		"/dstDir/roles/x",
+		"/dstDir/roles/x/main.yml",
+	}, got)
+}
+
+func (suite *RepositoryPublicTestSuite) TestWorktreeDoesNotPruneBeforeBuildCommands() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{
+		Git:           suite.gitURL,
+		Version:       suite.gitSHA,
+		DstDir:        suite.dstDir,
+		Include:       []string{"_build/**"},
+		BuildCommands: []config.Command{{Cmd: "make"}},
+	}
+	suite.mockGit.EXPECT().
+		Worktree(gomock.Any(), suite.cloneDir, suite.gitSHA, suite.dstDir).
+		DoAndReturn(func(_ context.Context, _, _, dst string) error {
+			suite.writeFiles(dst, map[string]string{"Makefile": "all:"})
+			return nil
+		})
+
+	err := repo.Worktree(context.Background(), c, suite.cloneDir, suite.dstDir)
+	assert.NoError(suite.T(), err)
+
+	// The build still needs its inputs; copying the targets filters them
+	_, err = suite.appFs.Stat("/dstDir/Makefile")
+	assert.NoError(suite.T(), err)
+	got, err := repo.Targets(c, suite.dstDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"_build/**"}, got[0].Include)
+}
+
+func (suite *RepositoryPublicTestSuite) TestWorktreeArchiveOk() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{Archive: suite.archive, SHA256: suite.checksum}
+	suite.mockCopyManager.EXPECT().CopyDir(suite.cloneDir, suite.dstDir).Return(nil)
+
+	err := repo.Worktree(context.Background(), c, suite.cloneDir, suite.dstDir)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *RepositoryPublicTestSuite) TestWorktreeLocalPathSkipsGitDir() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{Path: "/src/fork"}
+	suite.writeFiles("/src/fork", map[string]string{
+		".git/HEAD":  "ref: refs/heads/main",
+		"README.md":  "fork",
+		"lib/foo.py": "foo",
+	})
+	suite.mockCopyManager.EXPECT().
+		CopyFile("/src/fork/README.md", suite.appFs.Join(suite.dstDir, "README.md")).
+		Return(nil)
+	suite.mockCopyManager.EXPECT().
+		CopyDir("/src/fork/lib", suite.appFs.Join(suite.dstDir, "lib")).
+		Return(nil)
+
+	err := repo.Worktree(context.Background(), c, "/src/fork", suite.dstDir)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *RepositoryPublicTestSuite) TestWorktreeLocalPathReturnsErrorWhenCopyErrors() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{Path: "/src/fork"}
+	suite.writeFiles("/src/fork", map[string]string{"README.md": "fork"})
+	errors := errors.New("tests error")
+	suite.mockCopyManager.EXPECT().CopyFile(gomock.Any(), gomock.Any()).Return(errors)
+
+	err := repo.Worktree(context.Background(), c, "/src/fork", suite.dstDir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoryPublicTestSuite) TestResolveArchiveOk() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{Archive: suite.archive, SHA256: suite.checksum}
+
+	got, tag, err := repo.Resolve(context.Background(), c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "sha256:"+suite.checksum, got)
+	assert.Empty(suite.T(), tag)
+}
+
+func (suite *RepositoryPublicTestSuite) TestResolveLocalPathIsNotPinned() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{Path: "/src/fork"}
+
+	got, tag, err := repo.Resolve(context.Background(), c, "/src/fork")
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), got)
+	assert.Empty(suite.T(), tag)
+}
+
+func (suite *RepositoryPublicTestSuite) TestResolveOk() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{
+		Git:     suite.gitURL,
+		Version: suite.gitTag,
+	}
+	suite.mockGit.EXPECT().
+		RevParse(gomock.Any(), suite.cloneDir, suite.gitTag).
+		Return(suite.gitSHA, nil)
+
+	got, tag, err := repo.Resolve(context.Background(), c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.gitSHA, got)
+	assert.Empty(suite.T(), tag)
+}
+
+func (suite *RepositoryPublicTestSuite) TestResolveReturnsErrorWhenRevParseErrors() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{
+		Git:     suite.gitURL,
+		Version: suite.gitTag,
+	}
+	errors := errors.New("tests error")
+	suite.mockGit.EXPECT().RevParse(gomock.Any(), gomock.Any(), gomock.Any()).Return("", errors)
+
+	_, _, err := repo.Resolve(context.Background(), c, suite.cloneDir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoryPublicTestSuite) TestResolveConstraintOk() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{
+		Git:     suite.gitURL,
+		Version: "^1.1",
+	}
+	gomock.InOrder(
+		suite.mockGit.EXPECT().
+			Tags(gomock.Any(), suite.cloneDir).
+			Return([]string{"v1.0", suite.gitTag, "v1.2.0-rc.1", "v2.0"}, nil),
+		suite.mockGit.EXPECT().
+			RevParse(gomock.Any(), suite.cloneDir, suite.gitTag).
+			Return(suite.gitSHA, nil),
+	)
+
+	got, tag, err := repo.Resolve(context.Background(), c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.gitSHA, got)
+	assert.Equal(suite.T(), suite.gitTag, tag)
+}
+
+func (suite *RepositoryPublicTestSuite) TestResolveConstraintReturnsErrorWhenNothingMatches() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{
+		Git:     suite.gitURL,
+		Version: "^3",
+	}
+	suite.mockGit.EXPECT().Tags(gomock.Any(), suite.cloneDir).Return([]string{suite.gitTag}, nil)
+	suite.mockGit.EXPECT().RevParse(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, _, err := repo.Resolve(context.Background(), c, suite.cloneDir)
+	assert.EqualError(
+		suite.T(),
+		err,
+		suite.gitURL+": no tag satisfies version constraint ^3",
+	)
+}
+
+func (suite *RepositoryPublicTestSuite) TestResolveConstraintReturnsErrorWhenTagsErrors() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{
+		Git:     suite.gitURL,
+		Version: "^1.1",
+	}
+	errors := errors.New("tests error")
+	suite.mockGit.EXPECT().Tags(gomock.Any(), suite.cloneDir).Return(nil, errors)
+
+	_, _, err := repo.Resolve(context.Background(), c, suite.cloneDir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoryPublicTestSuite) writeFiles(dir string, files map[string]string) {
+	for name, content := range files {
+		path := suite.appFs.Join(dir, name)
+		_ = suite.appFs.MkdirAll(suite.appFs.Dir(path), 0o755)
+		_ = suite.appFs.WriteFile(path, []byte(content), 0o644)
+	}
+}
+
+func (suite *RepositoryPublicTestSuite) TestHashIsIndependentOfLocation() {
+	repo := suite.NewRepositoryManager()
+	files := map[string]string{
+		"1.txt":        "one",
+		"subDir/2.txt": "two",
+	}
+	suite.writeFiles(suite.cloneDir, files)
+	suite.writeFiles(suite.dstDir, files)
+	c := config.Repository{DstDir: suite.dstDir}
+
+	got, err := repo.Hash(c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.Regexp(suite.T(), "^sha256:[0-9a-f]{64}$", got)
+
+	other, err := repo.Hash(c, suite.dstDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), got, other)
+}
+
+func (suite *RepositoryPublicTestSuite) TestHashChangesWithContent() {
+	repo := suite.NewRepositoryManager()
+	suite.writeFiles(suite.cloneDir, map[string]string{"1.txt": "one"})
+	c := config.Repository{DstDir: suite.dstDir}
+
+	before, err := repo.Hash(c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+
+	suite.writeFiles(suite.cloneDir, map[string]string{"1.txt": "uno"})
+	after, err := repo.Hash(c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.NotEqual(suite.T(), before, after)
+}
+
+func (suite *RepositoryPublicTestSuite) TestHashOnlyCoversSources() {
+	repo := suite.NewRepositoryManager()
+	suite.writeFiles(suite.cloneDir, map[string]string{
+		"cinder_manage":   "cinder",
+		"subDir/1.txt":    "one",
+		"unrelated/2.txt": "two",
+	})
+	c := config.Repository{
+		Sources: []config.Source{
+			{Src: "*_manage", DstDir: suite.dstDir},
+			{Src: "subDir", DstDir: suite.dstDir},
+		},
+	}
+
+	before, err := repo.Hash(c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+
+	// Files outside of the sources do not contribute to the hash
+	suite.writeFiles(suite.cloneDir, map[string]string{"unrelated/2.txt": "dos"})
+	after, err := repo.Hash(c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), before, after)
+}
+
+func (suite *RepositoryPublicTestSuite) TestHashSkipsExcluded() {
+	repo := suite.NewRepositoryManager()
+	suite.writeFiles(suite.cloneDir, map[string]string{
+		"chart/Chart.yaml":   "chart",
+		"chart/tests/a.yaml": "test",
+	})
+	c := config.Repository{
+		Sources: []config.Source{
+			{Src: "chart", DstDir: suite.dstDir, Exclude: []string{"tests"}},
+		},
+	}
+
+	before, err := repo.Hash(c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+
+	// Excluded files do not contribute to the hash
+	suite.writeFiles(suite.cloneDir, map[string]string{"chart/tests/a.yaml": "changed"})
+	after, err := repo.Hash(c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), before, after)
+}
+
+func (suite *RepositoryPublicTestSuite) TestHashReturnsErrorOnGarbagePatterns() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{
+		Sources: []config.Source{
+			{Src: "[", DstDir: suite.dstDir},
+		},
+	}
+
+	_, err := repo.Hash(c, suite.cloneDir)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *RepositoryPublicTestSuite) TestTagsOk() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{Git: suite.gitURL}
+	suite.mockGit.EXPECT().Tags(gomock.Any(), suite.cloneDir).Return([]string{suite.gitTag}, nil)
+
+	got, err := repo.Tags(context.Background(), c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{suite.gitTag}, got)
+}
+
+func (suite *RepositoryPublicTestSuite) TestTagsArchiveHasNone() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{Archive: suite.archive, SHA256: suite.checksum}
 
-	err := repo.Worktree(c, suite.cloneDir, suite.dstDir)
+	got, err := repo.Tags(context.Background(), c, suite.cloneDir)
 	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), got)
+}
+
+func (suite *RepositoryPublicTestSuite) TestTargetsWhenDstDir() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{DstDir: suite.dstDir}
+
+	got, err := repo.Targets(c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []internal.Target{
+		{Src: suite.cloneDir, Dst: suite.dstDir, Dir: true},
+	}, got)
+}
+
+func (suite *RepositoryPublicTestSuite) TestTargetsWhenDstDirMerged() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{DstDir: suite.dstDir, Mode: config.ModeMerge}
+
+	got, err := repo.Targets(c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []internal.Target{
+		{Src: suite.cloneDir, Dst: suite.dstDir, Dir: true, Mode: config.ModeMerge},
+	}, got)
+	assert.True(suite.T(), got[0].Merges())
+}
+
+func (suite *RepositoryPublicTestSuite) TestTargetsWhenSources() {
+	repo := suite.NewRepositoryManager()
+	suite.writeFiles(suite.cloneDir, map[string]string{
+		"cinder_manage":  "cinder",
+		"nova_manage":    "nova",
+		"neutron_router": "router",
+		"subDir/1.txt":   "one",
+	})
+	c := config.Repository{
+		Sources: []config.Source{
+			{Src: "*_manage", DstDir: "library"},
+			{Src: "neutron_router", DstFile: "library/neutron_router.py"},
+			{Src: "subDir", DstDir: "tests"},
+			{Src: "missing", DstDir: "tests"},
+		},
+	}
+
+	got, err := repo.Targets(c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []internal.Target{
+		{Src: "/cloneDir/cinder_manage", Dst: "library/cinder_manage"},
+		{Src: "/cloneDir/nova_manage", Dst: "library/nova_manage"},
+		{Src: "/cloneDir/neutron_router", Dst: "library/neutron_router.py"},
+		{Src: "/cloneDir/subDir", Dst: "tests", Dir: true},
+	}, got)
+}
+
+func (suite *RepositoryPublicTestSuite) TestTargetsWhenSourcesRecursive() {
+	repo := suite.NewRepositoryManager()
+	suite.writeFiles(suite.cloneDir, map[string]string{
+		"roles/a/defaults/main.yml": "a",
+		"roles/b/defaults/main.yml": "b",
+		"roles/b/tasks/main.yml":    "tasks",
+	})
+	c := config.Repository{
+		Sources: []config.Source{
+			{Src: "roles/**/defaults/*.yml", DstDir: "vars", Base: "roles"},
+			{Src: "roles/**/tasks", DstDir: "tasks", Base: "roles"},
+		},
+	}
+
+	got, err := repo.Targets(c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []internal.Target{
+		{Src: "/cloneDir/roles/a/defaults/main.yml", Dst: "vars/a/defaults/main.yml"},
+		{Src: "/cloneDir/roles/b/defaults/main.yml", Dst: "vars/b/defaults/main.yml"},
+		{Src: "/cloneDir/roles/b/tasks", Dst: "tasks/b/tasks", Dir: true},
+	}, got)
+}
+
+func (suite *RepositoryPublicTestSuite) TestTargetsReturnsErrorWhenMatchesCollide() {
+	repo := suite.NewRepositoryManager()
+	suite.writeFiles(suite.cloneDir, map[string]string{
+		"roles/a/defaults/main.yml": "a",
+		"roles/b/defaults/main.yml": "b",
+	})
+	c := config.Repository{
+		Sources: []config.Source{
+			{Src: "roles/**/defaults/*.yml", DstDir: "vars"},
+		},
+	}
+
+	_, err := repo.Targets(c, suite.cloneDir)
+	assert.ErrorContains(suite.T(), err, "set base to keep their paths")
+}
+
+func (suite *RepositoryPublicTestSuite) TestTargetsReturnsErrorWhenMatchIsNotBelowBase() {
+	repo := suite.NewRepositoryManager()
+	suite.writeFiles(suite.cloneDir, map[string]string{"roles/a/defaults/main.yml": "a"})
+	c := config.Repository{
+		Sources: []config.Source{
+			{Src: "roles/**/*.yml", DstDir: "vars", Base: "library"},
+		},
+	}
+
+	_, err := repo.Targets(c, suite.cloneDir)
+	assert.ErrorContains(suite.T(), err, "is not below base library")
+}
+
+func (suite *RepositoryPublicTestSuite) TestTargetsWhenSourcesFiltered() {
+	repo := suite.NewRepositoryManager()
+	suite.writeFiles(suite.cloneDir, map[string]string{
+		"cinder_manage":      "cinder",
+		"nova_manage":        "nova",
+		"chart/Chart.yaml":   "chart",
+		"chart/tests/a.yaml": "test",
+	})
+	c := config.Repository{
+		Sources: []config.Source{
+			{Src: "*_manage", DstDir: "library", Exclude: []string{"nova_*"}},
+			{Src: "chart", DstDir: "charts/app", Exclude: []string{"tests"}},
+		},
+	}
+
+	got, err := repo.Targets(c, suite.cloneDir)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []internal.Target{
+		{Src: "/cloneDir/cinder_manage", Dst: "library/cinder_manage"},
+		{Src: "/cloneDir/chart", Dst: "charts/app", Dir: true, Exclude: []string{"tests"}},
+	}, got)
+}
+
+func (suite *RepositoryPublicTestSuite) TestTargetsReturnsErrorOnGarbagePatterns() {
+	repo := suite.NewRepositoryManager()
+	c := config.Repository{
+		Sources: []config.Source{
+			{Src: "[", DstDir: suite.dstDir},
+		},
+	}
+
+	_, err := repo.Targets(c, suite.cloneDir)
+	assert.Error(suite.T(), err)
 }
 
 // In order for `go test` to run this suite, we need to create
@@ -30,15 +30,31 @@ import (
 
 // Repository contains the repository's details for cloning.
 type Repository struct {
-	appFs       avfs.VFS
-	copyManager CopyManager
-	gitManager  internal.GitManager
-	logger      *slog.Logger
+	appFs          avfs.VFS
+	copyManager    CopyManager
+	gitManager     internal.GitManager
+	archiveManager internal.ArchiveManager
+	patchManager   internal.PatchManager
+	logger         *slog.Logger
 }
 
 // CopyManager manager responsible for Copy operations.
 type CopyManager interface {
 	CopyDir(src string, dst string) error
+	CopyDirMatching(
+		src string,
+		dst string,
+		include []string,
+		exclude []string,
+		copied func(dst string),
+	) error
+	MergeDir(
+		src string,
+		dst string,
+		include []string,
+		exclude []string,
+		copied func(dst string),
+	) error
 	CopyFile(src string, dst string) error
 }
 
@@ -47,3 +63,12 @@ type Copy struct {
 	appFs  avfs.VFS
 	logger *slog.Logger
 }
+
+// copyOptions how Copy selects, and writes, the files of a directory tree.
+type copyOptions struct {
+	include []string
+	exclude []string
+	merge   bool
+	// copied called with the destination of each file once it is copied.
+	copied func(dst string)
+}
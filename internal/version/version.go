@@ -0,0 +1,135 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+// Package version selects Git tags by semantic version.
+package version
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// constraintChars characters which cannot appear in a Git ref name, or which
+// only appear at the start of a semantic version constraint.
+const constraintChars = "^~<>=!, |"
+
+// IsConstraint reports whether `v` is a semantic version constraint (e.g.
+// "^1.2", "~2.0.3", or ">=1.0 <2.0") rather than a commit-ish.
+func IsConstraint(v string) bool {
+	return strings.ContainsAny(v, constraintChars)
+}
+
+// Validate reports whether `constraint` is a well formed constraint.
+func Validate(constraint string) error {
+	_, err := semver.NewConstraint(constraint)
+	return err
+}
+
+// stable parse `tags` as semantic versions, newest first.  Tags which are not
+// semantic versions, and pre-releases, are skipped.
+func stable(tags []string) []*semver.Version {
+	versions := make([]*semver.Version, 0, len(tags))
+	for _, tag := range tags {
+		v, err := semver.NewVersion(tag)
+		if err != nil || v.Prerelease() != "" {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	sort.Sort(sort.Reverse(semver.Collection(versions)))
+
+	return versions
+}
+
+// Latest returns the newest stable tag, or an empty string when none of the
+// tags are semantic versions.
+func Latest(tags []string) string {
+	versions := stable(tags)
+	if len(versions) == 0 {
+		return ""
+	}
+
+	return versions[0].Original()
+}
+
+// LatestInMajor returns the newest stable tag sharing the major version of
+// `current`, or an empty string when `current` is not a semantic version.
+func LatestInMajor(tags []string, current string) string {
+	cv, err := semver.NewVersion(current)
+	if err != nil {
+		return ""
+	}
+
+	for _, v := range stable(tags) {
+		if v.Major() == cv.Major() {
+			return v.Original()
+		}
+	}
+
+	return ""
+}
+
+// Match returns the newest stable tag satisfying `constraint`.
+func Match(tags []string, constraint string) (string, error) {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return "", err
+	}
+
+	for _, v := range stable(tags) {
+		if c.Check(v) {
+			return v.Original(), nil
+		}
+	}
+
+	return "", fmt.Errorf("no tag satisfies version constraint %s", constraint)
+}
+
+// Upgrade returns the newest stable tag newer than `current`, restricted to
+// tags satisfying `constraint` when one is given.  An empty string is returned
+// when `current` is already the newest.
+func Upgrade(tags []string, current, constraint string) (string, error) {
+	cv, err := semver.NewVersion(current)
+	if err != nil {
+		return "", fmt.Errorf("%s is not a semantic version", current)
+	}
+
+	var c *semver.Constraints
+	if constraint != "" {
+		if c, err = semver.NewConstraint(constraint); err != nil {
+			return "", err
+		}
+	}
+
+	for _, v := range stable(tags) {
+		if c != nil && !c.Check(v) {
+			continue
+		}
+		if v.GreaterThan(cv) {
+			return v.Original(), nil
+		}
+		break
+	}
+
+	return "", nil
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package version_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/retr0h/gilt/v2/internal/version"
+)
+
+type VersionPublicTestSuite struct {
+	suite.Suite
+
+	tags []string
+}
+
+func (suite *VersionPublicTestSuite) SetupTest() {
+	suite.tags = []string{
+		"1.0",
+		"v1.2.0",
+		"v1.10.1",
+		"v2.0.0",
+		"v2.1.0",
+		"v3.0.0-rc.1",
+		"latest",
+	}
+}
+
+func (suite *VersionPublicTestSuite) TestLatest() {
+	assert.Equal(suite.T(), "v2.1.0", version.Latest(suite.tags))
+	assert.Equal(suite.T(), "", version.Latest([]string{"latest", "stable"}))
+	assert.Equal(suite.T(), "", version.Latest(nil))
+}
+
+func (suite *VersionPublicTestSuite) TestLatestInMajor() {
+	tests := []struct {
+		current  string
+		expected string
+	}{
+		{"1.0", "v1.10.1"},
+		{"v1.2.0", "v1.10.1"},
+		{"v2.0.0", "v2.1.0"},
+		{"v4.0.0", ""},
+		{"abc1234", ""},
+		{"main", ""},
+	}
+
+	for _, test := range tests {
+		got := version.LatestInMajor(suite.tags, test.current)
+		assert.Equal(suite.T(), test.expected, got, test.current)
+	}
+}
+
+func (suite *VersionPublicTestSuite) TestIsConstraint() {
+	tests := []struct {
+		version  string
+		expected bool
+	}{
+		{"^1.2", true},
+		{"~2.0.3", true},
+		{">=1.0 <2.0", true},
+		{">=1.0, <2.0", true},
+		{"1.x || 2.x", true},
+		{"v1.2.0", false},
+		{"1.1", false},
+		{"1.x", false},
+		{"abc1234", false},
+		{"main", false},
+		{"feature/foo", false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(suite.T(), test.expected, version.IsConstraint(test.version), test.version)
+	}
+}
+
+func (suite *VersionPublicTestSuite) TestValidate() {
+	assert.NoError(suite.T(), version.Validate("^1.2"))
+	assert.NoError(suite.T(), version.Validate(">=1.0 <2.0"))
+	assert.Error(suite.T(), version.Validate("^foo"))
+	assert.Error(suite.T(), version.Validate(">=1.0 <"))
+}
+
+func (suite *VersionPublicTestSuite) TestMatch() {
+	tests := []struct {
+		constraint string
+		expected   string
+	}{
+		{"^1.2", "v1.10.1"},
+		{"~1.2.0", "v1.2.0"},
+		{">=1.0 <2.0", "v1.10.1"},
+		{"^2", "v2.1.0"},
+		{">=2.0", "v2.1.0"},
+	}
+
+	for _, test := range tests {
+		got, err := version.Match(suite.tags, test.constraint)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), test.expected, got, test.constraint)
+	}
+}
+
+func (suite *VersionPublicTestSuite) TestMatchReturnsErrorWhenNothingMatches() {
+	_, err := version.Match(suite.tags, "^4.0")
+	assert.EqualError(suite.T(), err, "no tag satisfies version constraint ^4.0")
+}
+
+func (suite *VersionPublicTestSuite) TestMatchReturnsErrorOnInvalidConstraint() {
+	_, err := version.Match(suite.tags, "^foo")
+	assert.Error(suite.T(), err)
+}
+
+func (suite *VersionPublicTestSuite) TestUpgrade() {
+	tests := []struct {
+		current    string
+		constraint string
+		expected   string
+	}{
+		{"v1.2.0", "", "v2.1.0"},
+		{"1.0", "", "v2.1.0"},
+		{"v1.2.0", "^1.0", "v1.10.1"},
+		{"v1.10.1", "^1.0", ""},
+		{"v2.1.0", "", ""},
+		{"v4.0.0", "", ""},
+	}
+
+	for _, test := range tests {
+		got, err := version.Upgrade(suite.tags, test.current, test.constraint)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), test.expected, got, test.current)
+	}
+}
+
+func (suite *VersionPublicTestSuite) TestUpgradeReturnsErrorWhenNotSemver() {
+	_, err := version.Upgrade(suite.tags, "main", "")
+	assert.EqualError(suite.T(), err, "main is not a semantic version")
+}
+
+func (suite *VersionPublicTestSuite) TestUpgradeReturnsErrorOnInvalidConstraint() {
+	_, err := version.Upgrade(suite.tags, "v1.2.0", "^foo")
+	assert.Error(suite.T(), err)
+}
+
+// In order for `go test` to run this suite, we need to create
+// a normal test function and pass our suite to suite.Run.
+func TestVersionPublicTestSuite(t *testing.T) {
+	suite.Run(t, new(VersionPublicTestSuite))
+}
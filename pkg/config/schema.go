@@ -23,15 +23,79 @@ package config
 
 import (
 	"github.com/go-playground/validator/v10"
+
+	"github.com/retr0h/gilt/v2/internal/glob"
+	"github.com/retr0h/gilt/v2/internal/label"
+	"github.com/retr0h/gilt/v2/internal/version"
 )
 
 // regsiterValidatorsFn function to switch when testing
 var registerValidatorsFn = registerValidators
 
 // registerValidators register customer validators.
-func registerValidators(_ *validator.Validate) error {
-	// noop - hook for future validators
-	return nil
+func registerValidators(v *validator.Validate) error {
+	if err := v.RegisterValidation("version", validateVersion); err != nil {
+		return err
+	}
+	if err := v.RegisterValidation("unique_names", validateUniqueNames); err != nil {
+		return err
+	}
+	if err := v.RegisterValidation("label", validateLabel); err != nil {
+		return err
+	}
+	if err := v.RegisterValidation("label_expr", validateLabelExpr); err != nil {
+		return err
+	}
+
+	return v.RegisterValidation("glob", validateGlob)
+}
+
+// validateGlob a well formed include or exclude pattern.
+func validateGlob(fl validator.FieldLevel) bool {
+	return glob.Valid(fl.Field().String())
+}
+
+// validateLabel a well formed Repository label.
+func validateLabel(fl validator.FieldLevel) bool {
+	return label.ValidName(fl.Field().String())
+}
+
+// validateLabelExpr a well formed label expression.
+func validateLabelExpr(fl validator.FieldLevel) bool {
+	return label.Valid(fl.Field().String())
+}
+
+// validateUniqueNames no two Repository entries share a name.  Entries
+// without a name are not compared.
+func validateUniqueNames(fl validator.FieldLevel) bool {
+	repos, ok := fl.Field().Interface().([]Repository)
+	if !ok {
+		return false
+	}
+
+	seen := make(map[string]bool, len(repos))
+	for _, c := range repos {
+		if c.Name == "" {
+			continue
+		}
+		if seen[c.Name] {
+			return false
+		}
+		seen[c.Name] = true
+	}
+
+	return true
+}
+
+// validateVersion a version is either a commit-ish, which is checked by Git
+// at overlay time, or a well formed semantic version constraint.
+func validateVersion(fl validator.FieldLevel) bool {
+	v := fl.Field().String()
+	if !version.IsConstraint(v) {
+		return true
+	}
+
+	return version.Validate(v) == nil
 }
 
 // Validate validates a structs exposed fields.
@@ -23,6 +23,7 @@ package config
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -84,6 +85,155 @@ func (suite *SchemaTestSuite) TestRepositories() {
 			GiltFile: "giltFile",
 			GiltDir:  "giltDir",
 		}, "Key: 'Repositories.Repositories' Error:Field validation for 'Repositories' failed on the 'required' tag"},
+		{&Repositories{
+			GiltFile:   "giltFile",
+			GiltDir:    "giltDir",
+			GitBackend: GitBackendNative,
+			Repositories: []Repository{
+				{
+					Git:     "gitURL",
+					Version: "abc1234",
+					DstDir:  "dstDir",
+				},
+			},
+		}, ""},
+		{&Repositories{
+			GiltFile:   "giltFile",
+			GiltDir:    "giltDir",
+			GitBackend: "invalid",
+			Repositories: []Repository{
+				{
+					Git:     "gitURL",
+					Version: "abc1234",
+					DstDir:  "dstDir",
+				},
+			},
+		}, "Key: 'Repositories.GitBackend' Error:Field validation for 'GitBackend' failed on the 'oneof' tag"},
+		{&Repositories{
+			GiltFile: "giltFile",
+			GiltDir:  "giltDir",
+			Repositories: []Repository{
+				{
+					Name:    "etcd",
+					Git:     "gitURL",
+					Version: "abc1234",
+					DstDir:  "dstDir",
+				},
+				{
+					Git:     "gitURL",
+					Version: "abc1234",
+					DstDir:  "otherDir",
+				},
+				{
+					Git:     "gitURL",
+					Version: "abc1234",
+					DstDir:  "anotherDir",
+				},
+			},
+		}, ""},
+		{&Repositories{
+			GiltFile: "giltFile",
+			GiltDir:  "giltDir",
+			Repositories: []Repository{
+				{
+					Name:    "etcd",
+					Git:     "gitURL",
+					Version: "abc1234",
+					DstDir:  "dstDir",
+				},
+				{
+					Name:    "etcd",
+					Git:     "gitURL",
+					Version: "abc1234",
+					DstDir:  "otherDir",
+				},
+			},
+		}, "Key: 'Repositories.Repositories' Error:Field validation for 'Repositories' failed on the 'unique_names' tag"},
+		{&Repositories{
+			GiltFile: "giltFile",
+			GiltDir:  "giltDir",
+			Label:    "helm && !experimental",
+			Repositories: []Repository{
+				{
+					Git:     "gitURL",
+					Version: "abc1234",
+					DstDir:  "dstDir",
+					Labels:  []string{"helm"},
+				},
+			},
+		}, ""},
+		{&Repositories{
+			GiltFile: "giltFile",
+			GiltDir:  "giltDir",
+			Label:    "helm &&",
+			Repositories: []Repository{
+				{
+					Git:     "gitURL",
+					Version: "abc1234",
+					DstDir:  "dstDir",
+				},
+			},
+		}, "Key: 'Repositories.Label' Error:Field validation for 'Label' failed on the 'label_expr' tag"},
+		{&Repositories{
+			GiltFile: "giltFile",
+			GiltDir:  "giltDir",
+			Repositories: []Repository{
+				{
+					Git:      "gitURL",
+					Version:  "abc1234",
+					DstDir:   "dstDir",
+					Commands: []Command{{Cmd: ""}},
+				},
+			},
+		}, "Key: 'Repositories.Repositories[0].Commands[0].Cmd' Error:Field validation for 'Cmd' failed on the 'required' tag"},
+		{&Repositories{
+			GiltFile: "giltFile",
+			GiltDir:  "giltDir",
+			Repositories: []Repository{
+				{
+					Git:      "gitURL",
+					Version:  "abc1234",
+					DstDir:   "dstDir",
+					Commands: []Command{{Cmd: "make", Env: []string{"NOEQ"}}},
+				},
+			},
+		}, "Key: 'Repositories.Repositories[0].Commands[0].Env[0]' Error:Field validation for 'Env[0]' failed on the 'contains' tag"},
+		{&Repositories{
+			GiltFile: "giltFile",
+			GiltDir:  "giltDir",
+			Repositories: []Repository{
+				{
+					Git:         "gitURL",
+					Version:     "abc1234",
+					DstDir:      "dstDir",
+					PreCommands: []Command{{Cmd: ""}},
+				},
+			},
+		}, "Key: 'Repositories.Repositories[0].PreCommands[0].Cmd' Error:Field validation for 'Cmd' failed on the 'required' tag"},
+		{&Repositories{
+			GiltFile: "giltFile",
+			GiltDir:  "giltDir",
+			Repositories: []Repository{
+				{
+					Git:           "gitURL",
+					Version:       "abc1234",
+					DstDir:        "dstDir",
+					BuildCommands: []Command{{Cmd: "make", Env: []string{"=1"}}},
+				},
+			},
+		}, "Key: 'Repositories.Repositories[0].BuildCommands[0].Env[0]' Error:Field validation for 'Env[0]' failed on the 'startsnotwith' tag"},
+		{&Repositories{
+			GiltFile: "giltFile",
+			GiltDir:  "giltDir",
+			Repositories: []Repository{
+				{
+					Git:      "gitURL",
+					Version:  "abc1234",
+					DstDir:   "dstDir",
+					Commands: []Command{{Cmd: "make", Timeout: -1}},
+				},
+			},
+		}, "Key: 'Repositories.Repositories[0].Commands[0].Timeout' Error:Field validation for 'Timeout' failed on the 'gte' tag"},
 	}
 
 	// NOTE(nic): we have an entrypoint for validating this schema, so use it to
@@ -127,6 +277,47 @@ func (suite *SchemaTestSuite) TestSourceSchema() {
 			Src:    "src",
 			DstDir: "..",
 		}, "Key: 'Source.DstDir' Error:Field validation for 'DstDir' failed on the 'ne' tag"},
+		{&Source{
+			Src:    "roles/**/defaults/*.yml",
+			DstDir: "dstDir",
+			Base:   "roles",
+		}, ""},
+		{&Source{
+			Src:     "roles/**/defaults/main.yml",
+			DstFile: "dstFile",
+			Base:    "roles",
+		}, "Key: 'Source.Base' Error:Field validation for 'Base' failed on the 'excluded_with' tag"},
+		{&Source{
+			Src:     "src",
+			DstDir:  "dstDir",
+			Include: []string{"templates/**"},
+			Exclude: []string{"tests", "ci"},
+		}, ""},
+		{&Source{
+			Src:     "src",
+			DstDir:  "dstDir",
+			Exclude: []string{"tests/[a"},
+		}, "Key: 'Source.Exclude[0]' Error:Field validation for 'Exclude[0]' failed on the 'glob' tag"},
+		{&Source{
+			Src:     "src",
+			DstDir:  "dstDir",
+			Include: []string{""},
+		}, "Key: 'Source.Include[0]' Error:Field validation for 'Include[0]' failed on the 'required' tag"},
+		{&Source{
+			Src:    "library",
+			DstDir: "library",
+			Mode:   ModeSync,
+		}, ""},
+		{&Source{
+			Src:    "library",
+			DstDir: "library",
+			Mode:   "overwrite",
+		}, "Key: 'Source.Mode' Error:Field validation for 'Mode' failed on the 'oneof' tag"},
+		{&Source{
+			Src:     "library/etcd.py",
+			DstFile: "library/etcd.py",
+			Mode:    ModeMerge,
+		}, "Key: 'Source.Mode' Error:Field validation for 'Mode' failed on the 'excluded_with' tag"},
 	}
 
 	for _, test := range tests {
@@ -157,6 +348,28 @@ func (suite *SchemaTestSuite) TestCommandSchema() {
 		{&Command{
 			Args: []string{"bar", "baz"},
 		}, "Key: 'Command.Cmd' Error:Field validation for 'Cmd' failed on the 'required' tag"},
+		{&Command{
+			Cmd:   "make install",
+			Dir:   "roles/x",
+			Env:   []string{"ANSIBLE_NOCOLOR=1", "EMPTY="},
+			Shell: true,
+		}, ""},
+		{&Command{
+			Cmd: "foo",
+			Env: []string{"ANSIBLE_NOCOLOR"},
+		}, "Key: 'Command.Env[0]' Error:Field validation for 'Env[0]' failed on the 'contains' tag"},
+		{&Command{
+			Cmd: "foo",
+			Env: []string{"=1"},
+		}, "Key: 'Command.Env[0]' Error:Field validation for 'Env[0]' failed on the 'startsnotwith' tag"},
+		{&Command{
+			Cmd:     "foo",
+			Timeout: 30 * time.Second,
+		}, ""},
+		{&Command{
+			Cmd:     "foo",
+			Timeout: -time.Second,
+		}, "Key: 'Command.Timeout' Error:Field validation for 'Timeout' failed on the 'gte' tag"},
 	}
 
 	for _, test := range tests {
@@ -199,7 +412,61 @@ func (suite *SchemaTestSuite) TestRepositorySchema() {
 			Git:     "gitURL",
 			Version: "",
 			DstDir:  "dstDir",
-		}, "Key: 'Repository.Version' Error:Field validation for 'Version' failed on the 'required' tag"},
+		}, "Key: 'Repository.Version' Error:Field validation for 'Version' failed on the 'required_with' tag"},
+		{&Repository{
+			Archive: "https://example.com/release.tar.gz",
+			SHA256:  "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			DstDir:  "dstDir",
+		}, ""},
+		{&Repository{
+			Archive: "https://example.com/release.tar.gz",
+			DstDir:  "dstDir",
+		}, "Key: 'Repository.SHA256' Error:Field validation for 'SHA256' failed on the 'required_with' tag"},
+		{&Repository{
+			Archive: "https://example.com/release.tar.gz",
+			SHA256:  "9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08",
+			DstDir:  "dstDir",
+		}, "Key: 'Repository.SHA256' Error:Field validation for 'SHA256' failed on the 'lowercase' tag"},
+		{&Repository{
+			Archive: "release.tar.gz",
+			SHA256:  "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			DstDir:  "dstDir",
+		}, "Key: 'Repository.Archive' Error:Field validation for 'Archive' failed on the 'http_url' tag"},
+		{&Repository{
+			Git:     "gitURL",
+			Version: "abc1234",
+			Archive: "https://example.com/release.tar.gz",
+			SHA256:  "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			DstDir:  "dstDir",
+		}, "Key: 'Repository.Git' Error:Field validation for 'Git' failed on the 'excluded_with' tag\nKey: 'Repository.Version' Error:Field validation for 'Version' failed on the 'excluded_with' tag\nKey: 'Repository.Archive' Error:Field validation for 'Archive' failed on the 'excluded_with' tag\nKey: 'Repository.SHA256' Error:Field validation for 'SHA256' failed on the 'excluded_with' tag"},
+		{&Repository{
+			DstDir: "dstDir",
+		}, "Key: 'Repository.Git' Error:Field validation for 'Git' failed on the 'required_without_all' tag\nKey: 'Repository.Archive' Error:Field validation for 'Archive' failed on the 'required_without_all' tag\nKey: 'Repository.Path' Error:Field validation for 'Path' failed on the 'required_without_all' tag"},
+		{&Repository{
+			Path:   "../fork",
+			DstDir: "dstDir",
+		}, ""},
+		{&Repository{
+			Git:     "gitURL",
+			Version: "abc1234",
+			Path:    "../fork",
+			DstDir:  "dstDir",
+		}, "Key: 'Repository.Git' Error:Field validation for 'Git' failed on the 'excluded_with' tag\nKey: 'Repository.Version' Error:Field validation for 'Version' failed on the 'excluded_with' tag\nKey: 'Repository.Path' Error:Field validation for 'Path' failed on the 'excluded_with' tag"},
+		{&Repository{
+			Git:     "gitURL",
+			Version: "^1.2",
+			DstDir:  "dstDir",
+		}, ""},
+		{&Repository{
+			Git:     "gitURL",
+			Version: ">=1.0 <2.0",
+			DstDir:  "dstDir",
+		}, ""},
+		{&Repository{
+			Git:     "gitURL",
+			Version: "^foo",
+			DstDir:  "dstDir",
+		}, "Key: 'Repository.Version' Error:Field validation for 'Version' failed on the 'version' tag"},
 		{&Repository{
 			Git:     "gitURL",
 			Version: "abc1234",
@@ -221,6 +488,71 @@ func (suite *SchemaTestSuite) TestRepositorySchema() {
 			Version: "abc1234",
 			DstDir:  "..",
 		}, "Key: 'Repository.DstDir' Error:Field validation for 'DstDir' failed on the 'ne' tag"},
+		{&Repository{
+			Git:     "gitURL",
+			Version: "abc1234",
+			DstDir:  "dstDir",
+			Patches: []string{"patches/fix.patch"},
+		}, ""},
+		{&Repository{
+			Git:     "gitURL",
+			Version: "abc1234",
+			DstDir:  "dstDir",
+			Patches: []string{""},
+		}, "Key: 'Repository.Patches[0]' Error:Field validation for 'Patches[0]' failed on the 'required' tag"},
+		{&Repository{
+			Git:     "gitURL",
+			Version: "abc1234",
+			DstDir:  "dstDir",
+			Exclude: []string{".github"},
+		}, ""},
+		{&Repository{
+			Git:     "gitURL",
+			Version: "abc1234",
+			DstDir:  "dstDir",
+			Include: []string{"roles/[a"},
+		}, "Key: 'Repository.Include[0]' Error:Field validation for 'Include[0]' failed on the 'glob' tag"},
+		{&Repository{
+			Git:     "gitURL",
+			Version: "abc1234",
+			DstDir:  "dstDir",
+			Labels:  []string{"helm", "team/platform"},
+		}, ""},
+		{&Repository{
+			Git:     "gitURL",
+			Version: "abc1234",
+			DstDir:  "dstDir",
+			Labels:  []string{"helm charts"},
+		}, "Key: 'Repository.Labels[0]' Error:Field validation for 'Labels[0]' failed on the 'label' tag"},
+		{&Repository{
+			Git:     "gitURL",
+			Version: "abc1234",
+			Exclude: []string{".github"},
+			Sources: []Source{
+				{
+					Src:    "src",
+					DstDir: "dstDir",
+				},
+			},
+		}, "Key: 'Repository.Exclude' Error:Field validation for 'Exclude' failed on the 'excluded_with' tag"},
+		{&Repository{
+			Git:     "gitURL",
+			Version: "abc1234",
+			DstDir:  "library",
+			Mode:    ModeMerge,
+		}, ""},
+		{&Repository{
+			Git:     "gitURL",
+			Version: "abc1234",
+			DstDir:  "library",
+			Mode:    "overwrite",
+		}, "Key: 'Repository.Mode' Error:Field validation for 'Mode' failed on the 'oneof' tag"},
+		{&Repository{
+			Name:    "etcd,consul",
+			Git:     "gitURL",
+			Version: "abc1234",
+			DstDir:  "library",
+		}, "Key: 'Repository.Name' Error:Field validation for 'Name' failed on the 'excludesall' tag"},
 	}
 
 	for _, test := range tests {
@@ -20,50 +20,138 @@
 
 package config
 
+import (
+	"time"
+)
+
+const (
+	// GitBackendExec perform Git operations by running the git binary.
+	GitBackendExec = "exec"
+	// GitBackendNative perform Git operations in-process, without the git
+	// binary.
+	GitBackendNative = "native"
+)
+
+const (
+	// ModeReplace delete a destination directory before copying into it.
+	ModeReplace = "replace"
+	// ModeMerge copy into a destination directory, keeping what else is in it.
+	ModeMerge = "merge"
+	// ModeSync copy into a destination directory like ModeMerge, and delete
+	// the files an earlier overlay copied which are no longer produced.
+	ModeSync = "sync"
+)
+
 // Repositories perform repository operations.
 type Repositories struct {
 	// Debug enable or disable debug option set from CLI.
 	Debug bool `mapstruture:"debug"`
 	// Parallel enable or disable concurrent clone fetches.
 	Parallel bool `                           mapstructure:"parallel"`
-	// SkipCommands run post-commands as part of the overlay process
+	// SkipCommands skip pre-commands and post-commands during the overlay process
 	SkipCommands bool
+	// Locked refuse to overlay when a version resolves differently than
+	// recorded in the lock file.
+	Locked bool `                           mapstructure:"locked"`
+	// Prune delete files earlier overlays installed which the current overlay
+	// no longer produces.
+	Prune bool `                           mapstructure:"prune"`
 	// GiltFile path to Gilt's config file option set from CLI.
-	GiltFile string `                           mapstructure:"giltFile" validate:"required"`
+	GiltFile string `                           mapstructure:"giltFile"   validate:"required"`
 	// GiltDir path to Gilt's clone dir option set from CLI.
-	GiltDir string `                           mapstructure:"giltDir"  validate:"required"`
+	GiltDir string `                           mapstructure:"giltDir"    validate:"required"`
+	// Replace path to a file replacing Git repositories with local paths.
+	Replace string `                           mapstructure:"replace"`
+	// GitBackend how Git operations are performed, GitBackendExec when empty.
+	GitBackend string `                           mapstructure:"gitBackend" validate:"omitempty,oneof=exec native"`
+	// Only names of the only Repository entries to overlay; all of them when
+	// empty.
+	Only []string `                           mapstructure:"only"`
+	// Skip names of Repository entries not to overlay.
+	Skip []string `                           mapstructure:"skip"`
+	// Label expression the labels of Repository entries to overlay must
+	// match, such as `helm && !experimental`.
+	Label string `                           mapstructure:"label"      validate:"omitempty,label_expr"`
 	// Repositories a slice of repository configurations to overlay.
-	Repositories []Repository `mapstruture:"repositories"                         validate:"required,dive"`
+	Repositories []Repository `mapstruture:"repositories"                           validate:"required,unique_names,dive"`
 }
 
 // Source mapping of files and/or directories needing copied.
 type Source struct {
-	// Src source file or directory to copy.
+	// Src source file or directory to copy; a pattern in which `**` matches
+	// any number of directories.
 	Src string `mapstructure:"src"     validate:"required"`
+	// Base directory Src matches keep their path relative to, below DstDir,
+	// instead of being copied into DstDir by name.
+	Base string `mapstructure:"base"    validate:"excluded_with=DstFile"`
 	// DstFile destination of file copy.
 	DstFile string `mapstructure:"dstFile" validate:"required_without=DstDir,excluded_with=DstDir"`
 	// DstDir destination of directory copy.
 	DstDir string `mapstructure:"dstDir"  validate:"required_without=DstFile,excluded_with=DstFile,ne=.,ne=.."`
+	// Include patterns selecting which files below Src to copy; all of them
+	// when empty.
+	Include []string `mapstructure:"include" validate:"dive,required,glob"`
+	// Exclude patterns selecting which files below Src not to copy.
+	Exclude []string `mapstructure:"exclude" validate:"dive,required,glob"`
+	// Mode how DstDir is updated, ModeReplace when empty.
+	Mode string `mapstructure:"mode"    validate:"excluded_with=DstFile,omitempty,oneof=replace merge sync"`
 }
 
 //  Water string `validate:"required_without=Fire,excluded_with=Fire"`
 
 // Command command to execute.
 type Command struct {
-	Cmd  string   `mapstructure:"cmd"  validate:"required"`
+	Cmd  string   `mapstructure:"cmd"     validate:"required"`
 	Args []string `mapstructure:"args"`
+	// Dir working directory to run Cmd in, instead of the invocation directory.
+	Dir string `mapstructure:"dir"`
+	// Env `KEY=value` variables added to Cmd's environment.  A list, as Viper
+	// would lowercase the keys of a map.
+	Env []string `mapstructure:"env"     validate:"dive,required,contains==,startsnotwith=="`
+	// Shell run Cmd as a script with `sh -c`, and Args as its positional
+	// parameters.
+	Shell bool `mapstructure:"shell"`
+	// Timeout how long Cmd may run before it is killed, such as `30s`; no
+	// limit when zero.
+	Timeout time.Duration `mapstructure:"timeout" validate:"gte=0"`
 }
 
-// Repository contains the repository's details for cloning.
+// Repository contains the repository's details for cloning.  It is vendored
+// either from a Git repository, from a release archive, or from a local path.
 type Repository struct {
+	// Name optional name, unique within the Giltfile, to select the entry by.
+	Name string `mapstructure:"name"          validate:"excludesall=0x2C"`
+	// Labels grouping the entry with others, to select entries by.
+	Labels []string `mapstructure:"labels"        validate:"dive,label"`
 	// Git url of Git repository to clone.
-	Git string `mapstructure:"git"      validate:"required"`
-	// Version the commit SHA or tag to use.
-	Version string `mapstructure:"version"  validate:"required"`
+	Git string `mapstructure:"git"           validate:"required_without_all=Archive Path,excluded_with=Archive Path"`
+	// Version the commit SHA, branch, or tag to use, or a semantic version
+	// constraint resolved against the repository's tags.
+	Version string `mapstructure:"version"       validate:"required_with=Git,excluded_with=Archive Path,version"`
+	// Archive url of a tarball or zip file to download instead of cloning.
+	Archive string `mapstructure:"archive"       validate:"required_without_all=Git Path,excluded_with=Git Path,omitempty,http_url"`
+	// SHA256 checksum the downloaded Archive must match.
+	SHA256 string `mapstructure:"sha256"        validate:"required_with=Archive,excluded_with=Git Path,omitempty,len=64,hexadecimal,lowercase"`
+	// Path local directory, such as a checkout, to copy instead of cloning.
+	Path string `mapstructure:"path"          validate:"required_without_all=Git Archive,excluded_with=Git Archive"`
 	// DstDir destination directory to copy clone to.
-	DstDir string `mapstructure:"dstDir"   validate:"required_without=Sources,excluded_with=Sources,ne=.,ne=.."`
+	DstDir string `mapstructure:"dstDir"        validate:"required_without=Sources,excluded_with=Sources,ne=.,ne=.."`
+	// Include patterns selecting which files to copy to DstDir; all of them
+	// when empty.
+	Include []string `mapstructure:"include"       validate:"excluded_with=Sources,dive,required,glob"`
+	// Exclude patterns selecting which files not to copy to DstDir.
+	Exclude []string `mapstructure:"exclude"       validate:"excluded_with=Sources,dive,required,glob"`
+	// Mode how DstDir is updated, ModeReplace when empty.
+	Mode string `mapstructure:"mode"          validate:"excluded_with=Sources,omitempty,oneof=replace merge sync"`
 	// Sources containing files and/or directories to copy.
-	Sources []Source `mapstructure:"sources"  validate:"dive,required_without=DstDir,excluded_with=DstDir"`
+	Sources []Source `mapstructure:"sources"       validate:"dive,required_without=DstDir,excluded_with=DstDir"`
+	// Patches patch files applied, in order, to the extracted worktree.
+	Patches []string `mapstructure:"patches"       validate:"dive,required"`
+	// PreCommands commands to execute before any destination is touched.
+	PreCommands []Command `mapstructure:"preCommands"   validate:"dive"`
+	// BuildCommands commands to execute in the extracted worktree, before
+	// anything is copied out of it.
+	BuildCommands []Command `mapstructure:"buildCommands" validate:"dive"`
 	// Commands commands to execute on Repository.
-	Commands []Command `mapstructure:"commands"`
+	Commands []Command `mapstructure:"commands"      validate:"dive"`
 }
@@ -0,0 +1,104 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+// Package event defines the events gilt sends to an Observer as it works, so
+// callers may drive their own progress reporting or metrics.
+package event
+
+import (
+	"time"
+)
+
+// Type what happened.
+type Type string
+
+const (
+	// CloneStarted a Repository's clone is about to be fetched, or its archive
+	// downloaded.
+	CloneStarted Type = "cloneStarted"
+	// CloneFinished a Repository's clone was fetched.  Clone holds how it was
+	// obtained, as one of the report.Clone constants.
+	CloneFinished Type = "cloneFinished"
+	// FetchSkipped a Repository's clone was used without fetching anything;
+	// it was fetched earlier in the same run, is an archive unpacked before,
+	// or is a local path.
+	FetchSkipped Type = "fetchSkipped"
+	// WorktreeExtracted a Repository's worktree was extracted into Dir.
+	WorktreeExtracted Type = "worktreeExtracted"
+	// FileCopied File was copied into its destination, or written there by
+	// extracting the worktree.  Sent as each file is copied, in order.
+	FileCopied Type = "fileCopied"
+	// DirRemoved Dir was removed, either to be replaced, or because it is no
+	// longer overlaid.
+	DirRemoved Type = "dirRemoved"
+	// CommandStarted Cmd is about to run.
+	CommandStarted Type = "commandStarted"
+	// CommandFinished Cmd exited, or failed to run.
+	CommandFinished Type = "commandFinished"
+)
+
+// Event something gilt did to a Repository.
+type Event struct {
+	// Type what happened.
+	Type Type
+	// Time when it happened.
+	Time time.Time
+	// Name the Repository's name in the Giltfile, when it has one.
+	Name string
+	// Git url of the Git repository.
+	Git string
+	// Archive url of the archive, when not vendored from Git.
+	Archive string
+	// Path local directory, when not vendored from Git or an archive.
+	Path string
+	// Dir the directory cloned into, extracted into, removed, or which a
+	// command ran in.
+	Dir string
+	// File the file copied.
+	File string
+	// Clone how the clone was obtained; one of the report.Clone constants.
+	Clone string
+	// Stage when a command ran; one of the report.Stage constants.
+	Stage string
+	// Cmd the command line.
+	Cmd string
+	// ExitCode the command's exit status; -1 when it did not exit on its own,
+	// or could not be started.
+	ExitCode int
+	// Duration how long the clone, extraction, or command took.
+	Duration time.Duration
+	// Err why the clone, or command, failed.
+	Err error
+}
+
+// Observer receives the events of each operation.  Clones are fetched in
+// parallel, so Observe must be safe to call concurrently, and should return
+// quickly.
+type Observer interface {
+	Observe(e Event)
+}
+
+// ObserverFunc adapts a function to an Observer.
+type ObserverFunc func(e Event)
+
+// Observe calls `f` with `e`.
+func (f ObserverFunc) Observe(e Event) {
+	f(e)
+}
@@ -0,0 +1,236 @@
+// Copyright (c) 2026 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+// Package report defines the typed results gilt returns to its callers.
+package report
+
+import (
+	"time"
+)
+
+// Outdated compares a Repository's configured version with the tags
+// available upstream.
+type Outdated struct {
+	// Name the Repository's name in the Giltfile, when it has one.
+	Name string `json:"name,omitempty"`
+	// Git url of the Git repository.
+	Git string `json:"git"`
+	// Version the version configured in the Giltfile.
+	Version string `json:"version"`
+	// Latest the newest semantic version tag upstream.
+	Latest string `json:"latest"`
+	// LatestInMajor the newest semantic version tag upstream sharing the
+	// major version of Version.  Empty when Version is not a semantic version.
+	LatestInMajor string `json:"latestInMajor"`
+}
+
+// Update records a Repository whose version was bumped in the Giltfile.
+type Update struct {
+	// Name the Repository's name in the Giltfile, when it has one.
+	Name string `json:"name,omitempty"`
+	// Git url of the Git repository.
+	Git string `json:"git"`
+	// From the version previously configured in the Giltfile.
+	From string `json:"from"`
+	// To the version now configured in the Giltfile.
+	To string `json:"to"`
+}
+
+// How an overlay obtained a Repository's clone.
+const (
+	// CloneFresh the Repository was cloned, or its archive downloaded, anew.
+	CloneFresh = "fresh"
+	// CloneUpdated an existing clone was fetched.
+	CloneUpdated = "updated"
+	// CloneCached an archive unpacked earlier was used as it is.
+	CloneCached = "cached"
+	// CloneLocal a local path was used, which is never cloned.
+	CloneLocal = "local"
+)
+
+// Stages at which an overlay runs a Repository's commands.
+const (
+	// StagePre pre-commands, run before any destination is touched.
+	StagePre = "pre"
+	// StageBuild build commands, run in the extracted worktree.
+	StageBuild = "build"
+	// StagePost post-commands, run once the Repository is overlaid.
+	StagePost = "post"
+)
+
+// Overlay records what an overlay did to a Repository.
+type Overlay struct {
+	// Name the Repository's name in the Giltfile, when it has one.
+	Name string `json:"name,omitempty"`
+	// Git url of the Git repository.
+	Git string `json:"git"`
+	// Archive url of the archive, when not vendored from Git.
+	Archive string `json:"archive,omitempty"`
+	// Path local directory, when not vendored from Git or an archive.
+	Path string `json:"path,omitempty"`
+	// Version the version configured in the Giltfile.
+	Version string `json:"version"`
+	// Tag the tag a semantic version constraint in Version resolved to.
+	Tag string `json:"tag,omitempty"`
+	// Commit the commit SHA Version resolved to, or the archive's checksum.
+	Commit string `json:"commit"`
+	// Clone how the clone was obtained; one of the Clone constants.
+	Clone string `json:"clone"`
+	// Files files which were written.
+	Files []string `json:"files"`
+	// Replaced directories which were replaced wholesale.
+	Replaced []string `json:"replaced"`
+	// Commands commands which were run, in order.
+	Commands []Command `json:"commands"`
+	// Skipped stages which were skipped, such as StagePost when commands
+	// are skipped.
+	Skipped []string `json:"skipped"`
+}
+
+// Command records a command an overlay ran.
+type Command struct {
+	// Stage when the command ran; one of the Stage constants.
+	Stage string `json:"stage"`
+	// Cmd the command line.
+	Cmd string `json:"cmd"`
+	// Dir the directory the command ran in; the invocation directory when
+	// empty.
+	Dir string `json:"dir,omitempty"`
+	// ExitCode the command's exit status; -1 when it did not exit on its own,
+	// or could not be started.
+	ExitCode int `json:"exitCode"`
+	// Duration how long the command ran.
+	Duration time.Duration `json:"duration"`
+	// Error why the command failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Plan lists what an overlay of a Repository would change, without changing
+// anything.
+type Plan struct {
+	// Name the Repository's name in the Giltfile, when it has one.
+	Name string `json:"name,omitempty"`
+	// Git url of the Git repository.
+	Git string `json:"git"`
+	// Archive url of the archive, when not vendored from Git.
+	Archive string `json:"archive,omitempty"`
+	// Path local directory, when not vendored from Git or an archive.
+	Path string `json:"path,omitempty"`
+	// Version the version configured in the Giltfile.
+	Version string `json:"version"`
+	// Tag the tag a semantic version constraint in Version resolved to.
+	Tag string `json:"tag,omitempty"`
+	// Commit the commit SHA Version resolves to, or the archive's checksum.
+	Commit string `json:"commit"`
+	// Delete directories which would be removed before being replaced, and
+	// files a sync destination would no longer hold.
+	Delete []string `json:"delete"`
+	// Create files which would be created.
+	Create []string `json:"create"`
+	// Overwrite files which already exist, and would be replaced.
+	Overwrite []string `json:"overwrite"`
+	// PreCommands pre-commands which would be run.
+	PreCommands []string `json:"preCommands"`
+	// BuildCommands build commands which would be run in the worktree.
+	BuildCommands []string `json:"buildCommands"`
+	// Commands post-commands which would be run.
+	Commands []string `json:"commands"`
+}
+
+// Clean records what a clean removed of a Repository's overlay.
+type Clean struct {
+	// Name the Repository's name in the Giltfile, when it has one.
+	Name string `json:"name,omitempty"`
+	// Git url of the Git repository.
+	Git string `json:"git"`
+	// Archive url of the archive, when not vendored from Git.
+	Archive string `json:"archive,omitempty"`
+	// Path local directory, when not vendored from Git or an archive.
+	Path string `json:"path,omitempty"`
+	// Removed files and directories which were removed.
+	Removed []string `json:"removed"`
+	// Left directories which were left in place, as they still hold other
+	// files.
+	Left []string `json:"left"`
+}
+
+// Status lists the files in a Repository's destinations which differ from
+// what an overlay of its pinned version would produce.
+type Status struct {
+	// Name the Repository's name in the Giltfile, when it has one.
+	Name string `json:"name,omitempty"`
+	// Git url of the Git repository.
+	Git string `json:"git"`
+	// Archive url of the archive, when not vendored from Git.
+	Archive string `json:"archive,omitempty"`
+	// Path local directory, when not vendored from Git or an archive.
+	Path string `json:"path,omitempty"`
+	// Version the version configured in the Giltfile.
+	Version string `json:"version"`
+	// Tag the tag a semantic version constraint in Version resolved to.
+	Tag string `json:"tag,omitempty"`
+	// Commit the commit SHA Version resolves to, or the archive's checksum.
+	Commit string `json:"commit"`
+	// Modified files whose content differs from upstream.
+	Modified []string `json:"modified"`
+	// Deleted files upstream which are missing locally.
+	Deleted []string `json:"deleted"`
+	// Added files in a destination directory which are not upstream.
+	Added []string `json:"added"`
+}
+
+// Drifted reports whether any file differs from upstream.
+func (s Status) Drifted() bool {
+	return len(s.Modified)+len(s.Deleted)+len(s.Added) > 0
+}
+
+// CacheEntry describes a bare clone in the clone cache, or an unpacked
+// archive.
+type CacheEntry struct {
+	// Git url of the cloned Git repository.  Empty when it cannot be read,
+	// and for archives.
+	Git string `json:"git"`
+	// SHA256 checksum of an unpacked archive.  Empty for clones.
+	SHA256 string `json:"sha256,omitempty"`
+	// Dir path of the clone, or of the unpacked archive.
+	Dir string `json:"dir"`
+	// Size total size of the clone, in bytes.
+	Size int64 `json:"size"`
+	// LastFetch when the clone was last fetched, or the archive unpacked.
+	LastFetch time.Time `json:"lastFetch"`
+	// LastUsed when the clone was last used by gilt.  Zero when unknown.
+	LastUsed time.Time `json:"lastUsed"`
+	// Tags the tags in the clone; only listed when inspecting a clone.
+	Tags []string `json:"tags,omitempty"`
+}
+
+// CacheCheck the outcome of verifying a bare clone in the clone cache.
+type CacheCheck struct {
+	// Git url of the cloned Git repository.  Empty when it cannot be read.
+	Git string `json:"git"`
+	// Dir path of the clone.
+	Dir string `json:"dir"`
+	// OK whether `git fsck` found no problems.
+	OK bool `json:"ok"`
+	// Repaired whether a corrupt clone was cloned again.
+	Repaired bool `json:"repaired"`
+	// Error why the clone is corrupt, or could not be repaired.
+	Error string `json:"error,omitempty"`
+}
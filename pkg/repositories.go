@@ -21,7 +21,42 @@
 // Package pkg defines the public API interfaces for gilt.
 package pkg
 
+import (
+	"context"
+	"time"
+
+	"github.com/retr0h/gilt/v2/pkg/report"
+)
+
 // RepositoriesManager manager responsible for public Repositories operations.
 type RepositoriesManager interface {
 	Overlay() error
+	OverlayContext(ctx context.Context) error
+	OverlayReport() ([]report.Overlay, error)
+	OverlayReportContext(ctx context.Context) ([]report.Overlay, error)
+	Clean() error
+	CleanReport() ([]report.Clean, error)
+	Plan() ([]report.Plan, error)
+	PlanContext(ctx context.Context) ([]report.Plan, error)
+	Status() ([]report.Status, error)
+	StatusContext(ctx context.Context) ([]report.Status, error)
+	Outdated() ([]report.Outdated, error)
+	OutdatedContext(ctx context.Context) ([]report.Outdated, error)
+	Update(constraint string, repos []string) ([]report.Update, error)
+	UpdateContext(
+		ctx context.Context,
+		constraint string,
+		repos []string,
+	) ([]report.Update, error)
+	CacheList() ([]report.CacheEntry, error)
+	CacheListContext(ctx context.Context) ([]report.CacheEntry, error)
+	CacheInspect(name string) (report.CacheEntry, error)
+	CacheInspectContext(ctx context.Context, name string) (report.CacheEntry, error)
+	CacheVerify() ([]report.CacheCheck, error)
+	CacheVerifyContext(ctx context.Context) ([]report.CacheCheck, error)
+	CachePrune(unusedFor time.Duration) ([]report.CacheEntry, error)
+	CachePruneContext(
+		ctx context.Context,
+		unusedFor time.Duration,
+	) ([]report.CacheEntry, error)
 }
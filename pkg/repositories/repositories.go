@@ -22,6 +22,7 @@
 package repositories
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -31,19 +32,31 @@ import (
 	"github.com/avfs/avfs/vfs/osfs"
 	"github.com/danjacques/gofslock/fslock"
 
+	"github.com/retr0h/gilt/v2/internal"
+	"github.com/retr0h/gilt/v2/internal/archive"
+	"github.com/retr0h/gilt/v2/internal/cache"
 	"github.com/retr0h/gilt/v2/internal/exec"
 	"github.com/retr0h/gilt/v2/internal/git"
+	"github.com/retr0h/gilt/v2/internal/patch"
 	intPath "github.com/retr0h/gilt/v2/internal/path"
 	intRepos "github.com/retr0h/gilt/v2/internal/repositories"
 	"github.com/retr0h/gilt/v2/internal/repository"
 	"github.com/retr0h/gilt/v2/pkg/config"
+	"github.com/retr0h/gilt/v2/pkg/event"
+	"github.com/retr0h/gilt/v2/pkg/report"
 )
 
 // New factory to create a new Repository instance.
 func New(
 	c config.Repositories,
 	logger *slog.Logger,
+	opts ...Option,
 ) *Repositories {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	appFs := osfs.NewWithNoIdm()
 
 	copyManager := repository.NewCopy(
@@ -56,16 +69,34 @@ func New(
 		logger,
 	)
 
-	gitManager := git.New(
+	var gitManager internal.GitManager = git.New(
 		appFs,
 		execManager,
 		logger,
 	)
+	if c.GitBackend == config.GitBackendNative {
+		gitManager = git.NewNative(
+			appFs,
+			logger,
+		)
+	}
+
+	archiveManager := archive.New(
+		appFs,
+		logger,
+	)
+
+	patchManager := patch.New(
+		appFs,
+		logger,
+	)
 
 	repoManager := repository.New(
 		appFs,
 		copyManager,
 		gitManager,
+		archiveManager,
+		patchManager,
 		logger,
 	)
 
@@ -74,6 +105,14 @@ func New(
 		c,
 		repoManager,
 		execManager,
+		o.observer,
+		logger,
+	)
+
+	cacheManager := cache.New(
+		appFs,
+		repoManager,
+		gitManager,
 		logger,
 	)
 
@@ -81,10 +120,19 @@ func New(
 		appFs:        appFs,
 		c:            c,
 		reposManager: reposManager,
+		cacheManager: cacheManager,
 		logger:       logger,
 	}
 }
 
+// WithObserver send the events of each operation to `observer`, such as a
+// clone starting, or a command finishing.
+func WithObserver(observer event.Observer) Option {
+	return func(o *options) {
+		o.observer = observer
+	}
+}
+
 // getGiltDir create the GiltDir if it doesn't exist.
 func (r *Repositories) getGiltDir() (string, error) {
 	dir, err := intPath.ExpandUser(r.c.GiltDir)
@@ -99,9 +147,30 @@ func (r *Repositories) getGiltDir() (string, error) {
 	return dir, nil
 }
 
+// getCacheDir returns the clone cache under the GiltDir.
+func (r *Repositories) getCacheDir() (string, error) {
+	dir, err := r.getGiltDir()
+	if err != nil {
+		return "", err
+	}
+
+	return r.appFs.Join(dir, "cache"), nil
+}
+
+// getArchiveDir returns the dir holding unpacked archives under the GiltDir.
+func (r *Repositories) getArchiveDir() (string, error) {
+	dir, err := r.getGiltDir()
+	if err != nil {
+		return "", err
+	}
+
+	return r.appFs.Join(dir, "archives"), nil
+}
+
 // withLock is a convenience function to create a lock, execute a function while
-// holding that lock, and then release the lock on completion.
-func (r *Repositories) withLock(fn func() error) error {
+// holding that lock, and then release the lock on completion.  Waiting for the
+// lock stops once `ctx` is done.
+func (r *Repositories) withLock(ctx context.Context, fn func() error) error {
 	lockDir, err := r.getGiltDir()
 	if err != nil {
 		return err
@@ -109,7 +178,7 @@ func (r *Repositories) withLock(fn func() error) error {
 
 	blocker := func() error {
 		time.Sleep(time.Millisecond)
-		return nil
+		return ctx.Err()
 	}
 	lockFile := r.appFs.Join(lockDir, "gilt.lock")
 	r.logger.Info(
@@ -136,27 +205,46 @@ func (r *Repositories) logRepositoriesGroup() []any {
 			group := slog.Group(
 				strconv.Itoa(i),
 				slog.String("Src", s.Src),
+				slog.String("Base", s.Base),
 				slog.String("DstFile", s.DstFile),
 				slog.String("DstDir", s.DstDir),
+				slog.String("Mode", s.Mode),
 			)
 			sourceGroups = append(sourceGroups, group)
 		}
-		cmdGroups := make([]any, 0, len(repo.Commands))
-		for i, s := range repo.Commands {
-			group := slog.Group(
-				strconv.Itoa(i),
-				slog.String("Cmd", s.Cmd),
-			)
-			cmdGroups = append(cmdGroups, group)
-		}
 
 		group := slog.Group(
 			strconv.Itoa(i),
+			slog.String("Name", repo.Name),
+			slog.Any("Labels", repo.Labels),
 			slog.String("Git", repo.Git),
+			slog.String("Archive", repo.Archive),
+			slog.String("Path", repo.Path),
 			slog.String("Version", repo.Version),
 			slog.String("DstDir", repo.DstDir),
+			slog.String("Mode", repo.Mode),
 			slog.Group("Sources", sourceGroups...),
-			slog.Group("Commands", cmdGroups...),
+			slog.Group("PreCommands", logCommandsGroup(repo.PreCommands)...),
+			slog.Group("BuildCommands", logCommandsGroup(repo.BuildCommands)...),
+			slog.Group("Commands", logCommandsGroup(repo.Commands)...),
+		)
+		logGroups = append(logGroups, group)
+	}
+
+	return logGroups
+}
+
+// logCommandsGroup log Command config.
+func logCommandsGroup(commands []config.Command) []any {
+	logGroups := make([]any, 0, len(commands))
+
+	for i, s := range commands {
+		group := slog.Group(
+			strconv.Itoa(i),
+			slog.String("Cmd", s.Cmd),
+			slog.String("Dir", s.Dir),
+			slog.Bool("Shell", s.Shell),
+			slog.Duration("Timeout", s.Timeout),
 		)
 		logGroups = append(logGroups, group)
 	}
@@ -166,24 +254,308 @@ func (r *Repositories) logRepositoriesGroup() []any {
 
 // Overlay clone and extract the Repository items.
 func (r *Repositories) Overlay() error {
-	if err := r.withLock(func() error {
+	return r.OverlayContext(context.Background())
+}
+
+// OverlayContext clone and extract the Repository items.  Cancelling `ctx`
+// kills any git, or post-command, process still running, and rolls the
+// overlay back.
+func (r *Repositories) OverlayContext(ctx context.Context) error {
+	_, err := r.OverlayReportContext(ctx)
+	return err
+}
+
+// OverlayReport clone and extract the Repository items, and report what was
+// done to each.
+func (r *Repositories) OverlayReport() ([]report.Overlay, error) {
+	return r.OverlayReportContext(context.Background())
+}
+
+// OverlayReportContext clone and extract the Repository items, and report what
+// was done to each, stopping once `ctx` is done.  When the overlay fails, and
+// is rolled back, the report still covers the commands which ran.
+func (r *Repositories) OverlayReportContext(ctx context.Context) ([]report.Overlay, error) {
+	var results []report.Overlay
+	if err := r.withLock(ctx, func() error {
 		r.logger.Debug(
 			"current configuration",
 			slog.String("GiltDir", r.c.GiltDir),
 			slog.String("GiltFile", r.c.GiltFile),
+			slog.String("GitBackend", r.c.GitBackend),
+			slog.String("Replace", r.c.Replace),
 			slog.Bool("Debug", r.c.Debug),
 			slog.Bool("Parallel", r.c.Parallel),
+			slog.Bool("Locked", r.c.Locked),
+			slog.Bool("Prune", r.c.Prune),
+			slog.Any("Only", r.c.Only),
+			slog.Any("Skip", r.c.Skip),
+			slog.String("Label", r.c.Label),
 			slog.Group("Repository", r.logRepositoriesGroup()...),
 		)
 
-		return r.reposManager.Overlay()
+		var err error
+		results, err = r.reposManager.Overlay(ctx)
+		return err
 	}); err != nil {
 		r.logger.Error(
 			"error overlaying repositories",
 			slog.String("err", err.Error()),
 		)
+		return results, err
+	}
+
+	return results, nil
+}
+
+// Clean remove everything Overlay installed.
+func (r *Repositories) Clean() error {
+	_, err := r.CleanReport()
+	return err
+}
+
+// CleanReport remove everything Overlay installed, and report what was
+// removed of each Repository.
+func (r *Repositories) CleanReport() ([]report.Clean, error) {
+	var results []report.Clean
+	if err := r.withLock(context.Background(), func() error {
+		var err error
+		results, err = r.reposManager.Clean()
+		return err
+	}); err != nil {
+		r.logger.Error(
+			"error cleaning repositories",
+			slog.String("err", err.Error()),
+		)
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// Plan report what Overlay would change, without changing anything.
+func (r *Repositories) Plan() ([]report.Plan, error) {
+	return r.PlanContext(context.Background())
+}
+
+// PlanContext report what Overlay would change, stopping once `ctx` is done.
+func (r *Repositories) PlanContext(ctx context.Context) ([]report.Plan, error) {
+	var plans []report.Plan
+	if err := r.withLock(ctx, func() error {
+		var err error
+		plans, err = r.reposManager.Plan(ctx)
 		return err
+	}); err != nil {
+		r.logger.Error(
+			"error planning repositories",
+			slog.String("err", err.Error()),
+		)
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+// Status report the files which drifted from what Overlay would produce.
+func (r *Repositories) Status() ([]report.Status, error) {
+	return r.StatusContext(context.Background())
+}
+
+// StatusContext report the files which drifted from what Overlay would
+// produce, stopping once `ctx` is done.
+func (r *Repositories) StatusContext(ctx context.Context) ([]report.Status, error) {
+	var results []report.Status
+	if err := r.withLock(ctx, func() error {
+		var err error
+		results, err = r.reposManager.Status(ctx)
+		return err
+	}); err != nil {
+		r.logger.Error(
+			"error checking repository status",
+			slog.String("err", err.Error()),
+		)
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// Outdated compare each Repository's version with the newest tags upstream.
+func (r *Repositories) Outdated() ([]report.Outdated, error) {
+	return r.OutdatedContext(context.Background())
+}
+
+// OutdatedContext compare each Repository's version with the newest tags
+// upstream, stopping once `ctx` is done.
+func (r *Repositories) OutdatedContext(ctx context.Context) ([]report.Outdated, error) {
+	var results []report.Outdated
+	if err := r.withLock(ctx, func() error {
+		var err error
+		results, err = r.reposManager.Outdated(ctx)
+		return err
+	}); err != nil {
+		r.logger.Error(
+			"error checking for outdated repositories",
+			slog.String("err", err.Error()),
+		)
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// Update bump the version of the selected Repositories in the Giltfile.
+func (r *Repositories) Update(constraint string, repos []string) ([]report.Update, error) {
+	return r.UpdateContext(context.Background(), constraint, repos)
+}
+
+// UpdateContext bump the version of the selected Repositories in the
+// Giltfile, stopping once `ctx` is done.
+func (r *Repositories) UpdateContext(
+	ctx context.Context,
+	constraint string,
+	repos []string,
+) ([]report.Update, error) {
+	var results []report.Update
+	if err := r.withLock(ctx, func() error {
+		var err error
+		results, err = r.reposManager.Update(ctx, constraint, repos)
+		return err
+	}); err != nil {
+		r.logger.Error(
+			"error updating repositories",
+			slog.String("err", err.Error()),
+		)
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// CacheList describe each clone in the clone cache, and each unpacked archive.
+func (r *Repositories) CacheList() ([]report.CacheEntry, error) {
+	return r.CacheListContext(context.Background())
+}
+
+// CacheListContext describe each clone in the clone cache, and each unpacked
+// archive, stopping once `ctx` is done.
+func (r *Repositories) CacheListContext(ctx context.Context) ([]report.CacheEntry, error) {
+	var entries []report.CacheEntry
+	if err := r.withLock(ctx, func() error {
+		cacheDir, err := r.getCacheDir()
+		if err != nil {
+			return err
+		}
+		archiveDir, err := r.getArchiveDir()
+		if err != nil {
+			return err
+		}
+		entries, err = r.cacheManager.List(ctx, cacheDir, archiveDir)
+		return err
+	}); err != nil {
+		r.logger.Error(
+			"error listing cache",
+			slog.String("err", err.Error()),
+		)
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// CacheInspect describe the clone of the Git repository `name` in the clone
+// cache, along with its tags, or the unpacked archive with the checksum `name`.
+func (r *Repositories) CacheInspect(name string) (report.CacheEntry, error) {
+	return r.CacheInspectContext(context.Background(), name)
+}
+
+// CacheInspectContext describe the clone of the Git repository `name` in the
+// clone cache, along with its tags, or the unpacked archive with the checksum
+// `name`, stopping once `ctx` is done.
+func (r *Repositories) CacheInspectContext(
+	ctx context.Context,
+	name string,
+) (report.CacheEntry, error) {
+	var entry report.CacheEntry
+	if err := r.withLock(ctx, func() error {
+		cacheDir, err := r.getCacheDir()
+		if err != nil {
+			return err
+		}
+		archiveDir, err := r.getArchiveDir()
+		if err != nil {
+			return err
+		}
+		entry, err = r.cacheManager.Inspect(ctx, cacheDir, archiveDir, name)
+		return err
+	}); err != nil {
+		r.logger.Error(
+			"error inspecting cache",
+			slog.String("err", err.Error()),
+		)
+		return report.CacheEntry{}, err
+	}
+
+	return entry, nil
+}
+
+// CacheVerify check each clone in the clone cache, and repair corrupt ones.
+func (r *Repositories) CacheVerify() ([]report.CacheCheck, error) {
+	return r.CacheVerifyContext(context.Background())
+}
+
+// CacheVerifyContext check each clone in the clone cache, and repair corrupt
+// ones, stopping once `ctx` is done.
+func (r *Repositories) CacheVerifyContext(ctx context.Context) ([]report.CacheCheck, error) {
+	var checks []report.CacheCheck
+	if err := r.withLock(ctx, func() error {
+		cacheDir, err := r.getCacheDir()
+		if err != nil {
+			return err
+		}
+		checks, err = r.cacheManager.Verify(ctx, cacheDir)
+		return err
+	}); err != nil {
+		r.logger.Error(
+			"error verifying cache",
+			slog.String("err", err.Error()),
+		)
+		return nil, err
+	}
+
+	return checks, nil
+}
+
+// CachePrune remove each clone in the clone cache, and each unpacked archive,
+// unused for `unusedFor`.
+func (r *Repositories) CachePrune(unusedFor time.Duration) ([]report.CacheEntry, error) {
+	return r.CachePruneContext(context.Background(), unusedFor)
+}
+
+// CachePruneContext remove each clone in the clone cache, and each unpacked
+// archive, unused for `unusedFor`, stopping once `ctx` is done.
+func (r *Repositories) CachePruneContext(
+	ctx context.Context,
+	unusedFor time.Duration,
+) ([]report.CacheEntry, error) {
+	var pruned []report.CacheEntry
+	if err := r.withLock(ctx, func() error {
+		cacheDir, err := r.getCacheDir()
+		if err != nil {
+			return err
+		}
+		archiveDir, err := r.getArchiveDir()
+		if err != nil {
+			return err
+		}
+		pruned, err = r.cacheManager.Prune(ctx, cacheDir, archiveDir, unusedFor)
+		return err
+	}); err != nil {
+		r.logger.Error(
+			"error pruning cache",
+			slog.String("err", err.Error()),
+		)
+		return nil, err
 	}
 
-	return nil
+	return pruned, nil
 }
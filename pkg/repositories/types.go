@@ -27,6 +27,7 @@ import (
 
 	"github.com/retr0h/gilt/v2/internal"
 	"github.com/retr0h/gilt/v2/pkg/config"
+	"github.com/retr0h/gilt/v2/pkg/event"
 )
 
 // Repositories perform repository operations.
@@ -34,5 +35,14 @@ type Repositories struct {
 	appFs        avfs.VFS
 	c            config.Repositories
 	reposManager internal.RepositoriesManager
+	cacheManager internal.CacheManager
 	logger       *slog.Logger
 }
+
+// Option configures Repositories created by New.
+type Option func(o *options)
+
+// options optional settings of Repositories.
+type options struct {
+	observer event.Observer
+}
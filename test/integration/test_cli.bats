@@ -48,7 +48,9 @@ teardown() {
 	rm -rf ${GILT_LIBRARY_DIR}
 	rm -rf ${GILT_ROLES_DIR}
 	rm -rf ${GILT_TEST_DIR}
-	rm -f ${GILT_TEST_BASE_TMP_DIR}/Giltfile.yaml
+	rm -f ${GILT_TEST_BASE_TMP_DIR}/Giltfile.yaml ${GILT_TEST_BASE_TMP_DIR}/Giltfile.yaml.bak
+	rm -f ${GILT_TEST_BASE_TMP_DIR}/Giltfile.lock
+	rm -rf ${GILT_TEST_BASE_TMP_DIR}/.gilt
 	rm -f /tmp/initGiltfile.yaml
 }
 
@@ -68,6 +70,13 @@ teardown() {
 	echo "${output}" | jq '.version'
 }
 
+@test "invoke gilt version subcommand with text output" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; rm -f Giltfile.yaml; go run ${GILT_PROGRAM} --output text version"
+
+	[ "$status" -eq 0 ]
+	echo "${output}" | grep "GitVersion:"
+}
+
 @test "invoke gilt overlay subcommand" {
 	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} overlay"
 
@@ -170,6 +179,17 @@ teardown() {
 	[ "$status" = 0 ]
 }
 
+@test "invoke gilt overlay subcommand with native git backend" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} --git-backend native overlay"
+	[ "$status" -eq 0 ]
+
+	run stat ${GILT_CLONED_REPO_1_DST_DIR}
+	[ "$status" = 0 ]
+
+	run stat ${GILT_CLONED_REPO_2_DST_DIR}
+	[ "$status" = 0 ]
+}
+
 @test "invoke gilt overlay and copy sources" {
 	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} overlay"
 
@@ -215,6 +235,178 @@ teardown() {
 	[ "$status" = 0 ]
 }
 
+@test "invoke gilt overlay writes lock file" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} overlay"
+
+	[ "$status" -eq 0 ]
+	grep "commit: 77a95b7" ${GILT_TEST_BASE_TMP_DIR}/Giltfile.lock
+}
+
+@test "invoke gilt overlay with locked flag" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} overlay"
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} overlay --locked"
+
+	[ "$status" -eq 0 ]
+}
+
+@test "invoke gilt overlay with locked flag fails without lock file" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} overlay --locked"
+
+	[ "$status" -eq 1 ]
+	echo "${output}" | grep "missing from the lock file"
+}
+
+@test "invoke gilt overlay subcommand with dry-run flag" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} overlay --dry-run 2>/dev/null"
+
+	[ "$status" -eq 0 ]
+	echo "${output}" | grep "create     retr0h.ansible-etcd/"
+	echo "${output}" | grep "run        touch ansible-etcd-repo-post-command-1"
+
+	run stat ${GILT_CLONED_REPO_1_DST_DIR}
+	[ "$status" != 0 ]
+	run stat ${GILT_TEST_BASE_TMP_DIR}/Giltfile.lock
+	[ "$status" != 0 ]
+}
+
+@test "invoke gilt status subcommand" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} overlay"
+	[ "$status" -eq 0 ]
+
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} status 2>/dev/null"
+	[ "$status" -eq 0 ]
+	echo "${output}" | grep "no drift detected"
+}
+
+@test "invoke gilt status subcommand when destination modified" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} overlay"
+	[ "$status" -eq 0 ]
+	echo "local" >> ${GILT_LIBRARY_DIR}/nova_manage
+
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} status 2>/dev/null"
+	[ "$status" -eq 1 ]
+	echo "${output}" | grep "modified  library/nova_manage"
+}
+
+@test "invoke gilt clean subcommand" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} overlay"
+	[ "$status" -eq 0 ]
+	touch ${GILT_LIBRARY_DIR}/not-from-gilt
+
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} clean"
+	[ "$status" -eq 0 ]
+
+	run stat ${GILT_CLONED_REPO_1_DST_DIR}
+	[ "$status" != 0 ]
+	run stat ${GILT_LIBRARY_DIR}/nova_manage
+	[ "$status" != 0 ]
+	run stat ${GILT_LIBRARY_DIR}/not-from-gilt
+	[ "$status" = 0 ]
+	run stat ${GILT_TEST_BASE_TMP_DIR}/.gilt/Giltfile/manifest.json
+	[ "$status" != 0 ]
+}
+
+@test "invoke gilt clean subcommand with json output" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} overlay"
+	[ "$status" -eq 0 ]
+
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} --output json clean 2>/dev/null"
+	[ "$status" -eq 0 ]
+	echo "${output}" | jq -e '.[] | select(.git == "https://github.com/retr0h/ansible-etcd.git") | .removed | length > 0'
+}
+
+@test "invoke gilt overlay subcommand with json output" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} --output json overlay 2>/dev/null"
+
+	[ "$status" -eq 0 ]
+	echo "${output}" | jq -e '.[0].commit'
+	echo "${output}" | jq -e '.[0].clone'
+}
+
+@test "invoke gilt overlay subcommand with json output logs to stderr" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} --output json overlay 2>&1 >/dev/null"
+
+	[ "$status" -eq 0 ]
+	# Every line of stderr is a JSON log record
+	echo "${output}" | jq -e '.level and .msg'
+}
+
+@test "invoke gilt overlay subcommand with prune flag" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} overlay"
+	[ "$status" -eq 0 ]
+	sed -i.bak '/src: nova_quota/,+1d' ${GILT_TEST_BASE_TMP_DIR}/Giltfile.yaml
+
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} overlay --prune"
+	[ "$status" -eq 0 ]
+
+	run stat ${GILT_LIBRARY_DIR}/nova_quota
+	[ "$status" != 0 ]
+	run stat ${GILT_LIBRARY_DIR}/nova_manage
+	[ "$status" = 0 ]
+}
+
+@test "invoke gilt cache list subcommand" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} overlay"
+	[ "$status" -eq 0 ]
+
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} cache list 2>/dev/null"
+	[ "$status" -eq 0 ]
+	echo "${output}" | grep "https://github.com/retr0h/ansible-etcd.git"
+}
+
+@test "invoke gilt cache list subcommand without a Giltfile" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} --gilt-file missing.yaml cache list"
+
+	[ "$status" -eq 0 ]
+	echo "${output}" | grep "SOURCE"
+	[[ "${output}" != *"failed to read config"* ]]
+}
+
+@test "invoke gilt cache verify subcommand" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} overlay"
+	[ "$status" -eq 0 ]
+
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} cache verify 2>/dev/null"
+	[ "$status" -eq 0 ]
+	echo "${output}" | grep "ok        https://github.com/retr0h/ansible-etcd.git"
+}
+
+@test "invoke gilt cache prune subcommand with invalid duration" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} cache prune --unused-for soon"
+
+	[ "$status" -eq 1 ]
+	echo "${output}" | grep "invalid duration"
+}
+
+@test "invoke gilt outdated subcommand" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} outdated"
+
+	[ "$status" -eq 0 ]
+	echo "${output}" | grep "LATEST IN MAJOR"
+}
+
+@test "invoke gilt outdated subcommand with json output" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} --output json outdated 2>/dev/null"
+
+	[ "$status" -eq 0 ]
+	echo "${output}" | jq -e '.[0].latest'
+}
+
+@test "invoke gilt update subcommand" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} update 2>/dev/null"
+
+	[ "$status" -eq 0 ]
+	# Commit SHAs are left alone
+	grep "version: 77a95b7" ${GILT_TEST_BASE_TMP_DIR}/Giltfile.yaml
+}
+
+@test "invoke gilt update subcommand with unknown repository" {
+	run bash -c "cd ${GILT_TEST_BASE_TMP_DIR}; go run ${GILT_PROGRAM} update missing"
+
+	[ "$status" -eq 1 ]
+	echo "${output}" | grep "repository missing not found"
+}
+
 @test "invoke gilt client" {
 	run bash -c "cd ${GILT_TEST_CLIENT_DIR}; go mod tidy; go run main.go"
  	[ "$status" -eq 0 ]